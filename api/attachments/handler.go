@@ -0,0 +1,104 @@
+package attachments
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/maybemaby/workpad/api/utils"
+)
+
+// multipartOverhead leaves room for boundaries and part headers around the file
+const multipartOverhead = 64 << 10
+
+type AttachmentHandler struct {
+	store  AttachmentStore
+	limits Limits
+}
+
+func NewHandler(store AttachmentStore, limits Limits) *AttachmentHandler {
+	return &AttachmentHandler{store: store, limits: limits}
+}
+
+// UploadAttachment handles POST /attachments
+// Expects a multipart body with the blob in the "file" field
+func (h *AttachmentHandler) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, h.limits.MaxBytes+multipartOverhead)
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "Expected a multipart/form-data body", http.StatusBadRequest)
+		return
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				utils.WriteTooLarge(w, h.limits.MaxBytes)
+				return
+			}
+
+			http.Error(w, "Missing file field", http.StatusBadRequest)
+			return
+		}
+
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		attachment, err := h.store.Save(r.Context(), part, h.limits)
+		part.Close()
+
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+
+			switch {
+			case errors.Is(err, ErrTooLarge), errors.As(err, &maxBytesErr):
+				utils.WriteTooLarge(w, h.limits.MaxBytes)
+			case errors.Is(err, ErrUnsupportedType):
+				http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+			default:
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		utils.WriteJSON(w, r, attachment)
+		return
+	}
+}
+
+// GetAttachment handles GET /attachments/{hash}
+// Content never changes for a hash, so responses are cacheable forever, but only privately
+// since who may read it depends on the request's workspace
+func (h *AttachmentHandler) GetAttachment(w http.ResponseWriter, r *http.Request) {
+	hash := r.PathValue("hash")
+
+	attachment, file, err := h.store.Open(r.Context(), hash)
+	if err != nil {
+		if errors.Is(err, ErrAttachmentNotFound) {
+			http.Error(w, "Attachment not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	defer file.Close()
+
+	utils.WriteCacheControl(w, &utils.CacheControlOpts{
+		Private:   true,
+		MaxAge:    utils.IntPtr(31536000),
+		Immutable: true,
+	})
+
+	w.Header().Set("Content-Type", attachment.MimeType)
+	w.Header().Set("ETag", `"`+attachment.Hash+`"`)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	http.ServeContent(w, r, "", attachment.CreatedAt, file)
+}
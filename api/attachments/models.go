@@ -0,0 +1,54 @@
+package attachments
+
+import (
+	"errors"
+	"mime/multipart"
+	"time"
+)
+
+var (
+	ErrTooLarge           = errors.New("attachment is too large")
+	ErrUnsupportedType    = errors.New("attachment type is not allowed")
+	ErrAttachmentNotFound = errors.New("attachment not found")
+)
+
+type Attachment struct {
+	Hash      string    `json:"hash" required:"true" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	MimeType  string    `json:"mime_type" required:"true" db:"mime_type" example:"image/png"`
+	Size      int64     `json:"size" required:"true"`
+	URL       string    `json:"url" required:"true" db:"-" example:"/api/attachments/9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	CreatedAt time.Time `json:"created_at" required:"true" db:"created_at"`
+}
+
+type UploadAttachmentRequest struct {
+	File *multipart.FileHeader `formData:"file" required:"true"`
+}
+
+type GetAttachmentRequest struct {
+	Hash string `path:"hash" required:"true" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+}
+
+// Limits bounds what uploads are accepted
+type Limits struct {
+	MaxBytes     int64
+	AllowedTypes []string
+}
+
+// DefaultLimits accepts common screenshot and document formats up to 10 MiB
+// SVG is deliberately excluded, as it can carry script
+var DefaultLimits = Limits{
+	MaxBytes: 10 << 20,
+	AllowedTypes: []string{
+		"image/png",
+		"image/jpeg",
+		"image/gif",
+		"image/webp",
+		"application/pdf",
+		"text/plain; charset=utf-8",
+	},
+}
+
+// URLPath is where an attachment is served from, and what notes reference it by
+func URLPath(hash string) string {
+	return "/api/attachments/" + hash
+}
@@ -0,0 +1,254 @@
+package attachments
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/maybemaby/workpad/api/workspaces"
+)
+
+type AttachmentStore interface {
+	// Save stores the blob read from r, deduplicating by content hash
+	Save(ctx context.Context, r io.Reader, limits Limits) (Attachment, error)
+
+	// Open returns the attachment metadata and its blob, which the caller must close
+	// Attachments the workspace of ctx did not upload are not found
+	Open(ctx context.Context, hash string) (Attachment, *os.File, error)
+
+	// CollectGarbage removes attachments no note of the uploading workspace references
+	// that are older than grace
+	CollectGarbage(ctx context.Context, grace time.Duration) (int, error)
+}
+
+// AttachmentService stores blobs content-addressed on disk and their metadata in SQLite
+type AttachmentService struct {
+	db  *sqlx.DB
+	dir string
+	// mu keeps garbage collection from deleting a blob between Save storing it and recording it
+	mu sync.Mutex
+}
+
+func NewAttachmentService(db *sqlx.DB, dir string) *AttachmentService {
+	return &AttachmentService{db: db, dir: dir}
+}
+
+// blobPath shards blobs by the first two hex characters to keep directories small
+func (s *AttachmentService) blobPath(hash string) string {
+	return filepath.Join(s.dir, hash[:2], hash)
+}
+
+func validHash(hash string) bool {
+	if len(hash) != sha256.Size*2 {
+		return false
+	}
+
+	_, err := hex.DecodeString(hash)
+	return err == nil
+}
+
+func (s *AttachmentService) Save(ctx context.Context, r io.Reader, limits Limits) (Attachment, error) {
+	ws, err := workspaces.ID(ctx)
+	if err != nil {
+		return Attachment{}, err
+	}
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return Attachment{}, fmt.Errorf("failed to create attachments directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(s.dir, "upload-*")
+	if err != nil {
+		return Attachment{}, fmt.Errorf("failed to create temp file: %w", err)
+	}
+
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	// Sniff the type from the first bytes rather than trusting the client's header
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return Attachment{}, err
+	}
+	head = head[:n]
+
+	mimeType := http.DetectContentType(head)
+	if !slices.Contains(limits.AllowedTypes, mimeType) {
+		return Attachment{}, fmt.Errorf("%w: %s", ErrUnsupportedType, mimeType)
+	}
+
+	hasher := sha256.New()
+	writer := io.MultiWriter(tmp, hasher)
+
+	// Read one byte past the limit to tell an exact fit from an oversized upload
+	size, err := io.Copy(writer, io.LimitReader(io.MultiReader(bytes.NewReader(head), r), limits.MaxBytes+1))
+	if err != nil {
+		return Attachment{}, err
+	}
+
+	if size > limits.MaxBytes {
+		return Attachment{}, ErrTooLarge
+	}
+
+	if err := tmp.Close(); err != nil {
+		return Attachment{}, err
+	}
+
+	hash := hex.EncodeToString(hasher.Sum(nil))
+	path := s.blobPath(hash)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return Attachment{}, fmt.Errorf("failed to create attachments directory: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The blob is always replaced, the same bytes may have been collected since they were last uploaded
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return Attachment{}, fmt.Errorf("failed to store attachment: %w", err)
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Attachment{}, err
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO attachments (hash, mime_type, size) VALUES (?, ?, ?) ON CONFLICT (hash) DO NOTHING`, hash, mimeType, size)
+	if err != nil {
+		return Attachment{}, fmt.Errorf("failed to save attachment: %w", err)
+	}
+
+	// Re-uploading unreferenced bytes restarts their grace period, the client is about to embed them
+	_, err = tx.ExecContext(ctx, `INSERT INTO attachment_uploads (workspace_id, hash) VALUES (?, ?)
+		ON CONFLICT (workspace_id, hash) DO UPDATE SET created_at = CURRENT_TIMESTAMP`, ws, hash)
+	if err != nil {
+		return Attachment{}, fmt.Errorf("failed to save attachment: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Attachment{}, err
+	}
+
+	return s.get(ctx, ws, hash)
+}
+
+// get returns the attachment if workspace ws uploaded it, blobs uploaded by other workspaces are not found
+func (s *AttachmentService) get(ctx context.Context, ws int64, hash string) (Attachment, error) {
+	var attachment Attachment
+
+	err := s.db.GetContext(ctx, &attachment, `SELECT a.hash, a.mime_type, a.size, a.created_at FROM attachments a
+		JOIN attachment_uploads u ON u.hash = a.hash
+		WHERE u.workspace_id = ? AND a.hash = ?`, ws, hash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return attachment, ErrAttachmentNotFound
+		}
+		return attachment, fmt.Errorf("failed to get attachment: %w", err)
+	}
+
+	attachment.URL = URLPath(hash)
+
+	return attachment, nil
+}
+
+func (s *AttachmentService) Open(ctx context.Context, hash string) (Attachment, *os.File, error) {
+	if !validHash(hash) {
+		return Attachment{}, nil, ErrAttachmentNotFound
+	}
+
+	ws, err := workspaces.ID(ctx)
+	if err != nil {
+		return Attachment{}, nil, err
+	}
+
+	attachment, err := s.get(ctx, ws, hash)
+	if err != nil {
+		return Attachment{}, nil, err
+	}
+
+	file, err := os.Open(s.blobPath(hash))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return Attachment{}, nil, ErrAttachmentNotFound
+		}
+		return Attachment{}, nil, err
+	}
+
+	return attachment, file, nil
+}
+
+// CollectGarbage removes attachments no note of the uploading workspace references that are older than grace
+// The grace period keeps fresh uploads alive until the note embedding them is saved. Notes
+// only keep an upload alive for their own workspace, a blob goes once no workspace has it
+func (s *AttachmentService) CollectGarbage(ctx context.Context, grace time.Duration) (int, error) {
+	var hashes []string
+
+	cutoff := time.Now().UTC().Add(-grace).Format(time.DateTime)
+
+	_, err := s.db.ExecContext(ctx, `DELETE FROM attachment_uploads WHERE created_at < ?
+		AND NOT EXISTS (SELECT 1 FROM note_attachments na JOIN notes n ON n.id = na.note_id
+			WHERE na.hash = attachment_uploads.hash AND n.workspace_id = attachment_uploads.workspace_id)`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire unreferenced uploads: %w", err)
+	}
+
+	err = s.db.SelectContext(ctx, &hashes, `SELECT a.hash FROM attachments a
+		WHERE NOT EXISTS (SELECT 1 FROM attachment_uploads u WHERE u.hash = a.hash)`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find unreferenced attachments: %w", err)
+	}
+
+	removed := 0
+
+	for _, hash := range hashes {
+		deleted, err := s.removeUnreferenced(ctx, hash)
+		if err != nil {
+			return removed, err
+		}
+
+		if deleted {
+			removed++
+		}
+	}
+
+	return removed, nil
+}
+
+// removeUnreferenced deletes an attachment and its blob if no workspace has uploaded it
+func (s *AttachmentService) removeUnreferenced(ctx context.Context, hash string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Re-check inside the delete in case it was uploaded again meanwhile
+	result, err := s.db.ExecContext(ctx, `DELETE FROM attachments WHERE hash = ?
+		AND NOT EXISTS (SELECT 1 FROM attachment_uploads WHERE hash = ?)`, hash, hash)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete attachment: %w", err)
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return false, nil
+	}
+
+	if err := os.Remove(s.blobPath(hash)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, fmt.Errorf("failed to delete attachment blob: %w", err)
+	}
+
+	return true, nil
+}
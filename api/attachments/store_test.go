@@ -0,0 +1,200 @@
+package attachments
+
+import (
+	"bytes"
+	"database/sql"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/maybemaby/workpad/api/utils"
+	"github.com/maybemaby/workpad/api/workspaces"
+	"github.com/stretchr/testify/suite"
+	_ "modernc.org/sqlite"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n0000IHDR")
+
+type AttachmentStoreSuite struct {
+	suite.Suite
+	db  *sql.DB
+	dbx *sqlx.DB
+	dir string
+}
+
+func (s *AttachmentStoreSuite) SetupTest() {
+	s.db, _ = sql.Open("sqlite", ":memory:")
+	s.db.SetMaxOpenConns(1)
+	s.dbx = sqlx.NewDb(s.db, "sqlite")
+	s.dir = s.T().TempDir()
+
+	err := utils.SetupSqliteDb(s.db)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (s *AttachmentStoreSuite) TearDownTest() {
+	s.db.Close()
+}
+
+func (s *AttachmentStoreSuite) TestSave_Deduplicates() {
+	store := NewAttachmentService(s.dbx, s.dir)
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+
+	first, err := store.Save(ctx, bytes.NewReader(pngHeader), DefaultLimits)
+	s.Require().NoError(err)
+	s.Equal("image/png", first.MimeType)
+	s.Equal(int64(len(pngHeader)), first.Size)
+	s.Equal(URLPath(first.Hash), first.URL)
+
+	second, err := store.Save(ctx, bytes.NewReader(pngHeader), DefaultLimits)
+	s.Require().NoError(err)
+	s.Equal(first.Hash, second.Hash)
+
+	_, file, err := store.Open(ctx, first.Hash)
+	s.Require().NoError(err)
+	file.Close()
+}
+
+func (s *AttachmentStoreSuite) TestSave_Limits() {
+	store := NewAttachmentService(s.dbx, s.dir)
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+
+	_, err := store.Save(ctx, strings.NewReader("<html><script>alert(1)</script></html>"), DefaultLimits)
+	s.ErrorIs(err, ErrUnsupportedType)
+
+	_, err = store.Save(ctx, bytes.NewReader(pngHeader), Limits{MaxBytes: 8, AllowedTypes: DefaultLimits.AllowedTypes})
+	s.ErrorIs(err, ErrTooLarge)
+}
+
+func (s *AttachmentStoreSuite) TestCollectGarbage() {
+	store := NewAttachmentService(s.dbx, s.dir)
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+
+	kept, err := store.Save(ctx, bytes.NewReader(pngHeader), DefaultLimits)
+	s.Require().NoError(err)
+
+	orphan, err := store.Save(ctx, strings.NewReader("plain text"), DefaultLimits)
+	s.Require().NoError(err)
+
+	var noteId int
+	err = s.dbx.QueryRowContext(ctx, "INSERT INTO notes (html_content, note_date) VALUES (?, '2026-01-01') RETURNING id", `<img src="`+kept.URL+`">`).Scan(&noteId)
+	s.Require().NoError(err)
+	s.dbx.MustExecContext(ctx, "INSERT INTO note_attachments (note_id, hash) VALUES (?, ?)", noteId, kept.Hash)
+
+	// Nothing is old enough to collect yet
+	removed, err := store.CollectGarbage(ctx, time.Hour)
+	s.NoError(err)
+	s.Equal(0, removed)
+
+	removed, err = store.CollectGarbage(ctx, -time.Minute)
+	s.NoError(err)
+	s.Equal(1, removed)
+
+	_, _, err = store.Open(ctx, orphan.Hash)
+	s.ErrorIs(err, ErrAttachmentNotFound)
+
+	_, err = os.Stat(store.blobPath(orphan.Hash))
+	s.ErrorIs(err, os.ErrNotExist)
+
+	_, file, err := store.Open(ctx, kept.Hash)
+	s.Require().NoError(err)
+	file.Close()
+}
+
+func (s *AttachmentStoreSuite) TestCollectGarbage_KeepsReuploads() {
+	store := NewAttachmentService(s.dbx, s.dir)
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+
+	first, err := store.Save(ctx, bytes.NewReader(pngHeader), DefaultLimits)
+	s.Require().NoError(err)
+
+	// The first upload was never embedded and its blob was lost
+	s.dbx.MustExecContext(ctx, "UPDATE attachment_uploads SET created_at = datetime('now', '-2 hours') WHERE hash = ?", first.Hash)
+	s.Require().NoError(os.Remove(store.blobPath(first.Hash)))
+
+	second, err := store.Save(ctx, bytes.NewReader(pngHeader), DefaultLimits)
+	s.Require().NoError(err)
+	s.Equal(first.Hash, second.Hash)
+
+	removed, err := store.CollectGarbage(ctx, time.Hour)
+	s.NoError(err)
+	s.Equal(0, removed)
+
+	_, file, err := store.Open(ctx, second.Hash)
+	s.Require().NoError(err)
+	file.Close()
+}
+
+func (s *AttachmentStoreSuite) TestOpen_ScopedToWorkspace() {
+	store := NewAttachmentService(s.dbx, s.dir)
+	s.dbx.MustExecContext(s.T().Context(), `INSERT INTO workspaces (id, name) VALUES (2, 'Team')`)
+	own := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+	team := workspaces.WithID(s.T().Context(), 2)
+
+	attachment, err := store.Save(own, bytes.NewReader(pngHeader), DefaultLimits)
+	s.Require().NoError(err)
+
+	// Knowing the hash is not enough to read another workspace's blob
+	_, _, err = store.Open(team, attachment.Hash)
+	s.ErrorIs(err, ErrAttachmentNotFound)
+
+	_, _, err = store.Open(s.T().Context(), attachment.Hash)
+	s.ErrorIs(err, workspaces.ErrNoWorkspace)
+
+	// Uploading the same bytes shares the blob
+	_, err = store.Save(team, bytes.NewReader(pngHeader), DefaultLimits)
+	s.Require().NoError(err)
+
+	_, file, err := store.Open(team, attachment.Hash)
+	s.Require().NoError(err)
+	file.Close()
+}
+
+func (s *AttachmentStoreSuite) TestCollectGarbage_PerWorkspace() {
+	store := NewAttachmentService(s.dbx, s.dir)
+	s.dbx.MustExecContext(s.T().Context(), `INSERT INTO workspaces (id, name) VALUES (2, 'Team')`)
+	own := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+	team := workspaces.WithID(s.T().Context(), 2)
+
+	attachment, err := store.Save(own, bytes.NewReader(pngHeader), DefaultLimits)
+	s.Require().NoError(err)
+
+	_, err = store.Save(team, bytes.NewReader(pngHeader), DefaultLimits)
+	s.Require().NoError(err)
+
+	// Only the team's note references the blob
+	var noteId int
+	err = s.dbx.QueryRowContext(s.T().Context(), "INSERT INTO notes (workspace_id, html_content, note_date) VALUES (2, ?, '2026-01-01') RETURNING id", `<img src="`+attachment.URL+`">`).Scan(&noteId)
+	s.Require().NoError(err)
+	s.dbx.MustExecContext(s.T().Context(), "INSERT INTO note_attachments (note_id, hash) VALUES (?, ?)", noteId, attachment.Hash)
+
+	removed, err := store.CollectGarbage(s.T().Context(), -time.Minute)
+	s.NoError(err)
+	s.Equal(0, removed)
+
+	_, _, err = store.Open(own, attachment.Hash)
+	s.ErrorIs(err, ErrAttachmentNotFound)
+
+	_, file, err := store.Open(team, attachment.Hash)
+	s.Require().NoError(err)
+	file.Close()
+
+	// Once the team stops referencing it the blob goes too
+	s.dbx.MustExecContext(s.T().Context(), "DELETE FROM note_attachments WHERE note_id = ?", noteId)
+
+	removed, err = store.CollectGarbage(s.T().Context(), -time.Minute)
+	s.NoError(err)
+	s.Equal(1, removed)
+
+	_, err = os.Stat(store.blobPath(attachment.Hash))
+	s.ErrorIs(err, os.ErrNotExist)
+}
+
+func TestAttachmentStoreSuite(t *testing.T) {
+	suite.Run(t, new(AttachmentStoreSuite))
+}
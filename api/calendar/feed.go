@@ -0,0 +1,150 @@
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/maybemaby/workpad/api/notes"
+	"github.com/maybemaby/workpad/api/projects"
+	"github.com/maybemaby/workpad/api/reminders"
+)
+
+// uidDomain keeps UIDs globally unique as RFC 5545 recommends
+const uidDomain = "workpad"
+
+// summaryLength is how much of a note is used as its event title
+const summaryLength = 80
+
+// Feed renders notes, open reminders and project target dates as an iCalendar document
+type Feed struct {
+	notes     notes.NoteStore
+	projects  projects.ProjectStore
+	reminders reminders.ReminderStore
+}
+
+func NewFeed(notes notes.NoteStore, projects projects.ProjectStore, reminders reminders.ReminderStore) *Feed {
+	return &Feed{notes: notes, projects: projects, reminders: reminders}
+}
+
+// uid builds an identifier that stays the same across fetches so clients update entries in place
+func uid(kind string, key string) string {
+	return fmt.Sprintf("%s-%s@%s", kind, url.PathEscape(key), uidDomain)
+}
+
+func truncate(text string, max int) string {
+	runes := []rune(text)
+
+	if len(runes) <= max {
+		return text
+	}
+
+	return string(runes[:max-1]) + "…"
+}
+
+// Write renders the feed to w, only including entries for projectName when it is set
+func (f *Feed) Write(ctx context.Context, w io.Writer, projectName string, now time.Time) error {
+	noteList, err := f.notes.GetNotes(ctx, projectName)
+	if err != nil {
+		return fmt.Errorf("failed to get notes: %w", err)
+	}
+
+	open, err := f.reminders.ListOpen(ctx, projectName)
+	if err != nil {
+		return err
+	}
+
+	var projectList []projects.Project
+
+	if projectName != "" {
+		project, err := f.projects.GetByName(ctx, projectName)
+		if err != nil {
+			return err
+		}
+		projectList = []projects.Project{*project}
+	} else {
+		projectList, err = f.projects.GetAll(ctx, "")
+		if err != nil {
+			return err
+		}
+	}
+
+	cal := NewWriter(w)
+
+	cal.Begin("VCALENDAR")
+	cal.Line("VERSION", "2.0")
+	cal.Line("PRODID", "-//workpad//workpad//EN")
+	cal.Line("CALSCALE", "GREGORIAN")
+	cal.Line("METHOD", "PUBLISH")
+	cal.Text("X-WR-CALNAME", calendarName(projectName))
+
+	for _, note := range noteList {
+		text := notes.PlainText(note.HTMLContent)
+		day := note.Date.Format(time.DateOnly)
+
+		summary := "Notes"
+		if text != "" {
+			summary = truncate(text, summaryLength)
+		}
+
+		cal.Begin("VEVENT")
+		cal.Line("UID", uid("note", day))
+		cal.DateTime("DTSTAMP", now)
+		cal.Date("DTSTART", note.Date)
+		cal.Date("DTEND", note.Date.AddDate(0, 0, 1))
+		cal.Text("SUMMARY", summary)
+		cal.Text("DESCRIPTION", notes.Snippet(text))
+		cal.Line("TRANSP", "TRANSPARENT")
+		cal.End("VEVENT")
+	}
+
+	for _, reminder := range open {
+		due, err := time.Parse(time.DateOnly, reminder.DueDate)
+		if err != nil {
+			continue
+		}
+
+		cal.Begin("VTODO")
+		cal.Line("UID", uid("reminder", fmt.Sprint(reminder.Id)))
+		cal.DateTime("DTSTAMP", now)
+		cal.DateTime("CREATED", reminder.CreatedAt)
+		cal.Date("DUE", due)
+		cal.Text("SUMMARY", reminder.Text)
+		cal.Text("DESCRIPTION", "From notes on "+reminder.NoteDate)
+		if reminder.ProjectName != nil {
+			cal.Text("CATEGORIES", *reminder.ProjectName)
+		}
+		cal.Line("STATUS", "NEEDS-ACTION")
+		cal.End("VTODO")
+	}
+
+	for _, project := range projectList {
+		if project.TargetDate == nil {
+			continue
+		}
+
+		cal.Begin("VEVENT")
+		cal.Line("UID", uid("project", project.Name))
+		cal.DateTime("DTSTAMP", now)
+		cal.Date("DTSTART", *project.TargetDate)
+		cal.Date("DTEND", project.TargetDate.AddDate(0, 0, 1))
+		cal.Text("SUMMARY", project.Name+" target date")
+		cal.Text("CATEGORIES", project.Name)
+		cal.Line("TRANSP", "TRANSPARENT")
+		cal.End("VEVENT")
+	}
+
+	cal.End("VCALENDAR")
+
+	return cal.Err()
+}
+
+func calendarName(projectName string) string {
+	if projectName == "" {
+		return "workpad"
+	}
+
+	return "workpad: " + projectName
+}
@@ -0,0 +1,125 @@
+package calendar
+
+import (
+	"bytes"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/maybemaby/workpad/api/notes"
+	"github.com/maybemaby/workpad/api/projects"
+	"github.com/maybemaby/workpad/api/reminders"
+	"github.com/maybemaby/workpad/api/utils"
+	"github.com/maybemaby/workpad/api/workspaces"
+	"github.com/stretchr/testify/suite"
+	_ "modernc.org/sqlite"
+)
+
+func mustParseDate(value string) time.Time {
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		panic(err)
+	}
+
+	return t
+}
+
+type FeedSuite struct {
+	suite.Suite
+	db       *sql.DB
+	dbx      *sqlx.DB
+	notes    *notes.NoteService
+	projects *projects.SqliteStore
+	feed     *Feed
+}
+
+func (s *FeedSuite) SetupTest() {
+	s.db, _ = sql.Open("sqlite", ":memory:")
+	s.db.SetMaxOpenConns(1)
+	s.dbx = sqlx.NewDb(s.db, "sqlite")
+
+	err := utils.SetupSqliteDb(s.db)
+
+	if err != nil {
+		panic(err)
+	}
+
+	s.notes = notes.NewNoteService(s.dbx)
+	s.projects = projects.NewSqliteStore(s.dbx)
+	s.feed = NewFeed(s.notes, s.projects, reminders.NewReminderService(s.dbx))
+
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+
+	_, err = s.projects.CreateMultiple(ctx, []string{"OKT123", "Other"})
+	s.Require().NoError(err)
+
+	target := mustParseDate("2026-12-01")
+	_, err = s.projects.SetTargetDate(ctx, "OKT123", &target)
+	s.Require().NoError(err)
+
+	_, err = s.notes.CreateNote(ctx, `<ul data-type="taskList"><li data-type="taskItem" data-checked="false"><p><span data-type="mention" data-id="OKT123">@OKT123</span> follow up with vendor, legal; finance by 2026-11-02</p></li></ul>`, mustParseDate("2026-10-19"))
+	s.Require().NoError(err)
+
+	_, err = s.notes.CreateNote(ctx, "<p>"+strings.Repeat("Long day of meetings ", 10)+"</p>", mustParseDate("2026-10-20"))
+	s.Require().NoError(err)
+
+	_, err = s.notes.UpdateExcerptsForDate(ctx, mustParseDate("2026-10-19"), []notes.ExcerptNode{{Projects: []string{"OKT123"}, Node: `{"type":"paragraph","content":[{"type":"text","text":"follow up"}]}`}}, notes.SaveExcerptsOptions{})
+	s.Require().NoError(err)
+}
+
+func (s *FeedSuite) TearDownTest() {
+	s.db.Close()
+}
+
+func (s *FeedSuite) render(project string) string {
+	var buf bytes.Buffer
+
+	err := s.feed.Write(workspaces.WithID(s.T().Context(), workspaces.DefaultID), &buf, project, time.Date(2026, 10, 21, 9, 0, 0, 0, time.UTC))
+	s.Require().NoError(err)
+
+	return buf.String()
+}
+
+func (s *FeedSuite) TestWrite_AllEntries() {
+	out := s.render("")
+
+	s.True(strings.HasPrefix(out, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	s.True(strings.HasSuffix(out, "END:VCALENDAR\r\n"))
+
+	s.Contains(out, "UID:note-2026-10-19@workpad\r\n")
+	s.Contains(out, "UID:note-2026-10-20@workpad\r\n")
+	s.Contains(out, "DTSTART;VALUE=DATE:20261019\r\n")
+	s.Contains(out, "DUE;VALUE=DATE:20261102\r\n")
+	s.Contains(out, `vendor\, legal\; finance`)
+	s.Contains(out, "UID:project-OKT123@workpad\r\n")
+	s.Contains(out, "DTSTART;VALUE=DATE:20261201\r\n")
+	s.Equal(1, strings.Count(out, "BEGIN:VTODO"))
+
+	for line := range strings.SplitSeq(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		s.LessOrEqual(len(line), maxLineOctets, line)
+	}
+}
+
+func (s *FeedSuite) TestWrite_StableAcrossFetches() {
+	s.Equal(s.render(""), s.render(""))
+}
+
+func (s *FeedSuite) TestWrite_FiltersByProject() {
+	out := s.render("OKT123")
+
+	s.Contains(out, "UID:note-2026-10-19@workpad\r\n")
+	s.NotContains(out, "UID:note-2026-10-20@workpad")
+	s.Contains(out, "BEGIN:VTODO")
+	s.Contains(out, "UID:project-OKT123@workpad\r\n")
+
+	out = s.render("Other")
+
+	s.NotContains(out, "BEGIN:VEVENT")
+	s.NotContains(out, "BEGIN:VTODO")
+}
+
+func TestFeedSuite(t *testing.T) {
+	suite.Run(t, new(FeedSuite))
+}
@@ -0,0 +1,37 @@
+package calendar
+
+import (
+	"bytes"
+	"net/http"
+	"time"
+)
+
+type CalendarHandler struct {
+	feed *Feed
+}
+
+func NewCalendarHandler(feed *Feed) *CalendarHandler {
+	return &CalendarHandler{feed: feed}
+}
+
+// GetCalendar handles GET /calendar.ics
+func (h *CalendarHandler) GetCalendar(w http.ResponseWriter, r *http.Request) {
+	project := r.URL.Query().Get("project")
+
+	// Render fully before writing so failures still produce an error status
+	var buf bytes.Buffer
+
+	if err := h.feed.Write(r.Context(), &buf, project, time.Now()); err != nil {
+		if err.Error() == "project not found" {
+			http.Error(w, "Project not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="workpad.ics"`)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,97 @@
+package calendar
+
+import (
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// maxLineOctets is the longest content line RFC 5545 allows before folding
+	maxLineOctets = 75
+
+	dateFormat     = "20060102"
+	dateTimeFormat = "20060102T150405Z"
+)
+
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\n`,
+)
+
+// escapeText escapes a TEXT property value
+func escapeText(value string) string {
+	return textEscaper.Replace(value)
+}
+
+// Writer emits iCalendar content lines, folding long lines and ending each with CRLF
+type Writer struct {
+	w   io.Writer
+	err error
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Line writes a property whose value is already formatted
+func (w *Writer) Line(name string, value string) {
+	if w.err != nil {
+		return
+	}
+
+	line := name + ":" + value
+
+	var sb strings.Builder
+
+	// Fold on rune boundaries so multi-byte characters are never split
+	width := 0
+	for _, r := range line {
+		size := utf8.RuneLen(r)
+
+		if width+size > maxLineOctets {
+			sb.WriteString("\r\n ")
+			width = 1
+		}
+
+		sb.WriteRune(r)
+		width += size
+	}
+
+	sb.WriteString("\r\n")
+
+	_, w.err = io.WriteString(w.w, sb.String())
+}
+
+// Text writes a property with an escaped TEXT value
+func (w *Writer) Text(name string, value string) {
+	w.Line(name, escapeText(value))
+}
+
+// Date writes an all day DATE value
+func (w *Writer) Date(name string, date time.Time) {
+	w.Line(name+";VALUE=DATE", date.Format(dateFormat))
+}
+
+// DateTime writes a UTC DATE-TIME value
+func (w *Writer) DateTime(name string, t time.Time) {
+	w.Line(name, t.UTC().Format(dateTimeFormat))
+}
+
+func (w *Writer) Begin(component string) {
+	w.Line("BEGIN", component)
+}
+
+func (w *Writer) End(component string) {
+	w.Line("END", component)
+}
+
+// Err returns the first error encountered while writing
+func (w *Writer) Err() error {
+	return w.err
+}
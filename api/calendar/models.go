@@ -0,0 +1,10 @@
+package calendar
+
+type GetCalendarRequest struct {
+	// Token is the feed token, or a user's API token once users exist
+	// Calendar clients usually cannot send it as a bearer token
+	Token   string `query:"token" required:"false"`
+	Project string `query:"project" example:"OKT123" required:"false"`
+	// Workspace is the workspace id, the user's first workspace when it is not given
+	Workspace int64 `query:"workspace" required:"false"`
+}
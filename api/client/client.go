@@ -0,0 +1,326 @@
+// Package client is a typed Go client for the workpad API
+//
+// Request and response types are shared with the server packages, and the
+// endpoints used here are checked against the generated OpenAPI spec in tests.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/maybemaby/workpad/api/notes"
+	"github.com/maybemaby/workpad/api/pagination"
+	"github.com/maybemaby/workpad/api/projects"
+	"github.com/maybemaby/workpad/api/tz"
+	"github.com/maybemaby/workpad/api/workspaces"
+)
+
+// ErrNotFound is wrapped by APIError for 404 responses
+var ErrNotFound = errors.New("not found")
+
+// APIError is a non 2xx response from the API
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("api error %d: %s", e.StatusCode, e.Message)
+}
+
+func (e *APIError) Unwrap() error {
+	if e.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Endpoint is a method and path template as registered with MountRoutesOapi
+type Endpoint struct {
+	Method string
+	Path   string
+	// Query lists the query parameters the client may send
+	Query []string
+}
+
+var (
+	getNoteByDate         = Endpoint{Method: http.MethodGet, Path: "/api/notes/by-date", Query: []string{"date"}}
+	createNote            = Endpoint{Method: http.MethodPost, Path: "/api/notes"}
+	appendToNote          = Endpoint{Method: http.MethodPost, Path: "/api/notes/{date}/append"}
+	listProjects          = Endpoint{Method: http.MethodGet, Path: "/api/projects", Query: []string{"prefix", "limit", "cursor"}}
+	getExcerptsForProject = Endpoint{Method: http.MethodGet, Path: "/api/notes/excerpts/{project}", Query: []string{"include_descendants", "limit", "cursor", "format"}}
+)
+
+// Endpoints lists every endpoint the client calls
+var Endpoints = []Endpoint{
+	getNoteByDate,
+	createNote,
+	appendToNote,
+	listProjects,
+	getExcerptsForProject,
+}
+
+type Client struct {
+	baseURL    string
+	token      string
+	location   *time.Location
+	workspace  int64
+	httpClient *http.Client
+}
+
+type Option func(*Client)
+
+// WithToken sends token as a bearer token with every request
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithLocation dates today's note in loc instead of the local zone, and asks the server to do the same
+func WithLocation(loc *time.Location) Option {
+	return func(c *Client) {
+		c.location = loc
+	}
+}
+
+// WithWorkspace works in workspace id instead of the user's first workspace
+func WithWorkspace(id int64) Option {
+	return func(c *Client) {
+		c.workspace = id
+	}
+}
+
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// New creates a client for the server at baseURL, such as http://localhost:8000
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// do sends a request to endpoint, filling path parameters in order, and decodes a JSON response into out
+func (c *Client) do(ctx context.Context, endpoint Endpoint, pathParams []string, query url.Values, body any, out any) error {
+	path := endpoint.Path
+
+	for _, param := range pathParams {
+		start := strings.Index(path, "{")
+		end := strings.Index(path, "}")
+
+		if start < 0 || end < start {
+			return fmt.Errorf("too many path parameters for %s", endpoint.Path)
+		}
+
+		path = path[:start] + url.PathEscape(param) + path[end+1:]
+	}
+
+	target := c.baseURL + path
+
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, endpoint.Method, target, reader)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	if c.location != nil {
+		req.Header.Set(tz.Header, c.location.String())
+	}
+
+	if c.workspace != 0 {
+		req.Header.Set(workspaces.Header, strconv.FormatInt(c.workspace, 10))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+
+		return &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(message))}
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// GetNote returns the note for date, wrapping ErrNotFound when there is none
+func (c *Client) GetNote(ctx context.Context, date time.Time) (*notes.Note, error) {
+	var note notes.Note
+
+	err := c.do(ctx, getNoteByDate, nil, url.Values{"date": {date.Format(time.DateOnly)}}, nil, &note)
+	if err != nil {
+		return nil, err
+	}
+
+	return &note, nil
+}
+
+// SaveTodayNote replaces the content of today's note
+func (c *Client) SaveTodayNote(ctx context.Context, htmlContent string) (*notes.Note, error) {
+	var note notes.Note
+
+	err := c.do(ctx, createNote, nil, nil, notes.CreateNoteRequest{HTMLContent: htmlContent}, &note)
+	if err != nil {
+		return nil, err
+	}
+
+	return &note, nil
+}
+
+// Now returns the current time in the client's zone
+func (c *Client) Now() time.Time {
+	if c.location != nil {
+		return time.Now().In(c.location)
+	}
+
+	return time.Now()
+}
+
+// AppendToToday adds quick-capture text to the end of today's note, creating it if needed
+// The server converts Markdown bullets and task items, and links @Project mentions
+func (c *Client) AppendToToday(ctx context.Context, text string) (*notes.Note, error) {
+	var note notes.Note
+
+	today := c.Now().Format(time.DateOnly)
+
+	err := c.do(ctx, appendToNote, []string{today}, nil, notes.AppendNoteRequest{Text: text}, &note)
+	if err != nil {
+		return nil, err
+	}
+
+	return &note, nil
+}
+
+// pageSize is the page size the client requests, the largest the server allows
+const pageSize = pagination.MaxLimit
+
+// ListProjects lists projects, only those whose name starts with prefix when it is set
+func (c *Client) ListProjects(ctx context.Context, prefix string) ([]projects.Project, error) {
+	query := url.Values{"limit": {strconv.Itoa(pageSize)}}
+
+	if prefix != "" {
+		query.Set("prefix", prefix)
+	}
+
+	var list []projects.Project
+
+	for {
+		var page pagination.Page[projects.Project]
+
+		if err := c.do(ctx, listProjects, nil, query, nil, &page); err != nil {
+			return nil, err
+		}
+
+		list = append(list, page.Items...)
+
+		if page.NextCursor == "" {
+			return list, nil
+		}
+
+		query.Set("cursor", page.NextCursor)
+	}
+}
+
+type ExcerptOptions struct {
+	// Since drops excerpts from notes before this day when set
+	Since time.Time
+	// IncludeDescendants also returns excerpts of sub-projects
+	IncludeDescendants bool
+	// Format is notes.FormatJSON, notes.FormatHTML or notes.FormatText, excerpts are returned as stored when empty
+	Format string
+}
+
+// GetExcerpts lists excerpts mentioning project, newest first
+func (c *Client) GetExcerpts(ctx context.Context, project string, opts ExcerptOptions) ([]notes.NoteExcerpt, error) {
+	query := url.Values{"limit": {strconv.Itoa(pageSize)}}
+
+	if opts.IncludeDescendants {
+		query.Set("include_descendants", "true")
+	}
+
+	if opts.Format != "" {
+		query.Set("format", opts.Format)
+	}
+
+	since := ""
+	if !opts.Since.IsZero() {
+		since = opts.Since.Format(time.DateOnly)
+	}
+
+	var excerpts []notes.NoteExcerpt
+
+	for {
+		var page pagination.Page[notes.NoteExcerpt]
+
+		if err := c.do(ctx, getExcerptsForProject, []string{project}, query, nil, &page); err != nil {
+			return nil, err
+		}
+
+		for _, excerpt := range page.Items {
+			// Excerpts are newest first, so the rest of the listing is older still
+			if since != "" && excerpt.Date[:min(len(excerpt.Date), len(time.DateOnly))] < since {
+				return excerpts, nil
+			}
+
+			excerpts = append(excerpts, excerpt)
+		}
+
+		if page.NextCursor == "" {
+			return excerpts, nil
+		}
+
+		query.Set("cursor", page.NextCursor)
+	}
+}
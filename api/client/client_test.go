@@ -0,0 +1,150 @@
+package client_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maybemaby/workpad/api"
+	"github.com/maybemaby/workpad/api/client"
+)
+
+type openAPIDoc struct {
+	Paths map[string]map[string]struct {
+		Parameters []struct {
+			Name string `json:"name"`
+			In   string `json:"in"`
+		} `json:"parameters"`
+	} `json:"paths"`
+}
+
+// TestEndpointsMatchSpec fails when a route the client calls is renamed or removed on the server
+func TestEndpointsMatchSpec(t *testing.T) {
+	cfg := api.DefaultConfig()
+	cfg.Env = api.EnvDevelopment
+	cfg.Database.Path = filepath.Join(t.TempDir(), "spec.db")
+	cfg.AttachmentsDir = t.TempDir()
+
+	server, err := api.NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	server.MountRoutesOapi()
+
+	raw, err := server.OpenAPISpec()
+	if err != nil {
+		t.Fatalf("failed to generate spec: %v", err)
+	}
+
+	var doc openAPIDoc
+
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		t.Fatalf("failed to parse spec: %v", err)
+	}
+
+	for _, endpoint := range client.Endpoints {
+		operation, ok := doc.Paths[endpoint.Path][strings.ToLower(endpoint.Method)]
+		if !ok {
+			t.Errorf("%s %s is not in the OpenAPI spec", endpoint.Method, endpoint.Path)
+			continue
+		}
+
+		var query []string
+
+		for _, param := range operation.Parameters {
+			if param.In == "query" {
+				query = append(query, param.Name)
+			}
+		}
+
+		for _, name := range endpoint.Query {
+			if !slices.Contains(query, name) {
+				t.Errorf("%s %s has no query parameter %q in the OpenAPI spec", endpoint.Method, endpoint.Path, name)
+			}
+		}
+	}
+}
+
+func TestGetExcerpts_SinceAndToken(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		if r.URL.Path != "/api/notes/excerpts/OKT 123" {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+
+		// The first page links to a second, which must not be fetched past the since date
+		switch r.URL.Query().Get("cursor") {
+		case "":
+			w.Write([]byte(`{"items":[
+				{"id":3,"project_name":"OKT 123","excerpt":"<p>c</p>","date":"2026-03-01T00:00:00Z","note_id":3}
+			],"next_cursor":"page-2"}`))
+		case "page-2":
+			w.Write([]byte(`{"items":[
+				{"id":2,"project_name":"OKT 123","excerpt":"<p>b</p>","date":"2026-02-01T00:00:00Z","note_id":2},
+				{"id":1,"project_name":"OKT 123","excerpt":"<p>a</p>","date":"2025-12-31T00:00:00Z","note_id":1}
+			],"next_cursor":"page-3"}`))
+		default:
+			http.Error(w, "Unexpected page", http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	c := client.New(srv.URL, client.WithToken("secret"))
+
+	excerpts, err := c.GetExcerpts(t.Context(), "OKT 123", client.ExcerptOptions{Since: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(excerpts) != 2 || excerpts[0].Id != 3 || excerpts[1].Id != 2 {
+		t.Errorf("expected only the excerpt since 2026-01-01, got %+v", excerpts)
+	}
+
+	_, err = client.New(srv.URL).ListProjects(t.Context(), "")
+
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected a 401 APIError, got %v", err)
+	}
+}
+
+func TestAppendToToday_Location(t *testing.T) {
+	location, err := time.LoadLocation("Pacific/Kiritimati")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var path, zone string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, zone = r.URL.Path, r.Header.Get("X-Timezone")
+		w.Write([]byte(`{"id":1,"html_content":"<p>a</p>","date":"2026-10-19T00:00:00Z"}`))
+	}))
+	defer srv.Close()
+
+	c := client.New(srv.URL, client.WithLocation(location))
+
+	if _, err := c.AppendToToday(t.Context(), "a"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := "/api/notes/" + time.Now().In(location).Format(time.DateOnly) + "/append"; path != want {
+		t.Errorf("expected %s, got %s", want, path)
+	}
+
+	if zone != "Pacific/Kiritimati" {
+		t.Errorf("expected the zone in X-Timezone, got %q", zone)
+	}
+}
@@ -0,0 +1,312 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the server configuration
+// Values come from DefaultConfig, then the config file, then environment variables
+// named in the env tags, then command-line flags, each overriding the one before
+type Config struct {
+	// Env is development or production, development enables debug logging and the API docs
+	Env  string `yaml:"env" env:"APP_ENV"`
+	Port string `yaml:"port" env:"PORT"`
+	// Timezone is the IANA zone notes are dated in for clients that do not send their own
+	// in the X-Timezone header, the system zone when empty
+	Timezone string `yaml:"timezone" env:"WORKPAD_TZ"`
+	// AutoMigrate applies pending migrations when the server starts
+	AutoMigrate bool           `yaml:"auto_migrate" env:"AUTO_MIGRATE"`
+	Database    DatabaseConfig `yaml:"database"`
+	// AttachmentsDir defaults to an attachments directory next to the database
+	AttachmentsDir string `yaml:"attachments_dir" env:"ATTACHMENTS_DIR"`
+	// CORS controls which browser origins may call the API, such as the frontend dev server
+	CORS CORSConfig `yaml:"cors"`
+	// RateLimit bounds how fast each client may call the API
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+	// MaxBodyBytes bounds API request bodies, attachment uploads have their own limit
+	MaxBodyBytes int `yaml:"max_body_bytes" env:"MAX_BODY_BYTES"`
+	// FeedToken guards the calendar and Atom feeds in single-user mode, which are disabled when it is
+	// empty. Once users exist feeds take a user's API token instead
+	FeedToken string `yaml:"feed_token" env:"FEED_TOKEN" secret:"true"`
+	// OtelEndpoint enables trace and metric export over OTLP gRPC when set
+	OtelEndpoint string `yaml:"otel_endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+}
+
+type DatabaseConfig struct {
+	Path         string `yaml:"path" env:"SQLITE_DB_PATH"`
+	MaxOpenConns int    `yaml:"max_open_conns" env:"SQLITE_MAX_OPEN_CONNS"`
+	MaxIdleConns int    `yaml:"max_idle_conns" env:"SQLITE_MAX_IDLE_CONNS"`
+}
+
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
+)
+
+func DefaultConfig() Config {
+	return Config{
+		Env:         EnvProduction,
+		Port:        "8000",
+		AutoMigrate: true,
+		Database: DatabaseConfig{
+			Path:         "app.db",
+			MaxOpenConns: 25,
+			MaxIdleConns: 5,
+		},
+		CORS: CORSConfig{
+			AllowedOrigins:   []string{"http://localhost:5173"},
+			AllowCredentials: true,
+			AllowedHeaders:   []string{"Authorization", "Content-Type", "X-User-Agent", "Cache-Control", "X-Timezone", "X-Workspace"},
+			MaxAge:           3600,
+		},
+		RateLimit: RateLimitConfig{
+			RequestsPerMinute: 600,
+			Burst:             100,
+		},
+		MaxBodyBytes: 1 << 20,
+	}
+}
+
+// IsProd reports whether the server runs in production mode
+func (c Config) IsProd() bool {
+	return c.Env != EnvDevelopment
+}
+
+// LoadConfig reads the YAML file at path over the defaults and then applies the environment
+// A missing file is skipped unless required is set, unknown keys in the file are an error
+func LoadConfig(path string, required bool, lookupEnv func(string) (string, bool)) (Config, error) {
+	cfg := DefaultConfig()
+
+	if path != "" {
+		if err := cfg.readFile(path, required); err != nil {
+			return cfg, err
+		}
+	}
+
+	if err := applyEnv(reflect.ValueOf(&cfg).Elem(), lookupEnv); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+func (c *Config) readFile(path string, required bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && !required {
+			return nil
+		}
+
+		return fmt.Errorf("failed to open config file: %w", err)
+	}
+
+	defer f.Close()
+
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+
+	// An empty file leaves the defaults in place
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// applyEnv sets every field with an env tag whose variable is set, descending into nested structs
+func applyEnv(v reflect.Value, lookupEnv func(string) (string, bool)) error {
+	for i := range v.NumField() {
+		field := v.Field(i)
+		info := v.Type().Field(i)
+
+		if field.Kind() == reflect.Struct {
+			if err := applyEnv(field, lookupEnv); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name := info.Tag.Get("env")
+		if name == "" {
+			continue
+		}
+
+		value, ok := lookupEnv(name)
+		if !ok {
+			continue
+		}
+
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(value)
+		case reflect.Int:
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("%s must be an integer", name)
+			}
+			field.SetInt(int64(parsed))
+		case reflect.Bool:
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("%s must be true or false", name)
+			}
+			field.SetBool(parsed)
+		case reflect.Slice:
+			if field.Type().Elem().Kind() != reflect.String {
+				return fmt.Errorf("unsupported config field type for %s", name)
+			}
+
+			// Lists are comma separated, an empty value clears the list
+			values := []string{}
+			for item := range strings.SplitSeq(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					values = append(values, item)
+				}
+			}
+			field.Set(reflect.ValueOf(values))
+		default:
+			return fmt.Errorf("unsupported config field type for %s", name)
+		}
+	}
+
+	return nil
+}
+
+// Location returns the zone of Timezone, time.Local when it is empty
+func (c Config) Location() (*time.Location, error) {
+	if c.Timezone == "" {
+		return time.Local, nil
+	}
+
+	return time.LoadLocation(c.Timezone)
+}
+
+// Validate reports every invalid value at once
+func (c Config) Validate() error {
+	var errs []error
+
+	if c.Env != EnvDevelopment && c.Env != EnvProduction {
+		errs = append(errs, fmt.Errorf("env must be %s or %s, got %q", EnvDevelopment, EnvProduction, c.Env))
+	}
+
+	if port, err := strconv.Atoi(c.Port); err != nil || port < 1 || port > 65535 {
+		errs = append(errs, fmt.Errorf("port must be between 1 and 65535, got %q", c.Port))
+	}
+
+	if _, err := time.LoadLocation(c.Timezone); err != nil {
+		errs = append(errs, fmt.Errorf("unknown timezone %q", c.Timezone))
+	}
+
+	if c.Database.Path == "" {
+		errs = append(errs, errors.New("database.path is required"))
+	}
+
+	if c.Database.MaxOpenConns < 1 {
+		errs = append(errs, errors.New("database.max_open_conns must be at least 1"))
+	}
+
+	if c.Database.MaxIdleConns < 0 {
+		errs = append(errs, errors.New("database.max_idle_conns must not be negative"))
+	}
+
+	if _, err := NewCORSPolicy(c.CORS); err != nil {
+		errs = append(errs, fmt.Errorf("cors: %w", err))
+	}
+
+	if c.RateLimit.RequestsPerMinute < 0 {
+		errs = append(errs, errors.New("rate_limit.requests_per_minute must not be negative"))
+	}
+
+	if c.RateLimit.Enabled() && c.RateLimit.Burst < 1 {
+		errs = append(errs, errors.New("rate_limit.burst must be at least 1"))
+	}
+
+	if c.MaxBodyBytes < 1 {
+		errs = append(errs, errors.New("max_body_bytes must be at least 1"))
+	}
+
+	if c.OtelEndpoint != "" && !absoluteURL(c.OtelEndpoint) {
+		errs = append(errs, fmt.Errorf("otel_endpoint must be a URL such as http://localhost:4317, got %q", c.OtelEndpoint))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config: %w", errors.Join(errs...))
+	}
+
+	return nil
+}
+
+func absoluteURL(value string) bool {
+	parsed, err := url.Parse(value)
+
+	return err == nil && parsed.Scheme != "" && parsed.Host != ""
+}
+
+// Redacted returns a copy with every set secret replaced, safe to print or log
+func (c Config) Redacted() Config {
+	redactSecrets(reflect.ValueOf(&c).Elem())
+
+	return c
+}
+
+func redactSecrets(v reflect.Value) {
+	for i := range v.NumField() {
+		field := v.Field(i)
+
+		if field.Kind() == reflect.Struct {
+			redactSecrets(field)
+			continue
+		}
+
+		if v.Type().Field(i).Tag.Get("secret") == "true" && field.Kind() == reflect.String && field.String() != "" {
+			field.SetString("[redacted]")
+		}
+	}
+}
+
+// WriteYAML writes the config in the format of the config file, with secrets redacted
+func (c Config) WriteYAML(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+
+	if err := enc.Encode(c.Redacted()); err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
+
+// envNames lists the environment variables the config reads, for help output
+func envNames(v reflect.Type) []string {
+	var names []string
+
+	for i := range v.NumField() {
+		field := v.Field(i)
+
+		if field.Type.Kind() == reflect.Struct {
+			names = append(names, envNames(field.Type)...)
+			continue
+		}
+
+		if name := field.Tag.Get("env"); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// ConfigEnvNames lists the environment variables LoadConfig reads
+func ConfigEnvNames() string {
+	return strings.Join(envNames(reflect.TypeFor[Config]()), ", ")
+}
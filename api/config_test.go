@@ -0,0 +1,79 @@
+package api
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func envMap(values map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := values[key]
+		return value, ok
+	}
+}
+
+func TestLoadConfig_Precedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "workpad.yaml")
+
+	err := os.WriteFile(path, []byte("port: \"9000\"\ndatabase:\n  path: file.db\n  max_open_conns: 4\nfeed_token: from-file\n"), 0o600)
+	require.NoError(t, err)
+
+	cfg, err := LoadConfig(path, true, envMap(map[string]string{
+		"SQLITE_DB_PATH": "env.db",
+		"AUTO_MIGRATE":   "false",
+	}))
+	require.NoError(t, err)
+
+	assert.Equal(t, "9000", cfg.Port)
+	assert.Equal(t, "env.db", cfg.Database.Path)
+	assert.Equal(t, 4, cfg.Database.MaxOpenConns)
+	assert.Equal(t, 5, cfg.Database.MaxIdleConns)
+	assert.False(t, cfg.AutoMigrate)
+	assert.Equal(t, EnvProduction, cfg.Env)
+	assert.NoError(t, cfg.Validate())
+
+	var out bytes.Buffer
+	require.NoError(t, cfg.WriteYAML(&out))
+	assert.Contains(t, out.String(), "feed_token: '[redacted]'")
+	assert.NotContains(t, out.String(), "from-file")
+	assert.Equal(t, "from-file", cfg.FeedToken)
+}
+
+func TestLoadConfig_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := LoadConfig(filepath.Join(dir, "missing.yaml"), false, envMap(nil))
+	assert.NoError(t, err)
+
+	_, err = LoadConfig(filepath.Join(dir, "missing.yaml"), true, envMap(nil))
+	assert.Error(t, err)
+
+	unknown := filepath.Join(dir, "unknown.yaml")
+	require.NoError(t, os.WriteFile(unknown, []byte("prot: 9000\n"), 0o600))
+
+	_, err = LoadConfig(unknown, true, envMap(nil))
+	assert.ErrorContains(t, err, "prot")
+
+	_, err = LoadConfig("", false, envMap(map[string]string{"SQLITE_MAX_OPEN_CONNS": "many"}))
+	assert.ErrorContains(t, err, "SQLITE_MAX_OPEN_CONNS must be an integer")
+}
+
+func TestConfigValidate(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Env = "staging"
+	cfg.Port = "0"
+	cfg.Timezone = "Mars/Olympus"
+	cfg.CORS.AllowedOrigins = []string{"localhost"}
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "env must be")
+	assert.ErrorContains(t, err, "port must be")
+	assert.ErrorContains(t, err, "unknown timezone")
+	assert.ErrorContains(t, err, `invalid origin "localhost"`)
+}
@@ -0,0 +1,275 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/oaswrap/spec/adapter/httpopenapi"
+)
+
+// corsMethods are the methods a preflight may ask for
+var corsMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+type CORSConfig struct {
+	// AllowedOrigins lists origins such as http://localhost:5173. https://*.example.com matches
+	// any subdomain of example.com, and * matches every origin
+	AllowedOrigins []string `yaml:"allowed_origins" env:"CORS_ALLOWED_ORIGINS"`
+	// AllowCredentials lets browsers send cookies and authorization headers cross origin
+	AllowCredentials bool     `yaml:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS"`
+	AllowedHeaders   []string `yaml:"allowed_headers" env:"CORS_ALLOWED_HEADERS"`
+	// MaxAge is how many seconds browsers may cache a preflight response
+	MaxAge int `yaml:"max_age" env:"CORS_MAX_AGE"`
+}
+
+// originPattern is an allowed origin, Host starts with "*." for wildcard subdomains
+type originPattern struct {
+	Scheme string
+	Host   string
+	Port   string
+}
+
+func parseOriginPattern(value string) (originPattern, error) {
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" || (parsed.Path != "" && parsed.Path != "/") || parsed.RawQuery != "" {
+		return originPattern{}, fmt.Errorf("invalid origin %q, expected scheme://host[:port]", value)
+	}
+
+	host := strings.ToLower(parsed.Hostname())
+
+	if strings.Contains(strings.TrimPrefix(host, "*."), "*") {
+		return originPattern{}, fmt.Errorf("invalid origin %q, only a leading *. wildcard is supported", value)
+	}
+
+	return originPattern{Scheme: strings.ToLower(parsed.Scheme), Host: host, Port: parsed.Port()}, nil
+}
+
+func (p originPattern) matches(origin *url.URL) bool {
+	if strings.ToLower(origin.Scheme) != p.Scheme || origin.Port() != p.Port {
+		return false
+	}
+
+	host := strings.ToLower(origin.Hostname())
+
+	if suffix, ok := strings.CutPrefix(p.Host, "*"); ok {
+		return strings.HasSuffix(host, suffix) && len(host) > len(suffix)
+	}
+
+	return host == p.Host
+}
+
+// CORSPolicy decides which browser origins may call the API
+type CORSPolicy struct {
+	cfg       CORSConfig
+	anyOrigin bool
+	origins   []originPattern
+}
+
+func NewCORSPolicy(cfg CORSConfig) (*CORSPolicy, error) {
+	policy := &CORSPolicy{cfg: cfg}
+
+	var errs []error
+
+	for _, origin := range cfg.AllowedOrigins {
+		if origin == "*" {
+			policy.anyOrigin = true
+			continue
+		}
+
+		pattern, err := parseOriginPattern(origin)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		policy.origins = append(policy.origins, pattern)
+	}
+
+	// Browsers reject a wildcard with credentials, and echoing any origin instead would let every site act as the user
+	if policy.anyOrigin && cfg.AllowCredentials {
+		errs = append(errs, errors.New("allowed origin * cannot be combined with allow_credentials"))
+	}
+
+	if cfg.MaxAge < 0 {
+		errs = append(errs, errors.New("max_age must not be negative"))
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return policy, nil
+}
+
+// Allowed reports whether requests from origin may read API responses
+func (p *CORSPolicy) Allowed(origin string) bool {
+	if origin == "" {
+		return false
+	}
+
+	if p.anyOrigin {
+		return true
+	}
+
+	parsed, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+
+	return slices.ContainsFunc(p.origins, func(pattern originPattern) bool {
+		return pattern.matches(parsed)
+	})
+}
+
+func (p *CORSPolicy) allowOrigin(h http.Header, origin string) {
+	if p.anyOrigin {
+		h.Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+
+	h.Set("Access-Control-Allow-Origin", origin)
+
+	if p.cfg.AllowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// Handler applies the policy to requests below the routes' prefix and answers their preflight
+// requests with the methods registered for the path. Other requests, such as the SPA, pass through
+func (p *CORSPolicy) Handler(routes *RouteTable, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !routes.Contains(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		origin := r.Header.Get("Origin")
+
+		// Responses differ by origin, so shared caches must not serve one origin's response to another
+		w.Header().Add("Vary", "Origin")
+
+		if r.Method == http.MethodOptions {
+			if r.Header.Get("Access-Control-Request-Method") != "" {
+				p.preflight(w, r, routes, origin)
+				return
+			}
+
+			allowMethods(w, r, routes)
+			return
+		}
+
+		if p.Allowed(origin) {
+			p.allowOrigin(w.Header(), origin)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowMethods answers a plain OPTIONS request with the methods the path supports
+func allowMethods(w http.ResponseWriter, r *http.Request, routes *RouteTable) {
+	methods := routes.Methods(r.URL.Path)
+
+	if len(methods) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Allow", strings.Join(append(methods, http.MethodOptions), ", "))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (p *CORSPolicy) preflight(w http.ResponseWriter, r *http.Request, routes *RouteTable, origin string) {
+	h := w.Header()
+	h.Add("Vary", "Access-Control-Request-Method")
+	h.Add("Vary", "Access-Control-Request-Headers")
+
+	methods := routes.Methods(r.URL.Path)
+
+	if len(methods) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+
+	if !p.Allowed(origin) {
+		http.Error(w, "Origin not allowed", http.StatusForbidden)
+		return
+	}
+
+	p.allowOrigin(h, origin)
+	h.Set("Access-Control-Allow-Methods", strings.Join(append(methods, http.MethodOptions), ", "))
+
+	if len(p.cfg.AllowedHeaders) > 0 {
+		h.Set("Access-Control-Allow-Headers", strings.Join(p.cfg.AllowedHeaders, ", "))
+	}
+
+	if p.cfg.MaxAge > 0 {
+		h.Set("Access-Control-Max-Age", strconv.Itoa(p.cfg.MaxAge))
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RouteTable is a router that remembers the method and path of every route registered
+// through it, so preflight requests can be answered with the methods a path supports
+type RouteTable struct {
+	httpopenapi.Router
+	prefix string
+	lookup *http.ServeMux
+}
+
+// NewRouteTable records routes registered on router, which serves paths below prefix
+func NewRouteTable(prefix string, router httpopenapi.Router) *RouteTable {
+	return &RouteTable{
+		Router: router,
+		prefix: strings.TrimSuffix(prefix, "/"),
+		lookup: http.NewServeMux(),
+	}
+}
+
+func (t *RouteTable) record(pattern string) {
+	method, path, ok := strings.Cut(pattern, " ")
+
+	// Method-less patterns are catch-alls that would match every method
+	if !ok || !slices.Contains(corsMethods, method) {
+		return
+	}
+
+	t.lookup.Handle(method+" "+t.prefix+path, http.NotFoundHandler())
+}
+
+func (t *RouteTable) Handle(pattern string, handler http.Handler) httpopenapi.Route {
+	t.record(pattern)
+
+	return t.Router.Handle(pattern, handler)
+}
+
+func (t *RouteTable) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) httpopenapi.Route {
+	t.record(pattern)
+
+	return t.Router.HandleFunc(pattern, handler)
+}
+
+// Contains reports whether path is below the table's prefix
+func (t *RouteTable) Contains(path string) bool {
+	return strings.HasPrefix(path, t.prefix+"/")
+}
+
+// Methods lists the methods registered for path, matched the way the mux matches requests
+func (t *RouteTable) Methods(path string) []string {
+	var methods []string
+
+	for _, method := range corsMethods {
+		req := &http.Request{Method: method, URL: &url.URL{Path: path}}
+
+		if _, pattern := t.lookup.Handler(req); pattern != "" {
+			methods = append(methods, method)
+		}
+	}
+
+	return methods
+}
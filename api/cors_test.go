@@ -0,0 +1,117 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/oaswrap/spec/adapter/httpopenapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCORSTestHandler(t *testing.T, cfg CORSConfig) http.Handler {
+	policy, err := NewCORSPolicy(cfg)
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	r := httpopenapi.NewRouter(mux)
+	routes := NewRouteTable("/api", r.Group("/api"))
+
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	routes.HandleFunc("GET /projects/{name}", ok)
+	routes.HandleFunc("DELETE /projects/{name}", ok)
+	routes.HandleFunc("POST /projects/batch", ok)
+	mux.HandleFunc("/", ok)
+
+	return policy.Handler(routes, mux)
+}
+
+func preflight(handler http.Handler, path string, origin string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodOptions, path, nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", http.MethodDelete)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	return w
+}
+
+func TestCORS_Preflight(t *testing.T) {
+	handler := newCORSTestHandler(t, CORSConfig{
+		AllowedOrigins:   []string{"http://localhost:5173", "https://*.example.com"},
+		AllowCredentials: true,
+		AllowedHeaders:   []string{"Authorization", "Content-Type"},
+		MaxAge:           600,
+	})
+
+	w := preflight(handler, "/api/projects/alpha", "https://app.example.com")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET, DELETE, OPTIONS", w.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Authorization, Content-Type", w.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+	assert.Contains(t, w.Header().Values("Vary"), "Origin")
+
+	// Methods are matched like the mux does, so the wildcard routes also serve the literal path
+	w = preflight(handler, "/api/projects/batch", "http://localhost:5173")
+	assert.Equal(t, "GET, POST, DELETE, OPTIONS", w.Header().Get("Access-Control-Allow-Methods"))
+
+	w = preflight(handler, "/api/projects/alpha", "https://example.com")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+	w = preflight(handler, "/api/projects/alpha", "http://localhost:3000")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = preflight(handler, "/api/unknown", "http://localhost:5173")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, "/api/projects/alpha", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "GET, DELETE, OPTIONS", w.Header().Get("Allow"))
+}
+
+func TestCORS_SimpleRequests(t *testing.T) {
+	handler := newCORSTestHandler(t, CORSConfig{AllowedOrigins: []string{"http://localhost:5173"}})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/projects/alpha", nil)
+	req.Header.Set("Origin", "http://localhost:5173")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, "http://localhost:5173", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "Origin", w.Header().Get("Vary"))
+
+	req = httptest.NewRequest(http.MethodGet, "/api/projects/alpha", nil)
+	req.Header.Set("Origin", "https://evil.test")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+	// The SPA is same origin only and gets no CORS headers
+	req = httptest.NewRequest(http.MethodGet, "/notes", nil)
+	req.Header.Set("Origin", "http://localhost:5173")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Get("Vary"))
+}
+
+func TestNewCORSPolicy_Invalid(t *testing.T) {
+	_, err := NewCORSPolicy(CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true})
+	assert.ErrorContains(t, err, "cannot be combined")
+
+	_, err = NewCORSPolicy(CORSConfig{AllowedOrigins: []string{"https://app.*.example.com"}})
+	assert.ErrorContains(t, err, "only a leading")
+
+	policy, err := NewCORSPolicy(CORSConfig{AllowedOrigins: []string{"*"}})
+	require.NoError(t, err)
+	assert.True(t, policy.Allowed("https://anything.test"))
+}
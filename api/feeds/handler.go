@@ -0,0 +1,206 @@
+package feeds
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/maybemaby/workpad/api/notes"
+)
+
+// recentNotesLimit is how many days the notes feed includes
+const recentNotesLimit = 50
+
+const author = "workpad"
+
+type FeedHandler struct {
+	notes notes.NoteStore
+	// trustProxy honours the X-Forwarded-Proto and X-Forwarded-Host headers a proxy sets
+	trustProxy bool
+}
+
+func NewFeedHandler(notes notes.NoteStore, trustProxy bool) *FeedHandler {
+	return &FeedHandler{notes: notes, trustProxy: trustProxy}
+}
+
+// baseURL is the origin the request was made to, used to build links back to the app
+// Forwarded headers are only used behind a trusted proxy, clients could otherwise pick the host of every link
+func (h *FeedHandler) baseURL(r *http.Request) string {
+	scheme := "http"
+
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	host := r.Host
+
+	if h.trustProxy {
+		if proto := r.Header.Get("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+			scheme = proto
+		}
+
+		if forwarded := r.Header.Get("X-Forwarded-Host"); forwarded != "" {
+			host = forwarded
+		}
+	}
+
+	return scheme + "://" + host
+}
+
+// selfURL links back to the feed without the query string, keeping the token out of the document
+// The request URI is used because r.URL.Path has the /api prefix stripped by the router
+func selfURL(r *http.Request, base string) string {
+	path, _, _ := strings.Cut(r.RequestURI, "?")
+
+	return base + path
+}
+
+// Entry ids are derived from dates and project names rather than row ids
+// so they survive notes being rewritten and excerpts being rebuilt
+func noteEntryId(date string) string {
+	return "urn:workpad:note:" + date
+}
+
+func projectEntryId(project string, date string) string {
+	return "urn:workpad:project:" + url.PathEscape(project) + ":" + date
+}
+
+func writeFeed(w http.ResponseWriter, feed *Feed) {
+	// Encode fully before writing so failures still produce an error status
+	var buf bytes.Buffer
+
+	if err := feed.Write(&buf); err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	w.Header().Set("Cache-Control", "private, no-cache")
+	w.Write(buf.Bytes())
+}
+
+// GetNotesFeed handles GET /feeds/notes.atom
+func (h *FeedHandler) GetNotesFeed(w http.ResponseWriter, r *http.Request) {
+	recent, err := h.notes.GetRecentNotes(r.Context(), recentNotesLimit)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	base := h.baseURL(r)
+
+	feed := &Feed{
+		Id:     "urn:workpad:notes",
+		Title:  "workpad notes",
+		Author: Person{Name: author},
+		Links: []Link{
+			{Rel: "self", Type: "application/atom+xml", Href: selfURL(r, base)},
+			{Rel: "alternate", Type: "text/html", Href: base + "/"},
+		},
+	}
+
+	var updated time.Time
+
+	for _, note := range recent {
+		date := note.Date.Format(time.DateOnly)
+
+		if note.UpdatedAt.After(updated) {
+			updated = note.UpdatedAt
+		}
+
+		// Notes saved before sanitizing was added may still carry markup readers would run
+		sanitized, err := notes.Sanitize(note.HTMLContent)
+		if err != nil {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		feed.Entries = append(feed.Entries, Entry{
+			Id:        noteEntryId(date),
+			Title:     note.Date.Format("Monday, 2 January 2006"),
+			Updated:   timestamp(note.UpdatedAt),
+			Published: timestamp(note.Date),
+			Links:     []Link{{Rel: "alternate", Type: "text/html", Href: base + "/dates/" + date}},
+			Content:   Content{Type: "html", Body: sanitized.HTML},
+		})
+	}
+
+	feed.Updated = timestamp(updated)
+
+	writeFeed(w, feed)
+}
+
+// GetProjectFeed handles GET /feeds/projects/{name}.atom
+// Excerpts are grouped into one entry per project per day
+func (h *FeedHandler) GetProjectFeed(w http.ResponseWriter, r *http.Request) {
+	name, ok := strings.CutSuffix(r.PathValue("name"), ".atom")
+	if !ok || name == "" {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	excerpts, _, err := h.notes.GetExcerptsForProject(r.Context(), name, notes.ExcerptOptions{})
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	base := h.baseURL(r)
+
+	feed := &Feed{
+		Id:     "urn:workpad:project:" + url.PathEscape(name),
+		Title:  name + " on workpad",
+		Author: Person{Name: author},
+		Links: []Link{
+			{Rel: "self", Type: "application/atom+xml", Href: selfURL(r, base)},
+			{Rel: "alternate", Type: "text/html", Href: base + "/projects/" + url.PathEscape(name)},
+		},
+	}
+
+	var updated time.Time
+	var content strings.Builder
+
+	// Excerpts arrive newest day first, flush an entry whenever the day changes
+	for i, excerpt := range excerpts {
+		date := excerpt.Date[:min(len(excerpt.Date), len(time.DateOnly))]
+
+		rendered, err := notes.ExcerptHTML(excerpt.Excerpt)
+		if err != nil {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		content.WriteString(rendered)
+
+		if excerpt.UpdatedAt.After(updated) {
+			updated = excerpt.UpdatedAt
+		}
+
+		if i+1 < len(excerpts) && strings.HasPrefix(excerpts[i+1].Date, date) {
+			continue
+		}
+
+		published, _ := time.Parse(time.DateOnly, date)
+
+		feed.Entries = append(feed.Entries, Entry{
+			Id:        projectEntryId(excerpt.ProjectName, date),
+			Title:     excerpt.ProjectName + " on " + published.Format("Monday, 2 January 2006"),
+			Updated:   timestamp(excerpt.UpdatedAt),
+			Published: timestamp(published),
+			Links:     []Link{{Rel: "alternate", Type: "text/html", Href: base + "/dates/" + date}},
+			Content:   Content{Type: "html", Body: content.String()},
+		})
+
+		content.Reset()
+
+		if len(feed.Entries) == recentNotesLimit {
+			break
+		}
+	}
+
+	feed.Updated = timestamp(updated)
+
+	writeFeed(w, feed)
+}
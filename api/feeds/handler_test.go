@@ -0,0 +1,188 @@
+package feeds
+
+import (
+	"database/sql"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/maybemaby/workpad/api/notes"
+	"github.com/maybemaby/workpad/api/utils"
+	"github.com/maybemaby/workpad/api/workspaces"
+	"github.com/stretchr/testify/suite"
+	_ "modernc.org/sqlite"
+)
+
+func mustParseDate(value string) time.Time {
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		panic(err)
+	}
+
+	return t
+}
+
+type FeedHandlerSuite struct {
+	suite.Suite
+	db      *sql.DB
+	dbx     *sqlx.DB
+	notes   *notes.NoteService
+	handler *FeedHandler
+	mux     *http.ServeMux
+}
+
+func (s *FeedHandlerSuite) SetupTest() {
+	s.db, _ = sql.Open("sqlite", ":memory:")
+	s.db.SetMaxOpenConns(1)
+	s.dbx = sqlx.NewDb(s.db, "sqlite")
+
+	err := utils.SetupSqliteDb(s.db)
+
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = s.db.Exec("INSERT INTO projects (name) VALUES ('OKT123')")
+
+	if err != nil {
+		panic(err)
+	}
+
+	s.notes = notes.NewNoteService(s.dbx)
+	s.handler = NewFeedHandler(s.notes, false)
+
+	s.mux = http.NewServeMux()
+	s.mux.HandleFunc("GET /feeds/notes.atom", s.handler.GetNotesFeed)
+	s.mux.HandleFunc("GET /feeds/projects/{name}", s.handler.GetProjectFeed)
+}
+
+func (s *FeedHandlerSuite) TearDownTest() {
+	s.db.Close()
+}
+
+func (s *FeedHandlerSuite) get(path string) (*httptest.ResponseRecorder, Feed) {
+	return s.getWithHeaders(path, nil)
+}
+
+func (s *FeedHandlerSuite) getWithHeaders(path string, headers map[string]string) (*httptest.ResponseRecorder, Feed) {
+	req := httptest.NewRequest(http.MethodGet, "http://workpad.test"+path, nil)
+	req = req.WithContext(workspaces.WithID(req.Context(), workspaces.DefaultID))
+
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	w := httptest.NewRecorder()
+
+	s.mux.ServeHTTP(w, req)
+
+	var feed Feed
+
+	if w.Code == http.StatusOK {
+		s.Require().NoError(xml.Unmarshal(w.Body.Bytes(), &feed))
+	}
+
+	return w, feed
+}
+
+func (s *FeedHandlerSuite) TestNotesFeed_StableIdsAndUpdated() {
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+
+	_, err := s.notes.CreateNote(ctx, "<p>First draft</p>", mustParseDate("2026-10-19"))
+	s.Require().NoError(err)
+
+	_, err = s.db.Exec("UPDATE notes SET updated_at = '2026-10-19 08:00:00'")
+	s.Require().NoError(err)
+
+	// Saving identical content is not an edit
+	_, err = s.notes.CreateNote(ctx, "<p>First draft</p>", mustParseDate("2026-10-19"))
+	s.Require().NoError(err)
+
+	w, feed := s.get("/feeds/notes.atom")
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Equal("application/atom+xml; charset=utf-8", w.Header().Get("Content-Type"))
+	s.Require().Len(feed.Entries, 1)
+	s.Equal("urn:workpad:note:2026-10-19", feed.Entries[0].Id)
+	s.Equal("2026-10-19T08:00:00Z", feed.Entries[0].Updated)
+	s.Equal("2026-10-19T08:00:00Z", feed.Updated)
+	s.Equal("http://workpad.test/dates/2026-10-19", feed.Entries[0].Links[0].Href)
+	s.Equal("<p>First draft</p>", feed.Entries[0].Content.Body)
+
+	_, err = s.notes.CreateNote(ctx, "<p>Second draft</p>", mustParseDate("2026-10-19"))
+	s.Require().NoError(err)
+
+	_, feed = s.get("/feeds/notes.atom")
+	s.Require().Len(feed.Entries, 1)
+	s.Equal("urn:workpad:note:2026-10-19", feed.Entries[0].Id)
+	s.NotEqual("2026-10-19T08:00:00Z", feed.Entries[0].Updated)
+}
+
+func (s *FeedHandlerSuite) TestNotesFeed_SanitizesOldNotes() {
+	// Written directly, as notes saved before sanitizing was added
+	_, err := s.db.Exec(`INSERT INTO notes (html_content, note_date) VALUES ('<p onclick="steal()">Hi<script>alert(1)</script></p>', '2026-10-19')`)
+	s.Require().NoError(err)
+
+	w, feed := s.get("/feeds/notes.atom")
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().Len(feed.Entries, 1)
+	s.Equal("<p>Hi</p>", feed.Entries[0].Content.Body)
+}
+
+func (s *FeedHandlerSuite) TestNotesFeed_ForwardedHostNeedsTrustedProxy() {
+	_, err := s.notes.CreateNote(workspaces.WithID(s.T().Context(), workspaces.DefaultID), "<p>Hi</p>", mustParseDate("2026-10-19"))
+	s.Require().NoError(err)
+
+	forwarded := map[string]string{"X-Forwarded-Host": "evil.test", "X-Forwarded-Proto": "https"}
+
+	_, feed := s.getWithHeaders("/feeds/notes.atom", forwarded)
+	s.Require().Len(feed.Entries, 1)
+	s.Equal("http://workpad.test/dates/2026-10-19", feed.Entries[0].Links[0].Href)
+
+	s.handler.trustProxy = true
+
+	_, feed = s.getWithHeaders("/feeds/notes.atom", forwarded)
+	s.Require().Len(feed.Entries, 1)
+	s.Equal("https://evil.test/dates/2026-10-19", feed.Entries[0].Links[0].Href)
+}
+
+func (s *FeedHandlerSuite) TestProjectFeed_GroupsExcerptsByDay() {
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+
+	for _, date := range []string{"2026-10-19", "2026-10-20"} {
+		_, err := s.notes.CreateNote(ctx, "<p>Vendor call</p>", mustParseDate(date))
+		s.Require().NoError(err)
+	}
+
+	_, err := s.notes.UpdateExcerptsForDate(ctx, mustParseDate("2026-10-19"), []notes.ExcerptNode{
+		{Projects: []string{"OKT123"}, Node: `{"type":"paragraph","content":[{"type":"mention","attrs":{"id":"OKT123","label":"OKT123"}},{"type":"text","text":" call <vendor>","marks":[{"type":"bold"}]}]}`},
+		{Projects: []string{"OKT123"}, Node: `<p>second <script>alert(1)</script>line</p>`},
+	}, notes.SaveExcerptsOptions{})
+	s.Require().NoError(err)
+
+	_, err = s.notes.UpdateExcerptsForDate(ctx, mustParseDate("2026-10-20"), []notes.ExcerptNode{
+		{Projects: []string{"OKT123"}, Node: `<p>next day</p>`},
+	}, notes.SaveExcerptsOptions{})
+	s.Require().NoError(err)
+
+	w, feed := s.get("/feeds/projects/OKT123.atom")
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().Len(feed.Entries, 2)
+
+	s.Equal("urn:workpad:project:OKT123:2026-10-20", feed.Entries[0].Id)
+	s.Equal("<p>next day</p>", feed.Entries[0].Content.Body)
+
+	s.Equal("urn:workpad:project:OKT123:2026-10-19", feed.Entries[1].Id)
+	s.Contains(feed.Entries[1].Content.Body, `data-mention-id="OKT123">@OKT123</span><strong> call &lt;vendor&gt;</strong>`)
+	s.Contains(feed.Entries[1].Content.Body, "<p>second line</p>")
+	s.Equal("html", feed.Entries[1].Content.Type)
+
+	w, _ = s.get("/feeds/projects/OKT123")
+	s.Equal(http.StatusNotFound, w.Code)
+}
+
+func TestFeedHandlerSuite(t *testing.T) {
+	suite.Run(t, new(FeedHandlerSuite))
+}
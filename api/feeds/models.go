@@ -0,0 +1,15 @@
+package feeds
+
+type GetNotesFeedRequest struct {
+	// Token is the feed token, or a user's API token once users exist
+	// Feed readers usually cannot send it as a bearer token
+	Token string `query:"token" required:"false"`
+	// Workspace is the workspace id, the user's first workspace when it is not given
+	Workspace int64 `query:"workspace" required:"false"`
+}
+
+type GetProjectFeedRequest struct {
+	Name      string `path:"name" example:"OKT123.atom" required:"true"`
+	Token     string `query:"token" required:"false"`
+	Workspace int64  `query:"workspace" required:"false"`
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/google/uuid"
@@ -27,24 +28,9 @@ func (r *statusRecorder) WriteHeader(status int) {
 	r.ResponseWriter.WriteHeader(status)
 }
 
-func CorsMiddleware(origin string) alice.Constructor {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-
-			w.Header().Set("Access-Control-Allow-Origin", origin)
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, X-User-Agent, Cache-Control")
-			w.Header().Set("Access-Control-Allow-Credentials", "true")
-			w.Header().Set("Access-Control-Max-Age", "3600")
-
-			if r.Method == "OPTIONS" {
-				w.WriteHeader(http.StatusOK)
-				return
-			}
-
-			next.ServeHTTP(w, r)
-		})
-	}
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to flush event streams
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
 }
 
 func RequestIdMiddleware() alice.Constructor {
@@ -61,7 +47,7 @@ func LoggingMiddleware(logger *slog.Logger) alice.Constructor {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			rec := &statusRecorder{w, http.StatusOK}
-			url := r.URL.String()
+			url := redactedURL(r.URL)
 			requestId := r.Header.Get(RequestIdHeader)
 			method := r.Method
 
@@ -79,20 +65,33 @@ func LoggingMiddleware(logger *slog.Logger) alice.Constructor {
 	}
 }
 
-func RequestLogger(request *http.Request) *slog.Logger {
-	return request.Context().Value(RequestLoggerKey).(*slog.Logger)
+// redactedURL hides feed tokens passed in the query string from logs
+func redactedURL(u *url.URL) string {
+	query := u.Query()
+
+	if !query.Has("token") {
+		return u.String()
+	}
+
+	query.Set("token", "REDACTED")
+
+	redacted := *u
+	redacted.RawQuery = query.Encode()
+
+	return redacted.String()
 }
 
-type MiddlewareConfig struct {
-	CorsOrigin string
+func RequestLogger(request *http.Request) *slog.Logger {
+	return request.Context().Value(RequestLoggerKey).(*slog.Logger)
 }
 
-func RootMiddleware(logger *slog.Logger, cfg MiddlewareConfig) alice.Chain {
+// RootMiddleware is applied to every route, CORS is handled for the API alone by CORSPolicy
+func RootMiddleware(logger *slog.Logger) alice.Chain {
 
 	secureMw := secure.New(secure.Options{
 		AllowedHosts:      []string{},
 		HostsProxyHeaders: []string{"X-Forwarded-Host"},
 	})
 
-	return alice.New(CorsMiddleware(cfg.CorsOrigin), RequestIdMiddleware(), LoggingMiddleware(logger), secureMw.Handler)
+	return alice.New(RequestIdMiddleware(), LoggingMiddleware(logger), secureMw.Handler)
 }
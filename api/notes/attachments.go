@@ -0,0 +1,46 @@
+package notes
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// attachmentPattern matches attachment URLs in any attribute, such as img src or a href
+var attachmentPattern = regexp.MustCompile(`/api/attachments/([0-9a-f]{64})`)
+
+// AttachmentHashes returns the distinct attachment hashes a note references
+func AttachmentHashes(htmlContent string) []string {
+	seen := make(map[string]bool)
+	var hashes []string
+
+	for _, match := range attachmentPattern.FindAllStringSubmatch(htmlContent, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			hashes = append(hashes, match[1])
+		}
+	}
+
+	return hashes
+}
+
+// replaceAttachmentRefs records which attachments a note references, so unreferenced
+// blobs can be garbage collected
+func replaceAttachmentRefs(ctx context.Context, tx *sqlx.Tx, noteId int, htmlContent string) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM note_attachments WHERE note_id = ?`, noteId)
+
+	if err != nil {
+		return err
+	}
+
+	for _, hash := range AttachmentHashes(htmlContent) {
+		_, err := tx.ExecContext(ctx, `INSERT INTO note_attachments (note_id, hash) VALUES (?, ?)`, noteId, hash)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,246 @@
+package notes
+
+import (
+	"encoding/json"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	// mentionPattern matches the name after an @, trailing punctuation is not part of the name
+	mentionPattern = regexp.MustCompile(`^@([\p{L}\p{N}_](?:[\p{L}\p{N}_\-./]*[\p{L}\p{N}_])?)`)
+	linkPattern    = regexp.MustCompile(`^\[([^\]]+)\]\(([^)\s]+)\)`)
+	taskPattern    = regexp.MustCompile(`^[-*+]\s+\[([ xX])\]\s+(.*)$`)
+	bulletPattern  = regexp.MustCompile(`^[-*+]\s+(.*)$`)
+)
+
+// ProjectResolver returns the canonical name of a mentioned project, or "" when there is no such project
+type ProjectResolver func(name string) (string, error)
+
+// CaptureBlocks converts quick-capture text to ProseMirror blocks
+// Lines starting with "- ", "* " or "+ " become bullets, "- [ ] " and "- [x] " task items, and any
+// other line a paragraph. Consecutive items share a list. Inline **bold**, *italic*, `code` and
+// [links](url) are supported, and @Project tokens naming an existing project become mentions
+func CaptureBlocks(text string, resolve ProjectResolver) ([]PMNode, error) {
+	p := &inlineParser{resolve: resolve}
+
+	var blocks []PMNode
+
+	// appendItem adds item to the list ending the blocks, starting a new list when the type differs
+	appendItem := func(listType string, item PMNode) {
+		if n := len(blocks); n > 0 && blocks[n-1].Type == listType {
+			blocks[n-1].Content = append(blocks[n-1].Content, item)
+			return
+		}
+
+		blocks = append(blocks, PMNode{Type: listType, Content: []PMNode{item}})
+	}
+
+	for line := range strings.Lines(text) {
+		line = strings.TrimSpace(line)
+
+		if line == "" {
+			continue
+		}
+
+		if m := taskPattern.FindStringSubmatch(line); m != nil {
+			appendItem("taskList", PMNode{
+				Type:    "taskItem",
+				Attrs:   map[string]any{"checked": m[1] != " "},
+				Content: []PMNode{{Type: "paragraph", Content: p.parse(m[2], nil)}},
+			})
+			continue
+		}
+
+		if m := bulletPattern.FindStringSubmatch(line); m != nil {
+			appendItem("bulletList", PMNode{
+				Type:    "listItem",
+				Content: []PMNode{{Type: "paragraph", Content: p.parse(m[1], nil)}},
+			})
+			continue
+		}
+
+		blocks = append(blocks, PMNode{Type: "paragraph", Content: p.parse(line, nil)})
+	}
+
+	return blocks, p.err
+}
+
+type inlineParser struct {
+	resolve ProjectResolver
+	// err is the first error returned by resolve
+	err error
+}
+
+func withMark(marks []PMMark, mark PMMark) []PMMark {
+	return append(slices.Clone(marks), mark)
+}
+
+// parse converts one line of inline Markdown to text and mention nodes carrying marks
+// Unterminated syntax is kept as plain text
+func (p *inlineParser) parse(text string, marks []PMMark) []PMNode {
+	var nodes []PMNode
+	var plain strings.Builder
+
+	flush := func() {
+		if plain.Len() > 0 {
+			nodes = append(nodes, PMNode{Type: "text", Text: plain.String(), Marks: marks})
+			plain.Reset()
+		}
+	}
+
+	for i := 0; i < len(text); {
+		rest := text[i:]
+
+		// Italics and mentions only start a word, so snake_case and emails stay plain text
+		wordStart := true
+		if i > 0 {
+			previous, _ := utf8.DecodeLastRuneInString(text[:i])
+			wordStart = unicode.IsSpace(previous) || previous == '('
+		}
+
+		switch {
+		case rest[0] == '`':
+			if end := strings.IndexByte(rest[1:], '`'); end > 0 {
+				flush()
+				nodes = append(nodes, PMNode{Type: "text", Text: rest[1 : end+1], Marks: withMark(marks, PMMark{Type: "code"})})
+				i += end + 2
+				continue
+			}
+		case strings.HasPrefix(rest, "**"):
+			if end := strings.Index(rest[2:], "**"); end > 0 {
+				flush()
+				nodes = append(nodes, p.parse(rest[2:end+2], withMark(marks, PMMark{Type: "bold"}))...)
+				i += end + 4
+				continue
+			}
+		case (rest[0] == '*' || rest[0] == '_') && wordStart:
+			if end := strings.IndexByte(rest[1:], rest[0]); end > 0 {
+				flush()
+				nodes = append(nodes, p.parse(rest[1:end+1], withMark(marks, PMMark{Type: "italic"}))...)
+				i += end + 2
+				continue
+			}
+		case rest[0] == '[':
+			if m := linkPattern.FindStringSubmatch(rest); m != nil {
+				flush()
+				nodes = append(nodes, p.parse(m[1], withMark(marks, PMMark{Type: "link", Attrs: map[string]any{"href": m[2]}}))...)
+				i += len(m[0])
+				continue
+			}
+		case rest[0] == '@' && wordStart:
+			if m := mentionPattern.FindStringSubmatch(rest); m != nil {
+				canonical, err := p.resolve(m[1])
+				if err != nil && p.err == nil {
+					p.err = err
+				}
+
+				if canonical != "" {
+					flush()
+					nodes = append(nodes, PMNode{Type: "mention", Attrs: map[string]any{"id": canonical, "label": m[1]}})
+					i += len(m[0])
+					continue
+				}
+			}
+		}
+
+		_, size := utf8.DecodeRuneInString(rest)
+		plain.WriteString(rest[:size])
+		i += size
+	}
+
+	flush()
+
+	return nodes
+}
+
+// captureExcerpts lists the paragraphs and task items that directly contain mentions,
+// serialized the same way the editor reports excerpts
+func captureExcerpts(nodes []PMNode) ([]ExcerptNode, error) {
+	var excerpts []ExcerptNode
+
+	for _, n := range nodes {
+		if n.Type == "paragraph" || n.Type == "taskItem" {
+			var mentioned []string
+
+			for _, c := range n.Content {
+				if c.Type == "mention" {
+					mentioned = append(mentioned, c.attr("id"))
+				}
+			}
+
+			if len(mentioned) > 0 {
+				encoded, err := json.Marshal(n)
+				if err != nil {
+					return nil, err
+				}
+
+				excerpts = append(excerpts, ExcerptNode{Projects: mentioned, Node: string(encoded)})
+			}
+		}
+
+		children, err := captureExcerpts(n.Content)
+		if err != nil {
+			return nil, err
+		}
+
+		excerpts = append(excerpts, children...)
+	}
+
+	return excerpts, nil
+}
+
+// appendHTML adds addition to the end of existing note HTML
+// When both meet at the same kind of list the new items join the existing list
+func appendHTML(existing string, addition string) (string, error) {
+	existingNodes, err := parseFragment(existing)
+	if err != nil {
+		return "", err
+	}
+
+	additionNodes, err := parseFragment(addition)
+	if err != nil {
+		return "", err
+	}
+
+	var last *html.Node
+
+	for _, n := range existingNodes {
+		if n.Type == html.ElementNode {
+			last = n
+		} else if n.Type != html.TextNode || strings.TrimSpace(n.Data) != "" {
+			last = nil
+		}
+	}
+
+	if len(additionNodes) == 0 || last == nil || !sameList(last, additionNodes[0]) {
+		return existing + addition, nil
+	}
+
+	for c := additionNodes[0].FirstChild; c != nil; c = additionNodes[0].FirstChild {
+		additionNodes[0].RemoveChild(c)
+		last.AppendChild(c)
+	}
+
+	var sb strings.Builder
+
+	for _, n := range append(existingNodes, additionNodes[1:]...) {
+		if err := html.Render(&sb, n); err != nil {
+			return "", err
+		}
+	}
+
+	return sb.String(), nil
+}
+
+func sameList(a *html.Node, b *html.Node) bool {
+	return a.Type == html.ElementNode && b.Type == html.ElementNode &&
+		a.DataAtom == atom.Ul && b.DataAtom == atom.Ul &&
+		attr(a, "data-type") == attr(b, "data-type")
+}
@@ -0,0 +1,315 @@
+package notes
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Excerpt formats, FormatJSON returns excerpts as stored
+const (
+	FormatJSON = "json"
+	FormatHTML = "html"
+	FormatText = "text"
+)
+
+// excerptLocator finds excerpts in the HTML of their note so their context can be captured
+// The editor reports excerpts as ProseMirror nodes while notes are stored as HTML, so
+// blocks are matched by their kind and text
+type excerptLocator struct {
+	blocks []*html.Node
+	used   map[*html.Node]bool
+	// fromEnd matches the last unmatched block instead of the first
+	fromEnd bool
+}
+
+func newExcerptLocator(htmlContent string) (*excerptLocator, error) {
+	nodes, err := parseFragment(htmlContent)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &excerptLocator{used: make(map[*html.Node]bool)}
+
+	// Fragment nodes come back detached, put them back under a body so top level blocks have siblings
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	for _, n := range nodes {
+		body.AppendChild(n)
+	}
+
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if excerptKind(n) != "" {
+			l.blocks = append(l.blocks, n)
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+
+	for _, n := range nodes {
+		walk(n)
+	}
+
+	return l, nil
+}
+
+// excerptKind is the kind of block n is when it can hold an excerpt, "" otherwise
+// These are the paragraphs and task items the editor reports excerpts for
+func excerptKind(n *html.Node) string {
+	if n.Type != html.ElementNode {
+		return ""
+	}
+
+	switch {
+	case n.DataAtom == atom.P:
+		return "paragraph"
+	case n.DataAtom == atom.Li && attr(n, "data-type") == "taskItem":
+		return "taskItem"
+	}
+
+	return ""
+}
+
+// Context returns the context of the first block matching excerpt that was not matched before,
+// or the last one when fromEnd is set. Excerpts that are not found in the note get an empty context
+func (l *excerptLocator) Context(excerpt string) (ExcerptContext, error) {
+	rendered, err := ExcerptHTML(excerpt)
+	if err != nil {
+		return ExcerptContext{}, err
+	}
+
+	nodes, err := parseFragment(rendered)
+	if err != nil {
+		return ExcerptContext{}, err
+	}
+
+	var target *html.Node
+
+	for _, n := range nodes {
+		if excerptKind(n) != "" {
+			target = n
+			break
+		}
+	}
+
+	if target == nil {
+		return ExcerptContext{}, nil
+	}
+
+	kind, text := excerptKind(target), nodeText(target)
+
+	for i := range l.blocks {
+		block := l.blocks[i]
+		if l.fromEnd {
+			block = l.blocks[len(l.blocks)-1-i]
+		}
+
+		if l.used[block] || excerptKind(block) != kind || nodeText(block) != text {
+			continue
+		}
+
+		l.used[block] = true
+
+		return blockContext(block)
+	}
+
+	return ExcerptContext{}, nil
+}
+
+// blockContext captures the section heading, parent item and neighbouring blocks of block
+func blockContext(block *html.Node) (ExcerptContext, error) {
+	// The paragraph holding a list item's text stands for the item, so its neighbours are the other items
+	unit := block
+	for item := block.Parent; item != nil && item.DataAtom != atom.Body; item = item.Parent {
+		if item.DataAtom == atom.Li {
+			if itemHead(item) == block {
+				unit = item
+			}
+			break
+		}
+	}
+
+	var result ExcerptContext
+	var err error
+
+	render := func(n *html.Node) string {
+		if n == nil || err != nil {
+			return ""
+		}
+
+		var sb strings.Builder
+		if renderErr := html.Render(&sb, n); renderErr != nil {
+			err = fmt.Errorf("failed to render excerpt context: %w", renderErr)
+		}
+
+		return sb.String()
+	}
+
+	before, after := previousElement(unit), nextElement(unit)
+
+	// The ends of a top level list border the blocks around the list
+	if list := unit.Parent; unit.DataAtom == atom.Li && list != nil && list.Parent != nil && list.Parent.DataAtom == atom.Body {
+		if before == nil {
+			before = previousElement(list)
+		}
+
+		if after == nil {
+			after = nextElement(list)
+		}
+	}
+
+	result.Before = render(edgeBlock(before, false))
+	result.After = render(edgeBlock(after, true))
+
+	// Items of nested lists are written under the item holding the list
+	if unit.DataAtom == atom.Li && unit.Parent != nil && unit.Parent.Parent != nil && unit.Parent.Parent.DataAtom == atom.Li {
+		result.Parent = render(itemHead(unit.Parent.Parent))
+	}
+
+	top := block
+	for top.Parent != nil && top.Parent.DataAtom != atom.Body {
+		top = top.Parent
+	}
+
+	for n := previousElement(top); n != nil; n = previousElement(n) {
+		if isHeading(n) {
+			result.Heading = render(n)
+			break
+		}
+	}
+
+	return result, err
+}
+
+func isHeading(n *html.Node) bool {
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		return true
+	}
+
+	return false
+}
+
+func previousElement(n *html.Node) *html.Node {
+	for s := n.PrevSibling; s != nil; s = s.PrevSibling {
+		if s.Type == html.ElementNode {
+			return s
+		}
+	}
+
+	return nil
+}
+
+func nextElement(n *html.Node) *html.Node {
+	for s := n.NextSibling; s != nil; s = s.NextSibling {
+		if s.Type == html.ElementNode {
+			return s
+		}
+	}
+
+	return nil
+}
+
+// edgeBlock narrows a neighbouring list to the item nearest the excerpt, first when the list
+// follows it and last when it comes before, and list items to their own text
+func edgeBlock(n *html.Node, first bool) *html.Node {
+	if n == nil {
+		return nil
+	}
+
+	switch n.DataAtom {
+	case atom.Ul, atom.Ol:
+		var item *html.Node
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && c.DataAtom == atom.Li {
+				item = c
+				if first {
+					break
+				}
+			}
+		}
+
+		if item == nil {
+			return n
+		}
+
+		return itemHead(item)
+	case atom.Li:
+		return itemHead(n)
+	}
+
+	return n
+}
+
+// itemHead is the paragraph holding the text of a list item, leaving out its nested lists
+func itemHead(item *html.Node) *html.Node {
+	var head *html.Node
+
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil && head == nil; c = c.NextSibling {
+			if c.Type != html.ElementNode || c.DataAtom == atom.Ul || c.DataAtom == atom.Ol {
+				continue
+			}
+
+			if c.DataAtom == atom.P {
+				head = c
+				return
+			}
+
+			walk(c)
+		}
+	}
+
+	walk(item)
+
+	if head == nil {
+		return item
+	}
+
+	return head
+}
+
+// FormatExcerpts renders excerpts and their context in format
+// FormatHTML renders excerpts to HTML and FormatText renders excerpts and context to plain text,
+// FormatJSON leaves excerpts as stored, with context as HTML
+func FormatExcerpts(excerpts []NoteExcerpt, format string) error {
+	switch format {
+	case "", FormatJSON:
+		return nil
+	case FormatHTML, FormatText:
+	default:
+		return fmt.Errorf("unknown excerpt format %q", format)
+	}
+
+	for i := range excerpts {
+		rendered, err := ExcerptHTML(excerpts[i].Excerpt)
+		if err != nil {
+			return err
+		}
+
+		excerpts[i].Excerpt = rendered
+
+		if format == FormatText {
+			c := &excerpts[i].Context
+
+			excerpts[i].Excerpt = excerptText(rendered)
+			c.Heading = excerptText(c.Heading)
+			c.Parent = excerptText(c.Parent)
+			c.Before = excerptText(c.Before)
+			c.After = excerptText(c.After)
+		}
+	}
+
+	return nil
+}
+
+// excerptText is the text of excerpt HTML on one line
+func excerptText(htmlContent string) string {
+	return strings.Join(strings.Fields(PlainText(htmlContent)), " ")
+}
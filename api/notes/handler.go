@@ -3,10 +3,14 @@ package notes
 import (
 	"database/sql"
 	"errors"
+	"io"
+	"mime"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/maybemaby/workpad/api/pagination"
+	"github.com/maybemaby/workpad/api/tz"
 	"github.com/maybemaby/workpad/api/utils"
 )
 
@@ -19,12 +23,12 @@ func NewNoteHandler(noteStore NoteStore) *NoteHandler {
 }
 
 type GetNoteByDateRequest struct {
-	Date string `query:"date" example:"2026-01-01" required:"true"`
+	Date string `query:"date" example:"2026-01-01" required:"true" format:"date"`
 }
 
 type GetMonthNotesRequest struct {
-	Year  int `query:"year" example:"2026" required:"true"`
-	Month int `query:"month" example:"1" required:"true"`
+	Year  int `query:"year" example:"2026" required:"true" minimum:"1"`
+	Month int `query:"month" example:"1" required:"true" minimum:"1" maximum:"12"`
 }
 
 func (h *NoteHandler) GetNoteByDate(w http.ResponseWriter, r *http.Request) {
@@ -60,13 +64,12 @@ func (h *NoteHandler) CreateNote(w http.ResponseWriter, r *http.Request) {
 	var req CreateNoteRequest
 
 	if err := utils.ReadJSON(r, &req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		utils.WriteBodyError(w, err)
 		return
 	}
 
-	currentDate := time.Now().Local()
-
-	note, err := h.noteStore.CreateNote(r.Context(), req.HTMLContent, currentDate)
+	// Notes are filed on the client's day, which may not be the server's
+	note, err := h.noteStore.CreateNote(r.Context(), req.HTMLContent, tz.Today(r.Context()))
 
 	if err != nil {
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
@@ -80,6 +83,50 @@ func (h *NoteHandler) CreateNote(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// maxAppendSize bounds plain text append bodies
+const maxAppendSize = 64 << 10
+
+func (h *NoteHandler) AppendToNote(w http.ResponseWriter, r *http.Request) {
+	parsedDate, err := time.Parse(time.DateOnly, r.PathValue("date"))
+
+	if err != nil {
+		http.Error(w, "Invalid date format. Use YYYY-MM-DD.", http.StatusBadRequest)
+		return
+	}
+
+	var req AppendNoteRequest
+
+	// Shortcuts and shells can post the text as is instead of wrapping it in JSON
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	if mediaType == "text/plain" || mediaType == "text/markdown" {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxAppendSize))
+
+		if err != nil {
+			utils.WriteBodyError(w, err)
+			return
+		}
+
+		req.Text = string(body)
+	} else if err := utils.ReadJSON(r, &req); err != nil {
+		utils.WriteBodyError(w, err)
+		return
+	}
+
+	note, err := h.noteStore.AppendToNote(r.Context(), parsedDate, req.Text)
+
+	if err != nil {
+		if errors.Is(err, ErrEmptyText) {
+			http.Error(w, "Text is required", http.StatusBadRequest)
+		} else {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	utils.WriteJSON(w, r, note)
+}
+
 func (h *NoteHandler) GetMonthNotes(w http.ResponseWriter, r *http.Request) {
 	month := r.URL.Query().Get("month")
 	year := r.URL.Query().Get("year")
@@ -113,7 +160,7 @@ func (h *NoteHandler) UpdateNoteExcerpts(w http.ResponseWriter, r *http.Request)
 	var data UpdateNoteExcerptRequest
 
 	if err := utils.ReadJSON(r, &data); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		utils.WriteBodyError(w, err)
 		return
 	}
 
@@ -124,29 +171,193 @@ func (h *NoteHandler) UpdateNoteExcerpts(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err = h.noteStore.UpdateExcerptsForDate(r.Context(), parsedDate, data.Excerpts)
+	_, err = h.noteStore.UpdateExcerptsForDate(r.Context(), parsedDate, data.Excerpts, SaveExcerptsOptions{CreateProjects: data.CreateProjects})
 
 	if err != nil {
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		writeSaveError(w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// SaveNote handles PUT /notes/{date}, storing the note and rebuilding its excerpts at once
+func (h *NoteHandler) SaveNote(w http.ResponseWriter, r *http.Request) {
+	parsedDate, err := time.Parse(time.DateOnly, r.PathValue("date"))
+
+	if err != nil {
+		http.Error(w, "Invalid date format. Use YYYY-MM-DD.", http.StatusBadRequest)
+		return
+	}
+
+	var req SaveNoteRequest
+
+	if err := utils.ReadJSON(r, &req); err != nil {
+		utils.WriteBodyError(w, err)
+		return
+	}
+
+	note, _, err := h.noteStore.SaveNote(r.Context(), parsedDate, req.HTMLContent, req.Excerpts, SaveExcerptsOptions{CreateProjects: req.CreateProjects})
+
+	if err != nil {
+		writeSaveError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, r, note)
+}
+
+// writeSaveError responds 404 when there is no note, 422 listing the projects excerpts could
+// not be saved for, or 500
+func writeSaveError(w http.ResponseWriter, err error) {
+	var projectErrs ProjectErrors
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, "Note not found", http.StatusNotFound)
+	case errors.As(err, &projectErrs):
+		utils.ErrorJSON(w, ProjectErrorResponse{
+			Message: "Excerpts mention projects that cannot be saved",
+			Status:  http.StatusUnprocessableEntity,
+			Errors:  projectErrs,
+		}, http.StatusUnprocessableEntity)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
 type GetExcerptsForProjectRequest struct {
-	Project string `path:"project" example:"Project A" required:"true"`
+	Project            string `path:"project" example:"Project A" required:"true"`
+	IncludeDescendants bool   `query:"include_descendants" example:"true" required:"false"`
+	// Sort orders excerpts by note date, newest by default
+	Sort   string `query:"sort" example:"oldest" required:"false" enum:"newest,oldest"`
+	Limit  int    `query:"limit" example:"50" required:"false" minimum:"1" maximum:"200"`
+	Cursor string `query:"cursor" required:"false"`
+	// Format renders excerpts to HTML or plain text, json returns the editor's ProseMirror JSON
+	Format string `query:"format" example:"html" required:"false" enum:"json,html,text"`
+}
+
+// excerptFormat reads the format query param of r
+func excerptFormat(r *http.Request) (string, bool) {
+	format := r.URL.Query().Get("format")
+
+	switch format {
+	case "", FormatJSON, FormatHTML, FormatText:
+		return format, true
+	}
+
+	return "", false
 }
 
 func (h *NoteHandler) GetExcerptsForProject(w http.ResponseWriter, r *http.Request) {
 	projectName := r.PathValue("project")
 
-	excerpts, err := h.noteStore.GetExcerptsForProject(r.Context(), projectName)
+	limit, cursor, err := pagination.Params(r)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	format, ok := excerptFormat(r)
+
+	if !ok {
+		http.Error(w, "Invalid format parameter", http.StatusBadRequest)
+		return
+	}
+
+	opts := ExcerptOptions{Sort: r.URL.Query().Get("sort"), Limit: limit, Cursor: cursor}
+
+	if value := r.URL.Query().Get("include_descendants"); value != "" {
+		includeDescendants, err := strconv.ParseBool(value)
+
+		if err != nil {
+			http.Error(w, "Invalid include_descendants parameter", http.StatusBadRequest)
+			return
+		}
+
+		opts.IncludeDescendants = includeDescendants
+	}
+
+	excerpts, next, err := h.noteStore.GetExcerptsForProject(r.Context(), projectName, opts)
+
+	if err != nil {
+		if errors.Is(err, pagination.ErrInvalidCursor) {
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+		} else {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if err := FormatExcerpts(excerpts, format); err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	pagination.Write(w, r, pagination.Page[NoteExcerpt]{Items: excerpts, NextCursor: next})
+}
+
+type GetExcerptsForTagRequest struct {
+	Tag string `path:"tag" example:"decision" required:"true"`
+	// Format renders blocks to HTML or plain text, json returns the editor's ProseMirror JSON
+	Format string `query:"format" example:"html" required:"false" enum:"json,html,text"`
+}
+
+func (h *NoteHandler) GetTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := h.noteStore.GetTags(r.Context())
 
 	if err != nil {
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
+	utils.WriteJSON(w, r, tags)
+}
+
+func (h *NoteHandler) GetExcerptsForTag(w http.ResponseWriter, r *http.Request) {
+	tag := r.PathValue("tag")
+
+	format, ok := excerptFormat(r)
+
+	if !ok {
+		http.Error(w, "Invalid format parameter", http.StatusBadRequest)
+		return
+	}
+
+	excerpts, err := h.noteStore.GetExcerptsForTag(r.Context(), tag)
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := FormatExcerpts(excerpts, format); err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
 	utils.WriteJSON(w, r, excerpts)
 }
+
+type GetBacklinksRequest struct {
+	Date string `path:"date" example:"2026-01-01" required:"true" format:"date"`
+}
+
+func (h *NoteHandler) GetBacklinks(w http.ResponseWriter, r *http.Request) {
+	parsedDate, err := time.Parse(time.DateOnly, r.PathValue("date"))
+
+	if err != nil {
+		http.Error(w, "Invalid date format. Use YYYY-MM-DD.", http.StatusBadRequest)
+		return
+	}
+
+	backlinks, err := h.noteStore.GetBacklinks(r.Context(), parsedDate)
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, r, backlinks)
+}
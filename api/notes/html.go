@@ -0,0 +1,174 @@
+package notes
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// blockElements separate words even when the editor emits no whitespace between them
+var blockElements = map[atom.Atom]bool{
+	atom.P:          true,
+	atom.Div:        true,
+	atom.Li:         true,
+	atom.Ul:         true,
+	atom.Ol:         true,
+	atom.H1:         true,
+	atom.H2:         true,
+	atom.H3:         true,
+	atom.H4:         true,
+	atom.H5:         true,
+	atom.H6:         true,
+	atom.Blockquote: true,
+	atom.Pre:        true,
+	atom.Br:         true,
+	atom.Hr:         true,
+	atom.Label:      true,
+}
+
+// PlainText strips markup from note HTML, keeping block boundaries as whitespace
+func PlainText(htmlContent string) string {
+	var sb strings.Builder
+
+	tokenizer := html.NewTokenizer(strings.NewReader(htmlContent))
+
+	for {
+		tokenType := tokenizer.Next()
+
+		switch tokenType {
+		case html.ErrorToken:
+			// io.EOF or a malformed document, either way return what was read
+			return strings.TrimSpace(sb.String())
+		case html.TextToken:
+			sb.Write(tokenizer.Text())
+		case html.StartTagToken, html.EndTagToken, html.SelfClosingTagToken:
+			name, _ := tokenizer.TagName()
+
+			if blockElements[atom.Lookup(name)] {
+				sb.WriteByte(' ')
+			}
+		}
+	}
+}
+
+// WordCount returns the number of whitespace separated words in the note's text
+func WordCount(htmlContent string) int {
+	return len(strings.Fields(PlainText(htmlContent)))
+}
+
+// Block is a top level unit of text in a note, such as a paragraph, heading or task item
+type Block struct {
+	// Index is the position of the block in document order
+	Index int
+	Node  *html.Node
+	// HTML is the rendered outer HTML of the block
+	HTML string
+	Text string
+}
+
+// isBlock reports whether n is one of the blocks a note is split into
+// Task items are kept whole, while plain list items are split into their paragraphs
+func isBlock(n *html.Node) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+
+	switch n.DataAtom {
+	case atom.P, atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Pre:
+		return true
+	case atom.Li:
+		return attr(n, "data-type") == "taskItem"
+	}
+
+	return false
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+
+	return ""
+}
+
+// nodeText concatenates the text below n, separating block elements with whitespace
+func nodeText(n *html.Node) string {
+	var sb strings.Builder
+
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+			return
+		}
+
+		if n.Type == html.ElementNode && blockElements[n.DataAtom] {
+			sb.WriteByte(' ')
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+
+	walk(n)
+
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+func parseFragment(htmlContent string) ([]*html.Node, error) {
+	return html.ParseFragment(strings.NewReader(htmlContent), &html.Node{
+		Type:     html.ElementNode,
+		Data:     "body",
+		DataAtom: atom.Body,
+	})
+}
+
+// Blocks splits note HTML into its blocks in document order
+func Blocks(htmlContent string) ([]Block, error) {
+	nodes, err := parseFragment(htmlContent)
+	if err != nil {
+		return nil, err
+	}
+
+	var blocks []Block
+	var walkErr error
+
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if walkErr != nil {
+			return
+		}
+
+		if isBlock(n) {
+			var sb strings.Builder
+
+			if err := html.Render(&sb, n); err != nil {
+				walkErr = err
+				return
+			}
+
+			blocks = append(blocks, Block{
+				Index: len(blocks),
+				Node:  n,
+				HTML:  sb.String(),
+				Text:  nodeText(n),
+			})
+
+			return
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+
+	for _, n := range nodes {
+		walk(n)
+	}
+
+	return blocks, walkErr
+}
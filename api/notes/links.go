@@ -0,0 +1,88 @@
+package notes
+
+import (
+	"regexp"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// maxSnippetLength caps the block text stored with a link, in runes
+const maxSnippetLength = 280
+
+var (
+	datePattern     = regexp.MustCompile(`\b(\d{4}-\d{2}-\d{2})\b`)
+	datePathPattern = regexp.MustCompile(`/dates/(\d{4}-\d{2}-\d{2})(?:[/?#]|$)`)
+)
+
+// BlockLink is a reference from one block of a note to another day
+type BlockLink struct {
+	TargetDate string
+	Block      Block
+}
+
+func validDate(value string) bool {
+	_, err := time.Parse(time.DateOnly, value)
+	return err == nil
+}
+
+// Snippet shortens block text for display next to a backlink
+func Snippet(text string) string {
+	runes := []rune(text)
+
+	if len(runes) <= maxSnippetLength {
+		return text
+	}
+
+	return string(runes[:maxSnippetLength-1]) + "…"
+}
+
+// ExtractLinks finds references to other days in each block
+// Plain YYYY-MM-DD dates, note link nodes (data-type="noteLink" with data-date) and
+// anchors pointing at /dates/YYYY-MM-DD all count. Links to sourceDate itself are dropped
+func ExtractLinks(blocks []Block, sourceDate string) []BlockLink {
+	var links []BlockLink
+
+	for _, block := range blocks {
+		seen := map[string]bool{sourceDate: true}
+
+		add := func(date string) {
+			if seen[date] || !validDate(date) {
+				return
+			}
+
+			seen[date] = true
+			links = append(links, BlockLink{TargetDate: date, Block: block})
+		}
+
+		var walk func(*html.Node)
+		walk = func(n *html.Node) {
+			switch n.Type {
+			case html.TextNode:
+				for _, match := range datePattern.FindAllStringSubmatch(n.Data, -1) {
+					add(match[1])
+				}
+				return
+			case html.ElementNode:
+				if attr(n, "data-type") == "noteLink" {
+					add(attr(n, "data-date"))
+				}
+
+				if n.DataAtom == atom.A {
+					if match := datePathPattern.FindStringSubmatch(attr(n, "href")); match != nil {
+						add(match[1])
+					}
+				}
+			}
+
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				walk(c)
+			}
+		}
+
+		walk(block.Node)
+	}
+
+	return links
+}
@@ -1,28 +1,117 @@
 package notes
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/maybemaby/workpad/api/pagination"
+	"github.com/maybemaby/workpad/api/projects"
+)
 
 type Note struct {
 	HTMLContent string    `json:"html_content" required:"true" db:"html_content"`
 	Date        time.Time `json:"note_date" required:"true" db:"note_date"`
 	Id          int       `json:"id" required:"true"`
+	UpdatedAt   time.Time `json:"updated_at" required:"true" db:"updated_at"`
+	// Stripped lists content the sanitizer removed, only set in responses to writes
+	Stripped []string `json:"stripped,omitempty" db:"-" required:"false" example:"[<script> element]"`
 }
 
 type CreateNoteRequest struct {
 	HTMLContent string `json:"html_content" required:"true"`
 }
 
+// AppendNoteRequest is quick-capture text for the note of a day
+// The text may also be sent as the whole body with a text/plain or text/markdown content type
+type AppendNoteRequest struct {
+	Date string `json:"-" path:"date" example:"2026-01-01" required:"true" format:"date"`
+	Text string `json:"text" required:"true" example:"- [ ] Call the vendor about @OKT123 by 2026-11-02"`
+}
+
 type NoteExcerpt struct {
 	ProjectName string `json:"project_name" required:"true" db:"project_name"`
 	Excerpt     string `json:"excerpt" required:"true"`
 	Date        string `json:"date" required:"true" db:"note_date"`
 	Id          int    `json:"id" required:"true"`
 	NoteId      int    `json:"note_id" required:"true" db:"note_id"`
+	// UpdatedAt is when the note the excerpt came from last changed
+	UpdatedAt time.Time `json:"updated_at" required:"true" db:"updated_at"`
+	// Context is the part of the note the excerpt was written in
+	Context ExcerptContext `json:"context" required:"true" db:"context"`
 }
 
+// ExcerptContext holds the blocks around an excerpt as HTML, or as text in the text format
+// Blocks the excerpt does not have are empty
+type ExcerptContext struct {
+	// Heading is the nearest heading above the excerpt
+	Heading string `json:"heading,omitempty" required:"false" db:"heading" example:"<h2>Standup</h2>"`
+	// Parent is the list item the excerpt is nested under
+	Parent string `json:"parent,omitempty" required:"false" db:"parent" example:"<p>Vendor follow-ups</p>"`
+	Before string `json:"before,omitempty" required:"false" db:"before"`
+	After  string `json:"after,omitempty" required:"false" db:"after"`
+}
+
+// ExcerptPage is a page of GET /notes/excerpts/{project}, named for the OpenAPI spec
+type ExcerptPage pagination.Page[NoteExcerpt]
+
 type UpdateNoteExcerptRequest struct {
 	Excerpts []ExcerptNode `json:"excerpts" required:"true" nullable:"false"`
-	Date     string        `json:"date" required:"true" example:"2026-01-01"`
+	Date     string        `json:"date" required:"true" example:"2026-01-01" format:"date"`
+	// CreateProjects creates mentioned projects that do not exist instead of rejecting the update
+	CreateProjects bool `json:"create_projects" required:"false"`
+}
+
+// SaveNoteRequest saves a day's note and rebuilds its excerpts together
+type SaveNoteRequest struct {
+	Date        string        `json:"-" path:"date" example:"2026-01-01" required:"true" format:"date"`
+	HTMLContent string        `json:"html_content" required:"true"`
+	Excerpts    []ExcerptNode `json:"excerpts" required:"true" nullable:"false"`
+	// CreateProjects creates mentioned projects that do not exist instead of rejecting the save
+	CreateProjects bool `json:"create_projects" required:"false"`
+}
+
+// SaveExcerptsOptions controls how excerpts mentioning unknown projects are saved
+type SaveExcerptsOptions struct {
+	// CreateProjects creates unknown projects, otherwise they fail the save with ProjectErrors
+	CreateProjects bool
+}
+
+// SavedExcerpts describes a successful excerpt rebuild
+type SavedExcerpts struct {
+	// Projects lists the projects excerpts were saved for, aliases resolved
+	Projects []string
+	// Created lists the projects created for the excerpts
+	Created []projects.Project
+	// Changed reports whether the excerpts differ from the ones they replaced, which they
+	// always do when projects were created for them
+	Changed bool
+}
+
+// ProjectError is a mentioned project excerpts could not be saved for
+type ProjectError struct {
+	Project string `json:"project" required:"true" example:"OKT123"`
+	Message string `json:"message" required:"true" example:"project does not exist"`
+}
+
+// ProjectErrors is returned when excerpts mention projects they cannot be saved for, nothing is saved
+type ProjectErrors []ProjectError
+
+func (e ProjectErrors) Error() string {
+	messages := make([]string, len(e))
+
+	for i, projectErr := range e {
+		messages[i] = fmt.Sprintf("%s: %s", projectErr.Project, projectErr.Message)
+	}
+
+	return strings.Join(messages, ", ")
+}
+
+// ProjectErrorResponse is returned with 422 when excerpts mention projects they cannot be saved for
+type ProjectErrorResponse struct {
+	Message string         `json:"message" required:"true" example:"Excerpts mention projects that cannot be saved"`
+	Status  int            `json:"status" required:"true" enum:"422"`
+	Errors  []ProjectError `json:"errors" required:"true" nullable:"false"`
 }
 
 type UpdateNoteExcerptData struct {
@@ -33,3 +122,37 @@ type ExcerptNode struct {
 	Projects []string `json:"projects" example:"[Project A,Project B]" required:"true" nullable:"false"`
 	Node     string   `json:"node" required:"true" example:"{\"type\":\"paragraph\",\"content\":[{\"type\":\"text\",\"text\":\"Sample excerpt text.\"}]}"`
 }
+
+// Excerpt sorts by note date
+const (
+	SortNewest = "newest"
+	SortOldest = "oldest"
+)
+
+// ExcerptOptions controls which excerpts GetExcerptsForProject returns
+type ExcerptOptions struct {
+	// IncludeDescendants also returns excerpts mentioning any sub-project
+	IncludeDescendants bool
+	// Sort is SortNewest or SortOldest, SortNewest when empty
+	Sort string
+	// Limit is the page size, every excerpt is returned when it is 0
+	Limit int
+	// Cursor continues after the page it was returned with
+	Cursor string
+}
+
+type TagCount struct {
+	Tag string `json:"tag" required:"true" example:"decision"`
+	// Count is the number of blocks carrying the tag
+	Count     int    `json:"count" required:"true"`
+	NoteCount int    `json:"note_count" required:"true" db:"note_count"`
+	LastUsed  string `json:"last_used" required:"true" db:"last_used" example:"2026-01-01"`
+}
+
+// Backlink is a block in another day's note that references a date
+type Backlink struct {
+	SourceNoteId int    `json:"source_note_id" required:"true" db:"source_note_id"`
+	SourceDate   string `json:"source_date" required:"true" db:"source_date" example:"2026-03-05"`
+	BlockIndex   int    `json:"block_index" required:"true" db:"block_index"`
+	Snippet      string `json:"snippet" required:"true" example:"Follow up on the notes from 2026-03-04"`
+}
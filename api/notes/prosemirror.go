@@ -0,0 +1,370 @@
+package notes
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"slices"
+	"strconv"
+	"strings"
+
+	nethtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// PMNode is a ProseMirror node as serialized by the TipTap editor
+type PMNode struct {
+	Type    string         `json:"type"`
+	Attrs   map[string]any `json:"attrs,omitempty"`
+	Content []PMNode       `json:"content,omitempty"`
+	Text    string         `json:"text,omitempty"`
+	Marks   []PMMark       `json:"marks,omitempty"`
+}
+
+type PMMark struct {
+	Type  string         `json:"type"`
+	Attrs map[string]any `json:"attrs,omitempty"`
+}
+
+// pmElements maps ProseMirror block nodes to the HTML the editor renders them as
+var pmElements = map[string]string{
+	"doc":         "",
+	"paragraph":   "p",
+	"blockquote":  "blockquote",
+	"bulletList":  "ul",
+	"orderedList": "ol",
+	"listItem":    "li",
+}
+
+// pmMarks maps ProseMirror marks to their HTML elements, links are handled separately
+var pmMarks = map[string]string{
+	"bold":        "strong",
+	"italic":      "em",
+	"strike":      "s",
+	"underline":   "u",
+	"code":        "code",
+	"highlight":   "mark",
+	"subscript":   "sub",
+	"superscript": "sup",
+}
+
+// htmlMarks maps HTML inline elements back to ProseMirror marks, including the older tags browsers paste
+var htmlMarks = map[atom.Atom]string{
+	atom.Strong: "bold",
+	atom.B:      "bold",
+	atom.Em:     "italic",
+	atom.I:      "italic",
+	atom.S:      "strike",
+	atom.Strike: "strike",
+	atom.Del:    "strike",
+	atom.U:      "underline",
+	atom.Code:   "code",
+	atom.Mark:   "highlight",
+	atom.Sub:    "subscript",
+	atom.Sup:    "superscript",
+}
+
+func (n PMNode) attr(key string) string {
+	value, ok := n.Attrs[key]
+	if !ok || value == nil {
+		return ""
+	}
+
+	return fmt.Sprint(value)
+}
+
+// ParsePMNode decodes a serialized ProseMirror node
+func ParsePMNode(node string) (PMNode, error) {
+	var parsed PMNode
+
+	err := json.Unmarshal([]byte(node), &parsed)
+
+	return parsed, err
+}
+
+// RenderPMNode renders a ProseMirror node to the HTML the editor would produce
+// Unknown node types render their children so no text is lost
+func RenderPMNode(n PMNode) string {
+	var sb strings.Builder
+
+	renderPMNode(&sb, n)
+
+	return sb.String()
+}
+
+func renderPMNode(sb *strings.Builder, n PMNode) {
+	children := func() {
+		for _, c := range n.Content {
+			renderPMNode(sb, c)
+		}
+	}
+
+	switch n.Type {
+	case "text":
+		renderPMText(sb, n)
+	case "hardBreak":
+		sb.WriteString("<br>")
+	case "horizontalRule":
+		sb.WriteString("<hr>")
+	case "heading":
+		level := n.attr("level")
+		if len(level) != 1 || level < "1" || level > "6" {
+			level = "1"
+		}
+
+		sb.WriteString("<h" + level + ">")
+		children()
+		sb.WriteString("</h" + level + ">")
+	case "codeBlock":
+		sb.WriteString("<pre><code")
+		if lang := n.attr("language"); lang != "" {
+			sb.WriteString(` class="language-` + html.EscapeString(lang) + `"`)
+		}
+		sb.WriteString(">")
+		children()
+		sb.WriteString("</code></pre>")
+	case "taskList":
+		sb.WriteString(`<ul data-type="taskList">`)
+		children()
+		sb.WriteString("</ul>")
+	case "taskItem":
+		checked := n.attr("checked") == "true"
+
+		fmt.Fprintf(sb, `<li data-type="taskItem" data-checked="%t"><label><input type="checkbox"`, checked)
+		if checked {
+			sb.WriteString(" checked")
+		}
+		sb.WriteString("></label><div>")
+		children()
+		sb.WriteString("</div></li>")
+	case "mention":
+		id := n.attr("id")
+		label := n.attr("label")
+		if label == "" {
+			label = id
+		}
+
+		// data-id and data-label are what the editor reads back when the HTML is loaded
+		fmt.Fprintf(sb, `<span class="mention" data-type="mention" data-id="%[1]s" data-label="%[2]s" data-mention-id="%[1]s">@%[2]s</span>`, html.EscapeString(id), html.EscapeString(label))
+	case "image":
+		src := n.attr("src")
+		if !safeURL(atom.Img, src) {
+			return
+		}
+
+		fmt.Fprintf(sb, `<img src="%s" alt="%s">`, html.EscapeString(src), html.EscapeString(n.attr("alt")))
+	default:
+		tag, known := pmElements[n.Type]
+
+		if !known || tag == "" {
+			children()
+			return
+		}
+
+		sb.WriteString("<" + tag + ">")
+		children()
+		sb.WriteString("</" + tag + ">")
+	}
+}
+
+// BlockNode converts a block of note HTML, such as a Block's node, to the ProseMirror node the
+// editor would load it as. Tag marks become their text, which is how RenderPMNode would show them
+func BlockNode(n *nethtml.Node) PMNode {
+	switch {
+	case n.DataAtom == atom.Pre:
+		node := PMNode{Type: "codeBlock"}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.DataAtom == atom.Code {
+				if lang, ok := strings.CutPrefix(attr(c, "class"), "language-"); ok {
+					node.Attrs = map[string]any{"language": lang}
+				}
+			}
+		}
+
+		if text := rawText(n); text != "" {
+			node.Content = []PMNode{{Type: "text", Text: text}}
+		}
+
+		return node
+	case isHeading(n):
+		level, _ := strconv.Atoi(n.Data[1:])
+
+		return PMNode{Type: "heading", Attrs: map[string]any{"level": level}, Content: pmInline(n, nil)}
+	case n.DataAtom == atom.P:
+		return PMNode{Type: "paragraph", Content: pmInline(n, nil)}
+	case n.DataAtom == atom.Ul && attr(n, "data-type") == "taskList":
+		return PMNode{Type: "taskList", Content: pmBlocks(n)}
+	case n.DataAtom == atom.Li && attr(n, "data-type") == "taskItem":
+		return PMNode{Type: "taskItem", Attrs: map[string]any{"checked": attr(n, "data-checked") == "true"}, Content: pmBlocks(n)}
+	case n.DataAtom == atom.Hr:
+		return PMNode{Type: "horizontalRule"}
+	case n.DataAtom == atom.Img:
+		return PMNode{Type: "image", Attrs: map[string]any{"src": attr(n, "src"), "alt": attr(n, "alt")}}
+	case n.DataAtom == atom.Blockquote:
+		return PMNode{Type: "blockquote", Content: pmBlocks(n)}
+	case n.DataAtom == atom.Ul:
+		return PMNode{Type: "bulletList", Content: pmBlocks(n)}
+	case n.DataAtom == atom.Ol:
+		return PMNode{Type: "orderedList", Content: pmBlocks(n)}
+	case n.DataAtom == atom.Li:
+		return PMNode{Type: "listItem", Content: pmBlocks(n)}
+	}
+
+	return PMNode{Type: "paragraph", Content: pmInline(n, nil)}
+}
+
+// isPMBlock reports whether n converts to a ProseMirror block node rather than inline content
+func isPMBlock(n *nethtml.Node) bool {
+	if n.Type != nethtml.ElementNode {
+		return false
+	}
+
+	switch n.DataAtom {
+	case atom.P, atom.Pre, atom.Blockquote, atom.Ul, atom.Ol, atom.Li, atom.Hr:
+		return true
+	}
+
+	return isHeading(n)
+}
+
+// pmBlocks converts the children of a container, wrapping runs of inline content in paragraphs
+func pmBlocks(n *nethtml.Node) []PMNode {
+	var blocks []PMNode
+	var inline []PMNode
+
+	flush := func() {
+		for _, c := range inline {
+			if c.Type != "text" || strings.TrimSpace(c.Text) != "" {
+				blocks = append(blocks, PMNode{Type: "paragraph", Content: inline})
+				break
+			}
+		}
+
+		inline = nil
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch {
+		case c.DataAtom == atom.Label && c.Type == nethtml.ElementNode:
+			// The checkbox of a task item, its state is the item's checked attribute
+		case c.DataAtom == atom.Div && c.Type == nethtml.ElementNode:
+			flush()
+			blocks = append(blocks, pmBlocks(c)...)
+		case isPMBlock(c):
+			flush()
+			blocks = append(blocks, BlockNode(c))
+		default:
+			inline = append(inline, pmInline(c, nil)...)
+		}
+	}
+
+	flush()
+
+	return blocks
+}
+
+// pmInline converts inline HTML to text nodes carrying marks, n itself adds a mark when it is one
+func pmInline(n *nethtml.Node, marks []PMMark) []PMNode {
+	switch n.Type {
+	case nethtml.TextNode:
+		if n.Data == "" {
+			return nil
+		}
+
+		return []PMNode{{Type: "text", Text: n.Data, Marks: marks}}
+	case nethtml.ElementNode:
+	default:
+		return nil
+	}
+
+	switch {
+	case n.DataAtom == atom.Br:
+		return []PMNode{{Type: "hardBreak"}}
+	case n.DataAtom == atom.Img:
+		return []PMNode{BlockNode(n)}
+	case attr(n, "data-type") == "mention":
+		id, label := attr(n, "data-id"), attr(n, "data-label")
+		if label == "" {
+			label = strings.TrimPrefix(nodeText(n), "@")
+		}
+
+		return []PMNode{{Type: "mention", Attrs: map[string]any{"id": id, "label": label}}}
+	case n.DataAtom == atom.A:
+		// Clipped so sibling elements never share the appended mark
+		marks = append(slices.Clip(marks), PMMark{Type: "link", Attrs: map[string]any{"href": attr(n, "href")}})
+	case htmlMarks[n.DataAtom] != "":
+		marks = append(slices.Clip(marks), PMMark{Type: htmlMarks[n.DataAtom]})
+	}
+
+	var nodes []PMNode
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		nodes = append(nodes, pmInline(c, marks)...)
+	}
+
+	return nodes
+}
+
+// rawText concatenates the text below n as is, for code where whitespace matters
+func rawText(n *nethtml.Node) string {
+	if n.Type == nethtml.TextNode {
+		return n.Data
+	}
+
+	var sb strings.Builder
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sb.WriteString(rawText(c))
+	}
+
+	return sb.String()
+}
+
+func renderPMText(sb *strings.Builder, n PMNode) {
+	var closers []string
+
+	for _, mark := range n.Marks {
+		if mark.Type == "link" {
+			href, _ := mark.Attrs["href"].(string)
+			if !safeURL(atom.A, href) {
+				continue
+			}
+
+			sb.WriteString(`<a href="` + html.EscapeString(href) + `">`)
+			closers = append(closers, "</a>")
+			continue
+		}
+
+		if tag, ok := pmMarks[mark.Type]; ok {
+			sb.WriteString("<" + tag + ">")
+			closers = append(closers, "</"+tag+">")
+		}
+	}
+
+	sb.WriteString(html.EscapeString(n.Text))
+
+	for i := len(closers) - 1; i >= 0; i-- {
+		sb.WriteString(closers[i])
+	}
+}
+
+// ExcerptHTML returns safe HTML for a stored excerpt
+// Excerpts are either serialized ProseMirror nodes or the outer HTML of the mentioning block
+func ExcerptHTML(excerpt string) (string, error) {
+	trimmed := strings.TrimSpace(excerpt)
+
+	if strings.HasPrefix(trimmed, "{") {
+		node, err := ParsePMNode(trimmed)
+		if err == nil {
+			return RenderPMNode(node), nil
+		}
+	}
+
+	sanitized, err := Sanitize(excerpt)
+	if err != nil {
+		return "", err
+	}
+
+	return sanitized.HTML, nil
+}
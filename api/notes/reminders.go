@@ -0,0 +1,151 @@
+package notes
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/maybemaby/workpad/api/workspaces"
+	"golang.org/x/net/html"
+)
+
+// TaskReminder is an open task item that ends in a due date
+type TaskReminder struct {
+	Text        string
+	DueDate     string
+	ProjectName string
+}
+
+// mentionedProject returns the first project mentioned below n
+func mentionedProject(n *html.Node) string {
+	if n.Type == html.ElementNode {
+		if id := attr(n, "data-mention-id"); id != "" {
+			return id
+		}
+
+		if attr(n, "data-type") == "mention" {
+			return attr(n, "data-id")
+		}
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if project := mentionedProject(c); project != "" {
+			return project
+		}
+	}
+
+	return ""
+}
+
+// ExtractTaskReminders finds unchecked task items mentioning a date, such as
+// "@OKT123 follow up with vendor by 2026-11-02". The last date in the item is the due date
+func ExtractTaskReminders(blocks []Block) []TaskReminder {
+	var reminders []TaskReminder
+
+	for _, block := range blocks {
+		if attr(block.Node, "data-type") != "taskItem" || attr(block.Node, "data-checked") == "true" {
+			continue
+		}
+
+		matches := datePattern.FindAllStringSubmatch(block.Text, -1)
+
+		if len(matches) == 0 {
+			continue
+		}
+
+		dueDate := matches[len(matches)-1][1]
+
+		if !validDate(dueDate) {
+			continue
+		}
+
+		reminders = append(reminders, TaskReminder{
+			Text:        block.Text,
+			DueDate:     dueDate,
+			ProjectName: mentionedProject(block.Node),
+		})
+	}
+
+	return reminders
+}
+
+// syncDetectedReminders reconciles reminders detected from task items with the note's
+// current content. Unchanged reminders are kept so they do not fire again after every save
+func syncDetectedReminders(ctx context.Context, tx *sqlx.Tx, noteId int, blocks []Block) error {
+	var existing []struct {
+		Id      int    `db:"id"`
+		Text    string `db:"text"`
+		DueDate string `db:"due_date"`
+	}
+
+	err := tx.SelectContext(ctx, &existing, `SELECT id, text, date(due_date) AS due_date FROM reminders WHERE note_id = ? AND source = 'detected'`, noteId)
+
+	if err != nil {
+		return err
+	}
+
+	key := func(text string, dueDate string) string {
+		return text + "\x00" + dueDate
+	}
+
+	tasks := ExtractTaskReminders(blocks)
+	missing := make(map[string]bool, len(tasks))
+
+	for _, task := range tasks {
+		missing[key(task.Text, task.DueDate)] = true
+	}
+
+	for _, reminder := range existing {
+		k := key(reminder.Text, reminder.DueDate)
+
+		if missing[k] {
+			delete(missing, k)
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM reminders WHERE id = ?`, reminder.Id); err != nil {
+			return err
+		}
+	}
+
+	for _, task := range tasks {
+		k := key(task.Text, task.DueDate)
+
+		if !missing[k] {
+			continue
+		}
+
+		delete(missing, k)
+
+		var projectName *string
+
+		if task.ProjectName != "" {
+			// Only link projects that exist, resolving aliases like excerpts do
+			var canonical string
+
+			ws, err := workspaces.ID(ctx)
+
+			if err != nil {
+				return err
+			}
+
+			err = tx.QueryRowContext(ctx, selectProjectSQL, ws, ws, task.ProjectName, task.ProjectName).Scan(&canonical)
+
+			switch {
+			case err == nil:
+				projectName = &canonical
+			case !errors.Is(err, sql.ErrNoRows):
+				return err
+			}
+		}
+
+		_, err := tx.ExecContext(ctx, `INSERT INTO reminders (note_id, project_name, text, due_date, source) VALUES (?, ?, ?, ?, 'detected')`, noteId, projectName, task.Text, task.DueDate)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
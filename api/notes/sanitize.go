@@ -0,0 +1,238 @@
+package notes
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// droppedElements are removed together with their content
+var droppedElements = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Iframe:   true,
+	atom.Frame:    true,
+	atom.Frameset: true,
+	atom.Object:   true,
+	atom.Embed:    true,
+	atom.Template: true,
+	atom.Noscript: true,
+	atom.Textarea: true,
+	atom.Select:   true,
+	atom.Svg:      true,
+	atom.Math:     true,
+	atom.Link:     true,
+	atom.Meta:     true,
+	atom.Base:     true,
+	atom.Title:    true,
+}
+
+// globalAttributes are allowed on every element, covering the data attributes the
+// editor uses for mentions, tags, note links and task items
+var globalAttributes = map[string]bool{
+	"class":                        true,
+	"data-type":                    true,
+	"data-id":                      true,
+	"data-label":                   true,
+	"data-mention-id":              true,
+	"data-mention-suggestion-char": true,
+	"data-checked":                 true,
+	"data-date":                    true,
+	"contenteditable":              true,
+}
+
+// allowedElements maps the node and mark set produced by the TipTap editor to the
+// attributes each may carry on top of globalAttributes
+var allowedElements = map[atom.Atom]map[string]bool{
+	atom.P:          {},
+	atom.Br:         {},
+	atom.Hr:         {},
+	atom.H1:         {},
+	atom.H2:         {},
+	atom.H3:         {},
+	atom.H4:         {},
+	atom.H5:         {},
+	atom.H6:         {},
+	atom.Ul:         {},
+	atom.Ol:         {"start": true},
+	atom.Li:         {},
+	atom.Blockquote: {},
+	atom.Pre:        {},
+	atom.Code:       {},
+	atom.Strong:     {},
+	atom.B:          {},
+	atom.Em:         {},
+	atom.I:          {},
+	atom.S:          {},
+	atom.U:          {},
+	atom.Mark:       {},
+	atom.Sub:        {},
+	atom.Sup:        {},
+	atom.Span:       {},
+	atom.Div:        {},
+	atom.Label:      {},
+	atom.Input:      {"type": true, "checked": true, "disabled": true},
+	atom.A:          {"href": true, "target": true, "rel": true},
+	atom.Img:        {"src": true, "alt": true, "title": true, "width": true, "height": true},
+}
+
+var dataImagePattern = regexp.MustCompile(`^data:image/(png|jpeg|gif|webp);base64,`)
+
+// safeURL allows relative URLs and http(s) or mailto links, images may also be inline data
+func safeURL(element atom.Atom, value string) bool {
+	value = strings.TrimSpace(value)
+
+	if element == atom.Img && dataImagePattern.MatchString(value) {
+		return true
+	}
+
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return false
+	}
+
+	switch strings.ToLower(parsed.Scheme) {
+	case "":
+		return true
+	case "http", "https":
+		return true
+	case "mailto":
+		return element == atom.A
+	}
+
+	return false
+}
+
+// SanitizeResult is the cleaned HTML and a description of everything that was removed
+type SanitizeResult struct {
+	HTML     string
+	Stripped []string
+}
+
+// Sanitize applies the note allowlist to untrusted HTML
+// Disallowed elements are unwrapped keeping their text, while script-like elements are
+// dropped with their content. The result is always the re-rendered tree, never the input,
+// so markup the parser and a browser could read differently does not pass through
+func Sanitize(htmlContent string) (SanitizeResult, error) {
+	nodes, err := parseFragment(htmlContent)
+	if err != nil {
+		return SanitizeResult{}, err
+	}
+
+	var stripped []string
+
+	root := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	for _, n := range nodes {
+		root.AppendChild(n)
+	}
+
+	sanitizeChildren(root, &stripped)
+
+	var sb strings.Builder
+
+	for c := root.FirstChild; c != nil; c = c.NextSibling {
+		if err := html.Render(&sb, c); err != nil {
+			return SanitizeResult{}, err
+		}
+	}
+
+	return SanitizeResult{HTML: sb.String(), Stripped: stripped}, nil
+}
+
+func sanitizeChildren(parent *html.Node, stripped *[]string) {
+	for c := parent.FirstChild; c != nil; {
+		next := c.NextSibling
+
+		switch c.Type {
+		case html.TextNode:
+		case html.ElementNode:
+			next = sanitizeElement(parent, c, stripped)
+		case html.CommentNode:
+			parent.RemoveChild(c)
+			*stripped = append(*stripped, "comment")
+		default:
+			parent.RemoveChild(c)
+			*stripped = append(*stripped, "unsupported node")
+		}
+
+		c = next
+	}
+}
+
+// sanitizeElement cleans n in place and returns the next node to visit
+func sanitizeElement(parent *html.Node, n *html.Node, stripped *[]string) *html.Node {
+	next := n.NextSibling
+
+	if droppedElements[n.DataAtom] {
+		parent.RemoveChild(n)
+		*stripped = append(*stripped, fmt.Sprintf("<%s> element", n.Data))
+		return next
+	}
+
+	allowedAttrs, ok := allowedElements[n.DataAtom]
+
+	if !ok || n.DataAtom == 0 {
+		// Unwrap unknown elements, their children are visited next
+		first := n.FirstChild
+
+		for c := n.FirstChild; c != nil; {
+			following := c.NextSibling
+			n.RemoveChild(c)
+			parent.InsertBefore(c, n)
+			c = following
+		}
+
+		parent.RemoveChild(n)
+		*stripped = append(*stripped, fmt.Sprintf("<%s> tag", n.Data))
+
+		if first != nil {
+			return first
+		}
+		return next
+	}
+
+	if n.DataAtom == atom.Input && !strings.EqualFold(attr(n, "type"), "checkbox") {
+		parent.RemoveChild(n)
+		*stripped = append(*stripped, "<input> element")
+		return next
+	}
+
+	attrs := n.Attr[:0]
+
+	for _, a := range n.Attr {
+		key := strings.ToLower(a.Key)
+
+		if a.Namespace != "" || (!globalAttributes[key] && !allowedAttrs[key]) {
+			*stripped = append(*stripped, fmt.Sprintf("%s attribute on <%s>", a.Key, n.Data))
+			continue
+		}
+
+		if (key == "href" || key == "src") && !safeURL(n.DataAtom, a.Val) {
+			*stripped = append(*stripped, fmt.Sprintf("unsafe %s on <%s>", a.Key, n.Data))
+			continue
+		}
+
+		attrs = append(attrs, a)
+	}
+
+	n.Attr = attrs
+
+	// A link opening another window could otherwise navigate the app through window.opener
+	if n.DataAtom == atom.A && hasAttr(n, "target") {
+		n.Attr = slices.DeleteFunc(n.Attr, func(a html.Attribute) bool { return strings.EqualFold(a.Key, "rel") })
+		n.Attr = append(n.Attr, html.Attribute{Key: "rel", Val: "noopener noreferrer"})
+	}
+
+	sanitizeChildren(n, stripped)
+
+	return next
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	return slices.ContainsFunc(n.Attr, func(a html.Attribute) bool { return strings.EqualFold(a.Key, key) })
+}
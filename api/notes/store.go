@@ -2,59 +2,492 @@ package notes
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/maybemaby/workpad/api/pagination"
+	"github.com/maybemaby/workpad/api/projects"
+	"github.com/maybemaby/workpad/api/workspaces"
 )
 
 type NoteStore interface {
 	GetNoteByDate(ctx context.Context, date time.Time) (Note, error)
 	CreateNote(ctx context.Context, htmlContent string, date time.Time) (Note, error)
 	GetNoteDatesForMonth(ctx context.Context, year int, month time.Month) ([]int, error)
-	UpdateExcerptsForDate(ctx context.Context, date time.Time, excerpts []ExcerptNode) error
-	GetExcerptsForProject(ctx context.Context, projectName string) ([]NoteExcerpt, error)
+	UpdateExcerptsForDate(ctx context.Context, date time.Time, excerpts []ExcerptNode, opts SaveExcerptsOptions) (SavedExcerpts, error)
+	SaveNote(ctx context.Context, date time.Time, htmlContent string, excerpts []ExcerptNode, opts SaveExcerptsOptions) (Note, SavedExcerpts, error)
+	// GetExcerptsForProject returns a page of excerpts and the cursor of the next page, empty on the last page
+	GetExcerptsForProject(ctx context.Context, projectName string, opts ExcerptOptions) ([]NoteExcerpt, string, error)
+	GetTags(ctx context.Context) ([]TagCount, error)
+	GetExcerptsForTag(ctx context.Context, tag string) ([]NoteExcerpt, error)
+	GetBacklinks(ctx context.Context, date time.Time) ([]Backlink, error)
+	GetNotes(ctx context.Context, projectName string) ([]Note, error)
+	GetRecentNotes(ctx context.Context, limit int) ([]Note, error)
+	AppendToNote(ctx context.Context, date time.Time, text string) (Note, error)
 }
 
+// ErrEmptyText is returned when appended text contains nothing to add
+var ErrEmptyText = errors.New("nothing to append")
+
+// resolveProjectSQL maps a project alias to its canonical name, leaving other names unchanged
+// Its args are the workspace, then the name twice
+const resolveProjectSQL = `COALESCE((SELECT project_name FROM project_aliases WHERE workspace_id = ? AND alias = ?), ?)`
+
+// selectProjectSQL finds a project by name or alias, its args are the workspace twice and the name twice
+const selectProjectSQL = `SELECT name FROM projects WHERE workspace_id = ? AND name = ` + resolveProjectSQL
+
+// insertExcerptSQL stores an excerpt, context blocks the excerpt does not have are stored as NULL
+const insertExcerptSQL = `INSERT INTO project_excerpts (workspace_id, project_name, note_id, excerpt, note_date, context_heading, context_parent, context_before, context_after)
+	VALUES (?, ?, ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''))`
+
+// selectExcerptContext reads the context columns of project_excerpts e into NoteExcerpt.Context
+const selectExcerptContext = `COALESCE(e.context_heading, '') AS "context.heading", COALESCE(e.context_parent, '') AS "context.parent",
+	COALESCE(e.context_before, '') AS "context.before", COALESCE(e.context_after, '') AS "context.after"`
+
+// Events is told about note changes inside the transaction making them, so whatever it records
+// commits or rolls back with the change. An error fails the change
+// Saves that leave a note or its excerpts as they were are not reported, as autosaves repeat them
+type Events interface {
+	NoteSaved(ctx context.Context, tx *sqlx.Tx, note Note) error
+	// ExcerptsSaved is called once the excerpts of the note for date were replaced with different ones
+	ExcerptsSaved(ctx context.Context, tx *sqlx.Tx, date time.Time, saved SavedExcerpts) error
+}
+
+// noEvents is the Events of a service nothing listens to
+type noEvents struct{}
+
+func (noEvents) NoteSaved(context.Context, *sqlx.Tx, Note) error                         { return nil }
+func (noEvents) ExcerptsSaved(context.Context, *sqlx.Tx, time.Time, SavedExcerpts) error { return nil }
+
 type NoteService struct {
-	db *sqlx.DB
+	db     *sqlx.DB
+	events Events
 }
 
 func NewNoteService(db *sqlx.DB) *NoteService {
-	return &NoteService{db: db}
+	return &NoteService{db: db, events: noEvents{}}
+}
+
+// WithEvents reports note changes to events from within their transactions
+func (s *NoteService) WithEvents(events Events) *NoteService {
+	s.events = events
+	return s
 }
 
 func (s *NoteService) GetNoteByDate(ctx context.Context, date time.Time) (Note, error) {
 	var note Note
 
-	err := s.db.GetContext(ctx, &note, "SELECT id, html_content, note_date FROM notes WHERE date(note_date) = ?", date.Format("2006-01-02"))
+	ws, err := workspaces.ID(ctx)
+
+	if err != nil {
+		return Note{}, err
+	}
+
+	err = s.db.GetContext(ctx, &note, "SELECT id, html_content, note_date, updated_at FROM notes WHERE workspace_id = ? AND date(note_date) = ?", ws, date.Format("2006-01-02"))
 
 	return note, err
 }
 
+// CreateNote sanitizes and stores the note for date, replacing any existing content
+// Anything the sanitizer removed is listed in the returned note's Stripped field
 func (s *NoteService) CreateNote(ctx context.Context, htmlContent string, date time.Time) (Note, error) {
-	var id int
+	tx, err := s.db.BeginTxx(ctx, nil)
 
-	err := s.db.QueryRowContext(ctx, `INSERT INTO notes (html_content, note_date) VALUES (?, ?) ON CONFLICT (note_date) DO UPDATE SET html_content = excluded.html_content RETURNING id`, htmlContent, date.Format("2006-01-02")).Scan(&id)
+	if err != nil {
+		return Note{}, err
+	}
+
+	defer tx.Rollback()
+
+	note, changed, err := writeNote(ctx, tx, htmlContent, date)
 
 	if err != nil {
 		return Note{}, err
 	}
 
+	if changed {
+		if err := s.events.NoteSaved(ctx, tx, note); err != nil {
+			return Note{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Note{}, err
+	}
+
+	return note, nil
+}
+
+// SaveNote stores the note for date like CreateNote and replaces its excerpts like
+// UpdateExcerptsForDate in one transaction, so the excerpts always match the saved content
+func (s *NoteService) SaveNote(ctx context.Context, date time.Time, htmlContent string, excerpts []ExcerptNode, opts SaveExcerptsOptions) (Note, SavedExcerpts, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+
+	if err != nil {
+		return Note{}, SavedExcerpts{}, err
+	}
+
+	defer tx.Rollback()
+
+	note, changed, err := writeNote(ctx, tx, htmlContent, date)
+
+	if err != nil {
+		return Note{}, SavedExcerpts{}, err
+	}
+
+	if changed {
+		if err := s.events.NoteSaved(ctx, tx, note); err != nil {
+			return Note{}, SavedExcerpts{}, err
+		}
+	}
+
+	saved, err := replaceExcerpts(ctx, tx, note, excerpts, opts)
+
+	if err != nil {
+		return Note{}, SavedExcerpts{}, err
+	}
+
+	if saved.Changed {
+		if err := s.events.ExcerptsSaved(ctx, tx, date, saved); err != nil {
+			return Note{}, SavedExcerpts{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Note{}, SavedExcerpts{}, err
+	}
+
+	return note, saved, nil
+}
+
+// writeNote sanitizes, stores and indexes the note for date, and reports whether its content changed
+func writeNote(ctx context.Context, tx *sqlx.Tx, htmlContent string, date time.Time) (Note, bool, error) {
+	var id int
+	var updatedAt time.Time
+	var previous sql.NullString
+
+	sanitized, err := Sanitize(htmlContent)
+
+	if err != nil {
+		return Note{}, false, err
+	}
+
+	htmlContent = sanitized.HTML
+
+	blocks, err := Blocks(htmlContent)
+
+	if err != nil {
+		return Note{}, false, err
+	}
+
+	ws, err := workspaces.ID(ctx)
+
+	if err != nil {
+		return Note{}, false, err
+	}
+
+	err = tx.GetContext(ctx, &previous, `SELECT html_content FROM notes WHERE workspace_id = ? AND date(note_date) = ?`, ws, date.Format(time.DateOnly))
+
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return Note{}, false, err
+	}
+
+	// Autosaves of unchanged content keep updated_at so feeds do not report an edit
+	err = tx.QueryRowContext(ctx, `INSERT INTO notes (workspace_id, html_content, note_date, updated_at) VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT (workspace_id, note_date) DO UPDATE SET
+			updated_at = CASE WHEN notes.html_content = excluded.html_content THEN notes.updated_at ELSE excluded.updated_at END,
+			html_content = excluded.html_content
+		RETURNING id, updated_at`, ws, htmlContent, date.Format("2006-01-02")).Scan(&id, &updatedAt)
+
+	if err != nil {
+		return Note{}, false, err
+	}
+
+	if err := indexNote(ctx, tx, id, date, htmlContent, blocks); err != nil {
+		return Note{}, false, err
+	}
+
 	return Note{
 		Id:          id,
 		HTMLContent: htmlContent,
 		Date:        date,
-	}, nil
+		UpdatedAt:   updatedAt,
+		Stripped:    sanitized.Stripped,
+	}, !previous.Valid || previous.String != htmlContent, nil
+}
+
+// IndexVersion is the version of indexNote and Sanitize, bump it when either changes so notes
+// stored by an older version are sanitized and reindexed by Reindex
+const IndexVersion = 2
+
+// indexNote rebuilds the tags, links, attachment references and detected reminders of a saved note
+func indexNote(ctx context.Context, tx *sqlx.Tx, id int, date time.Time, htmlContent string, blocks []Block) error {
+	if err := replaceTags(ctx, tx, id, date, blocks); err != nil {
+		return err
+	}
+
+	if err := replaceLinks(ctx, tx, id, date, blocks); err != nil {
+		return err
+	}
+
+	if err := replaceAttachmentRefs(ctx, tx, id, htmlContent); err != nil {
+		return err
+	}
+
+	if err := syncDetectedReminders(ctx, tx, id, blocks); err != nil {
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, `UPDATE notes SET index_version = ? WHERE id = ?`, IndexVersion, id)
+
+	return err
+}
+
+// Reindex sanitizes and rebuilds the indexes of notes in every workspace that were stored by an
+// older IndexVersion, batchSize notes per transaction, and returns how many notes it reindexed
+// Notes keep their updated_at, as their text is not edited
+func (s *NoteService) Reindex(ctx context.Context, batchSize int) (int, error) {
+	reindexed := 0
+
+	for {
+		count, err := s.reindexBatch(ctx, batchSize)
+		reindexed += count
+
+		if err != nil || count < batchSize {
+			return reindexed, err
+		}
+	}
+}
+
+func (s *NoteService) reindexBatch(ctx context.Context, limit int) (int, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback()
+
+	var stale []struct {
+		Note
+		WorkspaceId int64 `db:"workspace_id"`
+	}
+
+	err = tx.SelectContext(ctx, &stale, `SELECT id, workspace_id, html_content, note_date, updated_at FROM notes WHERE index_version < ? ORDER BY id LIMIT ?`, IndexVersion, limit)
+
+	if err != nil {
+		return 0, err
+	}
+
+	for _, note := range stale {
+		sanitized, err := Sanitize(note.HTMLContent)
+
+		if err != nil {
+			return 0, fmt.Errorf("failed to sanitize note %d: %w", note.Id, err)
+		}
+
+		if sanitized.HTML != note.HTMLContent {
+			if _, err := tx.ExecContext(ctx, `UPDATE notes SET html_content = ? WHERE id = ?`, sanitized.HTML, note.Id); err != nil {
+				return 0, err
+			}
+		}
+
+		blocks, err := Blocks(sanitized.HTML)
+
+		if err != nil {
+			return 0, fmt.Errorf("failed to split note %d: %w", note.Id, err)
+		}
+
+		// Runs outside any request, so index in the note's own workspace
+		noteCtx := workspaces.WithID(ctx, note.WorkspaceId)
+
+		if err := indexNote(noteCtx, tx, note.Id, note.Date, sanitized.HTML, blocks); err != nil {
+			return 0, fmt.Errorf("failed to index note %d: %w", note.Id, err)
+		}
+	}
+
+	return len(stale), tx.Commit()
+}
+
+// AppendToNote adds quick-capture text to the end of the note for date, creating the note
+// when there is none. The text is converted with CaptureBlocks, and excerpts are added for
+// the new blocks that mention projects while existing excerpts are kept
+func (s *NoteService) AppendToNote(ctx context.Context, date time.Time, text string) (Note, error) {
+	day := date.Format(time.DateOnly)
+	ws, err := workspaces.ID(ctx)
+
+	if err != nil {
+		return Note{}, err
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+
+	if err != nil {
+		return Note{}, err
+	}
+
+	defer tx.Rollback()
+
+	// Writing before reading takes the write lock, so concurrent appends cannot lose each other's text
+	_, err = tx.ExecContext(ctx, `INSERT INTO notes (workspace_id, html_content, note_date, updated_at) VALUES (?, '', ?, CURRENT_TIMESTAMP)
+		ON CONFLICT (workspace_id, note_date) DO NOTHING`, ws, day)
+
+	if err != nil {
+		return Note{}, err
+	}
+
+	var existing Note
+
+	if err := tx.GetContext(ctx, &existing, `SELECT id, html_content FROM notes WHERE workspace_id = ? AND date(note_date) = ?`, ws, day); err != nil {
+		return Note{}, err
+	}
+
+	resolveStmt, err := tx.PreparexContext(ctx, selectProjectSQL)
+
+	if err != nil {
+		return Note{}, err
+	}
+
+	defer resolveStmt.Close()
+
+	captured, err := CaptureBlocks(text, func(name string) (string, error) {
+		var canonical string
+
+		err := resolveStmt.QueryRowxContext(ctx, ws, ws, name, name).Scan(&canonical)
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+
+		return canonical, err
+	})
+
+	if err != nil {
+		return Note{}, err
+	}
+
+	if len(captured) == 0 {
+		return Note{}, ErrEmptyText
+	}
+
+	var addition strings.Builder
+
+	for _, block := range captured {
+		addition.WriteString(RenderPMNode(block))
+	}
+
+	htmlContent, err := appendHTML(existing.HTMLContent, addition.String())
+
+	if err != nil {
+		return Note{}, err
+	}
+
+	sanitized, err := Sanitize(htmlContent)
+
+	if err != nil {
+		return Note{}, err
+	}
+
+	htmlContent = sanitized.HTML
+
+	blocks, err := Blocks(htmlContent)
+
+	if err != nil {
+		return Note{}, err
+	}
+
+	var updatedAt time.Time
+
+	err = tx.QueryRowContext(ctx, `UPDATE notes SET html_content = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING updated_at`,
+		htmlContent, existing.Id).Scan(&updatedAt)
+
+	if err != nil {
+		return Note{}, err
+	}
+
+	if err := indexNote(ctx, tx, existing.Id, date, htmlContent, blocks); err != nil {
+		return Note{}, err
+	}
+
+	excerpts, err := captureExcerpts(captured)
+
+	if err != nil {
+		return Note{}, err
+	}
+
+	locator, err := newExcerptLocator(htmlContent)
+
+	if err != nil {
+		return Note{}, err
+	}
+
+	// The appended blocks end the note, so they are located from the end in case earlier
+	// blocks have the same text
+	locator.fromEnd = true
+	contexts := make([]ExcerptContext, len(excerpts))
+
+	for i := len(excerpts) - 1; i >= 0; i-- {
+		contexts[i], err = locator.Context(excerpts[i].Node)
+
+		if err != nil {
+			return Note{}, err
+		}
+	}
+
+	for i, excerpt := range excerpts {
+		c := contexts[i]
+
+		for _, projectName := range slices.Compact(slices.Sorted(slices.Values(excerpt.Projects))) {
+			_, err := tx.ExecContext(ctx, insertExcerptSQL,
+				ws, projectName, existing.Id, excerpt.Node, day, c.Heading, c.Parent, c.Before, c.After)
+
+			if err != nil {
+				return Note{}, err
+			}
+		}
+	}
+
+	note := Note{
+		Id:          existing.Id,
+		HTMLContent: htmlContent,
+		Date:        date,
+		UpdatedAt:   updatedAt,
+		Stripped:    sanitized.Stripped,
+	}
+
+	if err := s.events.NoteSaved(ctx, tx, note); err != nil {
+		return Note{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Note{}, err
+	}
+
+	return note, nil
 }
 
 // TODO: Should probably just be a date range
 func (s *NoteService) GetNoteDatesForMonth(ctx context.Context, year int, month time.Month) ([]int, error) {
 	var days []int
 
-	startDate := time.Date(year, month, 1, 0, 0, 0, 0, time.Local)
+	startDate := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
 	endDate := startDate.AddDate(0, 1, -1)
 
-	rows, err := s.db.QueryxContext(ctx, `SELECT strftime('%d', note_date) FROM notes WHERE note_date >= ? AND note_date <= ?`, startDate.Format(time.DateOnly), endDate.Format(time.DateOnly))
+	ws, err := workspaces.ID(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryxContext(ctx, `SELECT strftime('%d', note_date) FROM notes WHERE workspace_id = ? AND note_date >= ? AND note_date <= ?`,
+		ws, startDate.Format(time.DateOnly), endDate.Format(time.DateOnly))
 
 	if err != nil {
 		return nil, err
@@ -73,49 +506,449 @@ func (s *NoteService) GetNoteDatesForMonth(ctx context.Context, year int, month
 	return days, nil
 }
 
-func (s *NoteService) UpdateExcerptsForDate(ctx context.Context, date time.Time, excerpts []ExcerptNode) error {
-
+// UpdateExcerptsForDate replaces the excerpts of the note for date
+// Excerpts mentioning unknown projects fail the whole update with ProjectErrors, unless
+// opts.CreateProjects is set and the projects are created instead
+func (s *NoteService) UpdateExcerptsForDate(ctx context.Context, date time.Time, excerpts []ExcerptNode, opts SaveExcerptsOptions) (SavedExcerpts, error) {
 	note, err := s.GetNoteByDate(ctx, date)
 
 	if err != nil {
-		return err
+		return SavedExcerpts{}, err
 	}
 
 	tx, err := s.db.BeginTxx(ctx, nil)
 
 	if err != nil {
-		return err
+		return SavedExcerpts{}, err
 	}
 
 	defer tx.Rollback()
 
-	_, err = tx.ExecContext(ctx, `DELETE FROM project_excerpts WHERE note_date = ?`, date.Format(time.DateOnly))
+	saved, err := replaceExcerpts(ctx, tx, note, excerpts, opts)
 
 	if err != nil {
-		return err
+		return SavedExcerpts{}, err
+	}
+
+	if saved.Changed {
+		if err := s.events.ExcerptsSaved(ctx, tx, date, saved); err != nil {
+			return SavedExcerpts{}, err
+		}
 	}
 
-	insertStmt, err := tx.PrepareContext(ctx, `INSERT INTO project_excerpts (project_name, note_id, excerpt, note_date) VALUES (?, ?, ?, ?)`)
+	if err := tx.Commit(); err != nil {
+		return SavedExcerpts{}, err
+	}
+
+	return saved, nil
+}
+
+// storedExcerpt is what an excerpt rebuild stores for each excerpt, compared to tell whether it changed anything
+type storedExcerpt struct {
+	ProjectName string         `db:"project_name"`
+	Excerpt     string         `db:"excerpt"`
+	Context     ExcerptContext `db:"context"`
+}
+
+// replaceExcerpts rebuilds the excerpts of note, every unknown project is reported before
+// returning so one save lists them all
+func replaceExcerpts(ctx context.Context, tx *sqlx.Tx, note Note, excerpts []ExcerptNode, opts SaveExcerptsOptions) (SavedExcerpts, error) {
+	day := note.Date.Format(time.DateOnly)
+	ws, err := workspaces.ID(ctx)
 
 	if err != nil {
-		return err
+		return SavedExcerpts{}, err
+	}
+
+	var previous, current []storedExcerpt
+
+	err = tx.SelectContext(ctx, &previous, `SELECT e.project_name, e.excerpt, `+selectExcerptContext+` FROM project_excerpts e WHERE e.note_id = ? ORDER BY e.id`, note.Id)
+
+	if err != nil {
+		return SavedExcerpts{}, err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM project_excerpts WHERE note_id = ?`, note.Id)
+
+	if err != nil {
+		return SavedExcerpts{}, err
+	}
+
+	insertStmt, err := tx.PrepareContext(ctx, insertExcerptSQL)
+
+	if err != nil {
+		return SavedExcerpts{}, err
 	}
 
 	defer insertStmt.Close()
 
+	resolveStmt, err := tx.PreparexContext(ctx, selectProjectSQL)
+
+	if err != nil {
+		return SavedExcerpts{}, err
+	}
+
+	defer resolveStmt.Close()
+
+	locator, err := newExcerptLocator(note.HTMLContent)
+
+	if err != nil {
+		return SavedExcerpts{}, err
+	}
+
+	var saved SavedExcerpts
+	var projectErrs ProjectErrors
+
+	// canonical maps each mentioned name to its project, "" for names that cannot be saved
+	canonical := make(map[string]string)
+
+	resolve := func(name string) (string, error) {
+		if resolved, ok := canonical[name]; ok {
+			return resolved, nil
+		}
+
+		var resolved string
+
+		err := resolveStmt.QueryRowxContext(ctx, ws, ws, name, name).Scan(&resolved)
+
+		switch {
+		case err == nil:
+		case !errors.Is(err, sql.ErrNoRows):
+			return "", err
+		case strings.TrimSpace(name) == "":
+			projectErrs = append(projectErrs, ProjectError{Project: name, Message: "project name cannot be empty"})
+		case !opts.CreateProjects:
+			projectErrs = append(projectErrs, ProjectError{Project: name, Message: "project does not exist"})
+		default:
+			// Created like any other project, so " OKT" does not become a second OKT
+			project, created, err := projects.CreateInTx(ctx, tx, name)
+
+			if err != nil {
+				return "", fmt.Errorf("failed to create project %q: %w", name, err)
+			}
+
+			resolved = project.Name
+
+			if created {
+				saved.Created = append(saved.Created, *project)
+			}
+		}
+
+		canonical[name] = resolved
+
+		return resolved, nil
+	}
+
 	for _, excerptNode := range excerpts {
+		c, err := locator.Context(excerptNode.Node)
+
+		if err != nil {
+			return SavedExcerpts{}, err
+		}
+
+		// Several aliases of one project in the same block only produce one excerpt
+		seen := make(map[string]bool, len(excerptNode.Projects))
+
 		for _, projectName := range excerptNode.Projects {
-			insertStmt.ExecContext(ctx, projectName, note.Id, excerptNode.Node, date.Format(time.DateOnly))
+			resolved, err := resolve(projectName)
+
+			if err != nil {
+				return SavedExcerpts{}, err
+			}
+
+			if resolved == "" || seen[resolved] {
+				continue
+			}
+
+			seen[resolved] = true
+
+			_, err = insertStmt.ExecContext(ctx, ws, resolved, note.Id, excerptNode.Node, day, c.Heading, c.Parent, c.Before, c.After)
+
+			if err != nil {
+				return SavedExcerpts{}, fmt.Errorf("failed to save excerpt for project %q: %w", resolved, err)
+			}
+
+			current = append(current, storedExcerpt{ProjectName: resolved, Excerpt: excerptNode.Node, Context: c})
+
+			if !slices.Contains(saved.Projects, resolved) {
+				saved.Projects = append(saved.Projects, resolved)
+			}
 		}
 	}
 
-	return tx.Commit()
+	if len(projectErrs) > 0 {
+		return SavedExcerpts{}, projectErrs
+	}
+
+	saved.Changed = !slices.Equal(previous, current)
+
+	return saved, nil
+}
+
+type excerptRow struct {
+	NoteExcerpt
+	SortDate string `db:"sort_date"`
 }
 
-func (s *NoteService) GetExcerptsForProject(ctx context.Context, projectName string) ([]NoteExcerpt, error) {
-	var excerpts []NoteExcerpt
+func (s *NoteService) GetExcerptsForProject(ctx context.Context, projectName string, opts ExcerptOptions) ([]NoteExcerpt, string, error) {
+	sort := opts.Sort
+	if sort == "" {
+		sort = SortNewest
+	}
+
+	if sort != SortNewest && sort != SortOldest {
+		return nil, "", fmt.Errorf("unknown excerpt sort %q", sort)
+	}
+
+	ws, err := workspaces.ID(ctx)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	// The sort date is text so it survives the round trip through a cursor
+	query := `WITH page AS (
+			SELECT e.id, e.project_name, e.note_id, e.excerpt, e.note_date, CAST(e.note_date AS TEXT) AS sort_date, n.updated_at,
+				` + selectExcerptContext + `
+			FROM project_excerpts e JOIN notes n ON n.id = e.note_id
+			WHERE e.workspace_id = ? AND LOWER(e.project_name) = LOWER(` + resolveProjectSQL + `)
+		)`
+	args := []any{ws, ws, projectName, projectName}
+
+	if opts.IncludeDescendants {
+		// A block mentioning both a parent and its child is returned once
+		query = `WITH RECURSIVE tree(name) AS (
+				SELECT name FROM projects WHERE workspace_id = ? AND LOWER(name) = LOWER(` + resolveProjectSQL + `)
+				UNION
+				SELECT p.name FROM projects p JOIN tree t ON p.parent_name = t.name WHERE p.workspace_id = ?
+			), page AS (
+				SELECT MIN(e.id) AS id, e.project_name, e.note_id, e.excerpt, e.note_date, CAST(e.note_date AS TEXT) AS sort_date, n.updated_at,
+					` + selectExcerptContext + `
+				FROM project_excerpts e JOIN notes n ON n.id = e.note_id
+				WHERE e.workspace_id = ? AND e.project_name IN (SELECT name FROM tree)
+				GROUP BY e.note_id, e.excerpt
+			)`
+		args = []any{ws, ws, projectName, projectName, ws, ws}
+	}
 
-	err := s.db.SelectContext(ctx, &excerpts, `SELECT id, project_name, note_id, excerpt, note_date FROM project_excerpts WHERE LOWER(project_name) = LOWER(?) ORDER BY note_date DESC`, projectName)
+	query += ` SELECT * FROM page`
+
+	order := "DESC"
+	after := "<"
+	if sort == SortOldest {
+		order = "ASC"
+		after = ">"
+	}
+
+	if opts.Cursor != "" {
+		values, err := pagination.DecodeCursor(opts.Cursor, 3)
+		if err != nil || values[0] != sort {
+			return nil, "", pagination.ErrInvalidCursor
+		}
+
+		id, err := strconv.Atoi(values[2])
+		if err != nil {
+			return nil, "", pagination.ErrInvalidCursor
+		}
+
+		query += ` WHERE sort_date ` + after + ` ? OR (sort_date = ? AND id > ?)`
+		args = append(args, values[1], values[1], id)
+	}
+
+	query += ` ORDER BY sort_date ` + order + `, id`
+
+	// One extra row tells whether there is a next page
+	if opts.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, opts.Limit+1)
+	}
+
+	var rows []excerptRow
+	if err := s.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, "", err
+	}
+
+	next := ""
+	if opts.Limit > 0 && len(rows) > opts.Limit {
+		rows = rows[:opts.Limit]
+		last := rows[len(rows)-1]
+		next = pagination.EncodeCursor(sort, last.SortDate, strconv.Itoa(last.Id))
+	}
+
+	excerpts := make([]NoteExcerpt, len(rows))
+	for i, row := range rows {
+		excerpts[i] = row.NoteExcerpt
+	}
+
+	return excerpts, next, nil
+}
+
+// GetNotes lists notes by date, limited to notes with excerpts for projectName when it is set
+func (s *NoteService) GetNotes(ctx context.Context, projectName string) ([]Note, error) {
+	notes := []Note{}
+
+	ws, err := workspaces.ID(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if projectName == "" {
+		err = s.db.SelectContext(ctx, &notes, `SELECT id, html_content, note_date, updated_at FROM notes WHERE workspace_id = ? ORDER BY note_date`, ws)
+
+		return notes, err
+	}
+
+	err = s.db.SelectContext(ctx, &notes, `SELECT id, html_content, note_date, updated_at FROM notes
+		WHERE workspace_id = ? AND id IN (SELECT note_id FROM project_excerpts WHERE LOWER(project_name) = LOWER(`+resolveProjectSQL+`))
+		ORDER BY note_date`, ws, ws, projectName, projectName)
+
+	return notes, err
+}
+
+// GetRecentNotes lists the latest limit notes, newest first
+func (s *NoteService) GetRecentNotes(ctx context.Context, limit int) ([]Note, error) {
+	notes := []Note{}
+
+	ws, err := workspaces.ID(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.SelectContext(ctx, &notes, `SELECT id, html_content, note_date, updated_at FROM notes WHERE workspace_id = ? ORDER BY note_date DESC LIMIT ?`, ws, limit)
+
+	return notes, err
+}
+
+// replaceTags rebuilds the tag index of a note from its blocks
+// Blocks are stored as HTML and as the editor's ProseMirror JSON, which tag excerpts are served as
+func replaceTags(ctx context.Context, tx *sqlx.Tx, noteId int, date time.Time, blocks []Block) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM note_tags WHERE note_id = ?`, noteId)
+
+	if err != nil {
+		return err
+	}
+
+	tags := ExtractTags(blocks)
+
+	if len(tags) == 0 {
+		return nil
+	}
+
+	insertStmt, err := tx.PrepareContext(ctx, `INSERT INTO note_tags (note_id, note_date, tag, block_index, block_html, block_node) VALUES (?, ?, ?, ?, ?, ?)`)
+
+	if err != nil {
+		return err
+	}
+
+	defer insertStmt.Close()
+
+	for _, tag := range tags {
+		node, err := json.Marshal(BlockNode(tag.Block.Node))
+
+		if err != nil {
+			return fmt.Errorf("failed to encode tagged block: %w", err)
+		}
+
+		_, err = insertStmt.ExecContext(ctx, noteId, date.Format(time.DateOnly), tag.Tag, tag.Block.Index, tag.Block.HTML, string(node))
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *NoteService) GetTags(ctx context.Context) ([]TagCount, error) {
+	tags := []TagCount{}
+
+	ws, err := workspaces.ID(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.SelectContext(ctx, &tags, `SELECT tag, COUNT(*) AS count, COUNT(DISTINCT note_id) AS note_count, date(MAX(note_date)) AS last_used
+		FROM note_tags
+		WHERE note_id IN (SELECT id FROM notes WHERE workspace_id = ?)
+		GROUP BY tag
+		ORDER BY count DESC, tag`, ws)
+
+	return tags, err
+}
+
+// GetExcerptsForTag returns the blocks carrying a tag in the excerpt shape used for projects, newest note first
+// ProjectName holds the tag and Excerpt the block's ProseMirror JSON, or its HTML for blocks indexed before
+// the JSON was stored
+func (s *NoteService) GetExcerptsForTag(ctx context.Context, tag string) ([]NoteExcerpt, error) {
+	excerpts := []NoteExcerpt{}
+
+	ws, err := workspaces.ID(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.SelectContext(ctx, &excerpts, `SELECT t.id, t.tag AS project_name, t.note_id,
+			CASE WHEN t.block_node = '' THEN t.block_html ELSE t.block_node END AS excerpt,
+			date(t.note_date) AS note_date, n.updated_at
+		FROM note_tags t JOIN notes n ON n.id = t.note_id
+		WHERE n.workspace_id = ? AND t.tag = ? ORDER BY t.note_date DESC, t.block_index`, ws, NormalizeTag(tag))
 
 	return excerpts, err
 }
+
+// replaceLinks rebuilds the outgoing links of a note from its blocks
+// Links point at a date rather than a note id, so they stay valid when the target is rewritten
+func replaceLinks(ctx context.Context, tx *sqlx.Tx, noteId int, date time.Time, blocks []Block) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM note_links WHERE source_note_id = ?`, noteId)
+
+	if err != nil {
+		return err
+	}
+
+	sourceDate := date.Format(time.DateOnly)
+	links := ExtractLinks(blocks, sourceDate)
+
+	if len(links) == 0 {
+		return nil
+	}
+
+	insertStmt, err := tx.PrepareContext(ctx, `INSERT INTO note_links (source_note_id, source_date, target_date, block_index, snippet) VALUES (?, ?, ?, ?, ?)`)
+
+	if err != nil {
+		return err
+	}
+
+	defer insertStmt.Close()
+
+	for _, link := range links {
+		_, err := insertStmt.ExecContext(ctx, noteId, sourceDate, link.TargetDate, link.Block.Index, Snippet(link.Block.Text))
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetBacklinks lists the blocks of other days that reference date, newest first
+func (s *NoteService) GetBacklinks(ctx context.Context, date time.Time) ([]Backlink, error) {
+	backlinks := []Backlink{}
+
+	ws, err := workspaces.ID(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.SelectContext(ctx, &backlinks, `SELECT source_note_id, date(source_date) AS source_date, block_index, snippet
+		FROM note_links
+		WHERE target_date = ? AND source_note_id IN (SELECT id FROM notes WHERE workspace_id = ?)
+		ORDER BY source_date DESC, block_index`, date.Format(time.DateOnly), ws)
+
+	return backlinks, err
+}
@@ -3,12 +3,15 @@ package notes
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"testing"
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/maybemaby/workpad/api/pagination"
 	"github.com/maybemaby/workpad/api/projects"
 	"github.com/maybemaby/workpad/api/utils"
+	"github.com/maybemaby/workpad/api/workspaces"
 	"github.com/stretchr/testify/suite"
 	_ "modernc.org/sqlite"
 )
@@ -95,7 +98,7 @@ func (s *NoteStoreSuite) TearDownTest() {
 func (s *NoteStoreSuite) TestGetNoteByDate_Found() {
 	store := NewNoteService(s.dbx)
 
-	note, err := store.GetNoteByDate(s.T().Context(), mustParseTime(time.DateOnly, "2026-01-02"))
+	note, err := store.GetNoteByDate(workspaces.WithID(s.T().Context(), workspaces.DefaultID), mustParseTime(time.DateOnly, "2026-01-02"))
 
 	s.NoError(err)
 	s.Equal("<p>Note for 2026-01-02</p>", note.HTMLContent)
@@ -105,7 +108,7 @@ func (s *NoteStoreSuite) TestGetNoteByDate_Found() {
 func (s *NoteStoreSuite) TestGetNoteByDate_NotFound() {
 	store := NewNoteService(s.dbx)
 
-	_, err := store.GetNoteByDate(s.T().Context(), mustParseTime(time.DateOnly, "2026-12-31"))
+	_, err := store.GetNoteByDate(workspaces.WithID(s.T().Context(), workspaces.DefaultID), mustParseTime(time.DateOnly, "2026-12-31"))
 
 	s.ErrorIs(err, sql.ErrNoRows)
 }
@@ -113,7 +116,7 @@ func (s *NoteStoreSuite) TestGetNoteByDate_NotFound() {
 func (s *NoteStoreSuite) TestCreateNote_NewNote() {
 	store := NewNoteService(s.dbx)
 
-	note, err := store.CreateNote(s.T().Context(), "<p>New Note</p>", mustParseTime(time.DateOnly, "2026-04-01"))
+	note, err := store.CreateNote(workspaces.WithID(s.T().Context(), workspaces.DefaultID), "<p>New Note</p>", mustParseTime(time.DateOnly, "2026-04-01"))
 
 	s.NoError(err)
 	s.Equal("<p>New Note</p>", note.HTMLContent)
@@ -123,7 +126,7 @@ func (s *NoteStoreSuite) TestCreateNote_NewNote() {
 func (s *NoteStoreSuite) TestCreateNote_UpdateExistingNote() {
 	store := NewNoteService(s.dbx)
 
-	note, err := store.CreateNote(s.T().Context(), "<p>Updated Note for 2026-01-02</p>", mustParseTime(time.DateOnly, "2026-01-02"))
+	note, err := store.CreateNote(workspaces.WithID(s.T().Context(), workspaces.DefaultID), "<p>Updated Note for 2026-01-02</p>", mustParseTime(time.DateOnly, "2026-01-02"))
 
 	s.NoError(err)
 	s.Equal("<p>Updated Note for 2026-01-02</p>", note.HTMLContent)
@@ -133,7 +136,7 @@ func (s *NoteStoreSuite) TestCreateNote_UpdateExistingNote() {
 func (s *NoteStoreSuite) TestGetNoteDatesForMonth() {
 	store := NewNoteService(s.dbx)
 
-	days, err := store.GetNoteDatesForMonth(s.T().Context(), 2026, time.January)
+	days, err := store.GetNoteDatesForMonth(workspaces.WithID(s.T().Context(), workspaces.DefaultID), 2026, time.January)
 
 	s.NoError(err)
 	s.ElementsMatch([]int{1, 2, 3}, days)
@@ -141,7 +144,7 @@ func (s *NoteStoreSuite) TestGetNoteDatesForMonth() {
 
 func (s *NoteStoreSuite) TestGetNoteDatesForMonth_NoNotes() {
 	store := NewNoteService(s.dbx)
-	days, err := store.GetNoteDatesForMonth(s.T().Context(), 2026, time.February)
+	days, err := store.GetNoteDatesForMonth(workspaces.WithID(s.T().Context(), workspaces.DefaultID), 2026, time.February)
 
 	s.NoError(err)
 	s.Empty(days)
@@ -155,7 +158,7 @@ func (s *NoteStoreSuite) TestUpdateExcerpts() {
 		{Node: "Excerpt 2", Projects: []string{"Gamma"}},
 	}
 
-	err := store.UpdateExcerptsForDate(s.T().Context(), mustParseTime(time.DateOnly, "2026-01-02"), excerpts)
+	_, err := store.UpdateExcerptsForDate(workspaces.WithID(s.T().Context(), workspaces.DefaultID), mustParseTime(time.DateOnly, "2026-01-02"), excerpts, SaveExcerptsOptions{})
 
 	s.NoError(err)
 }
@@ -167,21 +170,543 @@ func (s *NoteStoreSuite) TestUpdateExcerpts_NoteNotFound() {
 		{Node: "Excerpt 1", Projects: []string{"Project Alpha", "Beta Project"}},
 	}
 
-	err := store.UpdateExcerptsForDate(s.T().Context(), mustParseTime(time.DateOnly, "2026-12-31"), excerpts)
+	_, err := store.UpdateExcerptsForDate(workspaces.WithID(s.T().Context(), workspaces.DefaultID), mustParseTime(time.DateOnly, "2026-12-31"), excerpts, SaveExcerptsOptions{})
 
 	s.ErrorIs(err, sql.ErrNoRows)
 }
 
 func (s *NoteStoreSuite) TestUpdateExcerpts_ProjectNotFound() {
 	store := NewNoteService(s.dbx)
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+	date := mustParseTime(time.DateOnly, "2026-01-02")
+
+	_, err := store.UpdateExcerptsForDate(ctx, date, []ExcerptNode{{Node: "Excerpt 1", Projects: []string{"Gamma"}}}, SaveExcerptsOptions{})
+	s.Require().NoError(err)
+
+	excerpts := []ExcerptNode{
+		{Node: "Excerpt 2", Projects: []string{"Gamma", "Nonexistent Project"}},
+		{Node: "Excerpt 3", Projects: []string{"Nonexistent Project", "Other Project"}},
+	}
+
+	_, err = store.UpdateExcerptsForDate(ctx, date, excerpts, SaveExcerptsOptions{})
+
+	var projectErrs ProjectErrors
+	s.Require().ErrorAs(err, &projectErrs)
+	s.Equal(ProjectErrors{
+		{Project: "Nonexistent Project", Message: "project does not exist"},
+		{Project: "Other Project", Message: "project does not exist"},
+	}, projectErrs)
+
+	// The failed update left the earlier excerpts in place
+	saved, _, err := store.GetExcerptsForProject(ctx, "Gamma", ExcerptOptions{})
+	s.Require().NoError(err)
+	s.Require().Len(saved, 1)
+	s.Equal("Excerpt 1", saved[0].Excerpt)
+}
+
+func (s *NoteStoreSuite) TestUpdateExcerpts_CreatesProjects() {
+	store := NewNoteService(s.dbx)
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+
+	excerpts := []ExcerptNode{
+		{Node: "Excerpt 1", Projects: []string{"Gamma", "New Project"}},
+		{Node: "Excerpt 2", Projects: []string{" New Project"}},
+	}
+
+	saved, err := store.UpdateExcerptsForDate(ctx, mustParseTime(time.DateOnly, "2026-01-02"), excerpts, SaveExcerptsOptions{CreateProjects: true})
+	s.Require().NoError(err)
+	s.Equal([]string{"Gamma", "New Project"}, saved.Projects)
+	s.Require().Len(saved.Created, 1)
+	s.Equal("New Project", saved.Created[0].Name)
+
+	created, _, err := store.GetExcerptsForProject(ctx, "New Project", ExcerptOptions{})
+	s.Require().NoError(err)
+	s.Len(created, 2)
+
+	// Names are trimmed like projects created directly
+	var untrimmed int
+	s.Require().NoError(s.dbx.GetContext(ctx, &untrimmed, "SELECT COUNT(*) FROM projects WHERE name = ' New Project'"))
+	s.Zero(untrimmed)
+}
+
+func (s *NoteStoreSuite) TestSaveNote() {
+	store := NewNoteService(s.dbx)
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+	date := mustParseTime(time.DateOnly, "2026-05-01")
+
+	excerpts := []ExcerptNode{{Node: "<p>Call about Gamma</p>", Projects: []string{"Gamma"}}}
+
+	note, saved, err := store.SaveNote(ctx, date, "<p>Call about Gamma</p><p>Lunch</p>", excerpts, SaveExcerptsOptions{})
+	s.Require().NoError(err)
+	s.Equal("<p>Call about Gamma</p><p>Lunch</p>", note.HTMLContent)
+	s.Equal([]string{"Gamma"}, saved.Projects)
+
+	stored, _, err := store.GetExcerptsForProject(ctx, "Gamma", ExcerptOptions{})
+	s.Require().NoError(err)
+	s.Require().Len(stored, 1)
+	s.Equal("<p>Lunch</p>", stored[0].Context.After)
+
+	// A save rejected for its excerpts leaves the note as it was
+	_, _, err = store.SaveNote(ctx, date, "<p>Rewritten</p>", []ExcerptNode{{Node: "<p>Rewritten</p>", Projects: []string{"Missing"}}}, SaveExcerptsOptions{})
+	s.ErrorAs(err, new(ProjectErrors))
+
+	unchanged, err := store.GetNoteByDate(ctx, date)
+	s.Require().NoError(err)
+	s.Equal(note.HTMLContent, unchanged.HTMLContent)
+}
+
+func (s *NoteStoreSuite) TestUpdateExcerpts_CapturesContext() {
+	store := NewNoteService(s.dbx)
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+	date := mustParseTime(time.DateOnly, "2026-01-02")
+
+	mention := PMNode{Type: "paragraph", Content: []PMNode{
+		{Type: "text", Text: "Call "},
+		{Type: "mention", Attrs: map[string]any{"id": "Gamma", "label": "Gamma"}},
+	}}
+	item := func(n PMNode, children ...PMNode) PMNode {
+		content := []PMNode{n}
+		if len(children) > 0 {
+			content = append(content, PMNode{Type: "bulletList", Content: children})
+		}
+
+		return PMNode{Type: "listItem", Content: content}
+	}
+	paragraph := func(text string) PMNode {
+		return PMNode{Type: "paragraph", Content: []PMNode{{Type: "text", Text: text}}}
+	}
+
+	doc := PMNode{Type: "doc", Content: []PMNode{
+		{Type: "heading", Attrs: map[string]any{"level": 2}, Content: []PMNode{{Type: "text", Text: "Standup"}}},
+		paragraph("Call Gamma"),
+		{Type: "bulletList", Content: []PMNode{
+			item(paragraph("Vendors"), item(mention), item(paragraph("Email legal"))),
+		}},
+		paragraph("Wrap up"),
+	}}
+
+	_, err := store.CreateNote(ctx, RenderPMNode(doc), date)
+	s.Require().NoError(err)
+
+	node, err := json.Marshal(mention)
+	s.Require().NoError(err)
+
+	_, err = store.UpdateExcerptsForDate(ctx, date, []ExcerptNode{{Node: string(node), Projects: []string{"Gamma"}}}, SaveExcerptsOptions{})
+	s.Require().NoError(err)
+
+	excerpts, _, err := store.GetExcerptsForProject(ctx, "Gamma", ExcerptOptions{})
+	s.Require().NoError(err)
+	s.Require().Len(excerpts, 1)
+	s.Equal(string(node), excerpts[0].Excerpt)
+	s.Equal(ExcerptContext{
+		Heading: "<h2>Standup</h2>",
+		Parent:  "<p>Vendors</p>",
+		After:   "<p>Email legal</p>",
+	}, excerpts[0].Context)
+
+	s.Require().NoError(FormatExcerpts(excerpts, FormatText))
+	s.Equal("Call @Gamma", excerpts[0].Excerpt)
+	s.Equal(ExcerptContext{Heading: "Standup", Parent: "Vendors", After: "Email legal"}, excerpts[0].Context)
+}
+
+func (s *NoteStoreSuite) TestGetExcerptsForProject_IncludeDescendants() {
+	store := NewNoteService(s.dbx)
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+
+	s.dbx.MustExecContext(ctx, "UPDATE projects SET parent_name = 'Project Alpha' WHERE name = 'Beta Project'")
+	s.dbx.MustExecContext(ctx, "UPDATE projects SET parent_name = 'Beta Project' WHERE name = 'Gamma'")
 
 	excerpts := []ExcerptNode{
-		{Node: "Excerpt 1", Projects: []string{"Nonexistent Project"}},
+		{Node: "Excerpt 1", Projects: []string{"Project Alpha", "Beta Project"}},
+		{Node: "Excerpt 2", Projects: []string{"Gamma"}},
+		{Node: "Excerpt 3", Projects: []string{"Delta Force"}},
 	}
 
-	err := store.UpdateExcerptsForDate(s.T().Context(), mustParseTime(time.DateOnly, "2026-01-02"), excerpts)
+	_, err := store.UpdateExcerptsForDate(ctx, mustParseTime(time.DateOnly, "2026-01-02"), excerpts, SaveExcerptsOptions{})
+	s.Require().NoError(err)
+
+	direct, _, err := store.GetExcerptsForProject(ctx, "project alpha", ExcerptOptions{})
+	s.NoError(err)
+	s.Len(direct, 1)
+
+	all, _, err := store.GetExcerptsForProject(ctx, "project alpha", ExcerptOptions{IncludeDescendants: true})
+	s.NoError(err)
+	s.Len(all, 2)
+	s.Equal("Excerpt 1", all[0].Excerpt)
+	s.Equal("Excerpt 2", all[1].Excerpt)
+}
+
+func (s *NoteStoreSuite) TestGetExcerptsForProject_Pages() {
+	store := NewNoteService(s.dbx)
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+
+	for _, date := range []string{"2026-01-01", "2026-01-02", "2026-01-03"} {
+		excerpts := []ExcerptNode{
+			{Node: date + " a", Projects: []string{"Gamma"}},
+			{Node: date + " b", Projects: []string{"Gamma"}},
+		}
+
+		_, err := store.UpdateExcerptsForDate(ctx, mustParseTime(time.DateOnly, date), excerpts, SaveExcerptsOptions{})
+		s.Require().NoError(err)
+	}
+
+	var texts []string
+	opts := ExcerptOptions{Limit: 4}
+
+	for {
+		page, next, err := store.GetExcerptsForProject(ctx, "Gamma", opts)
+		s.Require().NoError(err)
+		s.LessOrEqual(len(page), 4)
+
+		for _, excerpt := range page {
+			texts = append(texts, excerpt.Excerpt)
+		}
+
+		if next == "" {
+			break
+		}
+
+		opts.Cursor = next
+	}
+
+	s.Equal([]string{"2026-01-03 a", "2026-01-03 b", "2026-01-02 a", "2026-01-02 b", "2026-01-01 a", "2026-01-01 b"}, texts)
+
+	oldest, next, err := store.GetExcerptsForProject(ctx, "Gamma", ExcerptOptions{Sort: SortOldest, Limit: 3})
+	s.Require().NoError(err)
+	s.Equal("2026-01-01 a", oldest[0].Excerpt)
+	s.Equal("2026-01-02 a", oldest[2].Excerpt)
+
+	// A cursor only continues the sort it was issued for
+	_, _, err = store.GetExcerptsForProject(ctx, "Gamma", ExcerptOptions{Limit: 3, Cursor: next})
+	s.ErrorIs(err, pagination.ErrInvalidCursor)
+}
+
+func (s *NoteStoreSuite) TestUpdateExcerpts_ResolvesAliases() {
+	store := NewNoteService(s.dbx)
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+
+	s.dbx.MustExecContext(ctx, "INSERT INTO project_aliases (alias, project_name) VALUES ('alpha', 'Project Alpha'), ('PA-1', 'Project Alpha')")
+
+	excerpts := []ExcerptNode{
+		{Node: "Excerpt 1", Projects: []string{"ALPHA", "pa-1"}},
+		{Node: "Excerpt 2", Projects: []string{"Gamma"}},
+	}
+
+	_, err := store.UpdateExcerptsForDate(ctx, mustParseTime(time.DateOnly, "2026-01-02"), excerpts, SaveExcerptsOptions{})
+	s.Require().NoError(err)
+
+	found, _, err := store.GetExcerptsForProject(ctx, "Alpha", ExcerptOptions{})
+	s.NoError(err)
+	s.Require().Len(found, 1)
+	s.Equal("Project Alpha", found[0].ProjectName)
+	s.Equal("Excerpt 1", found[0].Excerpt)
+}
+
+func (s *NoteStoreSuite) TestSaveNote_AfterRename() {
+	store := NewNoteService(s.dbx)
+	projectStore := projects.NewSqliteStore(s.dbx)
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+	date := mustParseTime(time.DateOnly, "2026-05-01")
+
+	excerpts := []ExcerptNode{{Node: "<p>Call about Gamma</p>", Projects: []string{"Gamma"}}}
+
+	_, _, err := store.SaveNote(ctx, date, "<p>Call about Gamma</p>", excerpts, SaveExcerptsOptions{})
+	s.Require().NoError(err)
+
+	_, err = projectStore.Rename(ctx, "Gamma", "Omega")
+	s.Require().NoError(err)
+
+	// The old note still mentions Gamma, saving it again must not bring the project back
+	_, saved, err := store.SaveNote(ctx, date, "<p>Call about Gamma again</p>", excerpts, SaveExcerptsOptions{CreateProjects: true})
+	s.Require().NoError(err)
+	s.Equal([]string{"Omega"}, saved.Projects)
+	s.Empty(saved.Created)
+
+	project, err := projectStore.GetByName(ctx, "Gamma")
+	s.Require().NoError(err)
+	s.Equal("Omega", project.Name)
+
+	var recreated int
+	s.Require().NoError(s.dbx.GetContext(ctx, &recreated, "SELECT COUNT(*) FROM projects WHERE name = 'Gamma'"))
+	s.Zero(recreated)
+
+	stored, _, err := store.GetExcerptsForProject(ctx, "Omega", ExcerptOptions{})
+	s.Require().NoError(err)
+	s.Require().Len(stored, 1)
+	s.Equal("Omega", stored[0].ProjectName)
+}
+
+func (s *NoteStoreSuite) TestCreateNote_IndexesTags() {
+	store := NewNoteService(s.dbx)
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+
+	content := `<p>Chose <strong>vendor</strong> <a href="https://example.com">terms</a> #Decision #risk</p>` +
+		`<ul data-type="taskList"><li data-type="taskItem" data-checked="false"><label><input type="checkbox"></label><div><p>Waiting on legal <span data-type="tag" data-id="blocked">#blocked</span></p></div></li></ul>` +
+		`<p>Use <code>#include</code> and issue#12</p>`
+
+	_, err := store.CreateNote(ctx, content, mustParseTime(time.DateOnly, "2026-01-02"))
+	s.Require().NoError(err)
+
+	tags, err := store.GetTags(ctx)
+	s.NoError(err)
+	s.ElementsMatch([]string{"decision", "risk", "blocked"}, []string{tags[0].Tag, tags[1].Tag, tags[2].Tag})
+	s.Len(tags, 3)
+
+	excerpts, err := store.GetExcerptsForTag(ctx, "#BLOCKED")
+	s.NoError(err)
+	s.Require().Len(excerpts, 1)
+	s.Equal("blocked", excerpts[0].ProjectName)
+	s.Equal("2026-01-02", excerpts[0].Date)
+
+	// Blocks are returned as the editor's JSON, like project excerpts
+	node, err := ParsePMNode(excerpts[0].Excerpt)
+	s.Require().NoError(err)
+	s.Equal("taskItem", node.Type)
+	s.Equal(false, node.Attrs["checked"])
+	s.Require().Len(node.Content, 1)
+	s.Equal("paragraph", node.Content[0].Type)
+	s.Equal("Waiting on legal #blocked", excerptText(RenderPMNode(node)))
+
+	s.Require().NoError(FormatExcerpts(excerpts, FormatText))
+	s.Equal("Waiting on legal #blocked", excerpts[0].Excerpt)
+
+	excerpts, err = store.GetExcerptsForTag(ctx, "risk")
+	s.NoError(err)
+	s.Require().Len(excerpts, 1)
+	s.Require().NoError(FormatExcerpts(excerpts, FormatHTML))
+	s.Equal(`<p>Chose <strong>vendor</strong> <a href="https://example.com">terms</a> #Decision #risk</p>`, excerpts[0].Excerpt)
+
+	// Rewriting the note replaces its tags
+	_, err = store.CreateNote(ctx, "<p>Nothing to see</p>", mustParseTime(time.DateOnly, "2026-01-02"))
+	s.Require().NoError(err)
+
+	tags, err = store.GetTags(ctx)
+	s.NoError(err)
+	s.Empty(tags)
+}
+
+func (s *NoteStoreSuite) TestReindex_IndexesOldNotes() {
+	store := NewNoteService(s.dbx)
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+
+	// Written before tags were indexed, as the seeded notes are
+	_, err := s.dbx.ExecContext(ctx, `INSERT INTO notes (html_content, note_date) VALUES ('<p>Old call #Decision</p>', '2025-06-01')`)
+	s.Require().NoError(err)
+
+	tags, err := store.GetTags(ctx)
+	s.NoError(err)
+	s.Empty(tags)
+
+	count, err := store.Reindex(ctx, 2)
+	s.Require().NoError(err)
+	s.Equal(6, count)
+
+	tags, err = store.GetTags(ctx)
+	s.NoError(err)
+	s.Require().Len(tags, 1)
+	s.Equal("decision", tags[0].Tag)
+	s.Equal("2025-06-01", tags[0].LastUsed)
+
+	// Notes are reindexed once
+	count, err = store.Reindex(ctx, 2)
+	s.NoError(err)
+	s.Zero(count)
+}
+
+func (s *NoteStoreSuite) TestReindex_SanitizesOldNotes() {
+	store := NewNoteService(s.dbx)
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+
+	// Stored before notes were sanitized
+	_, err := s.dbx.ExecContext(ctx, `INSERT INTO notes (html_content, note_date, updated_at) VALUES (?, '2025-06-01', '2025-06-01 10:00:00')`,
+		`<p onclick="steal()">Hi<script>alert(1)</script></p>`)
+	s.Require().NoError(err)
+
+	_, err = store.Reindex(ctx, 100)
+	s.Require().NoError(err)
 
+	note, err := store.GetNoteByDate(ctx, mustParseTime(time.DateOnly, "2025-06-01"))
 	s.NoError(err)
+	s.Equal(`<p>Hi</p>`, note.HTMLContent)
+	s.Equal(mustParseTime(time.DateTime, "2025-06-01 10:00:00"), note.UpdatedAt.UTC())
+}
+
+func (s *NoteStoreSuite) TestCreateNote_IndexesBacklinks() {
+	store := NewNoteService(s.dbx)
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+
+	content := `<p>See notes from 2026-01-01 and 2026-13-40</p>` +
+		`<p>Follow up on <a data-type="noteLink" data-date="2026-01-01" href="/dates/2026-01-01">new year</a></p>` +
+		`<p>Today is 2026-01-03</p>`
+
+	_, err := store.CreateNote(ctx, content, mustParseTime(time.DateOnly, "2026-01-03"))
+	s.Require().NoError(err)
+
+	backlinks, err := store.GetBacklinks(ctx, mustParseTime(time.DateOnly, "2026-01-01"))
+	s.NoError(err)
+	s.Require().Len(backlinks, 2)
+	s.Equal("2026-01-03", backlinks[0].SourceDate)
+	s.Equal("See notes from 2026-01-01 and 2026-13-40", backlinks[0].Snippet)
+	s.Equal(1, backlinks[1].BlockIndex)
+
+	self, err := store.GetBacklinks(ctx, mustParseTime(time.DateOnly, "2026-01-03"))
+	s.NoError(err)
+	s.Empty(self)
+
+	// Rewriting the source note drops links it no longer contains
+	_, err = store.CreateNote(ctx, "<p>No references</p>", mustParseTime(time.DateOnly, "2026-01-03"))
+	s.Require().NoError(err)
+
+	backlinks, err = store.GetBacklinks(ctx, mustParseTime(time.DateOnly, "2026-01-01"))
+	s.NoError(err)
+	s.Empty(backlinks)
+}
+
+func (s *NoteStoreSuite) TestCreateNote_Sanitizes() {
+	store := NewNoteService(s.dbx)
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+
+	clean := `<p class="para-node"><span class="mention" data-type="mention" data-id="Gamma" data-label="Gamma" data-mention-suggestion-char="@" data-mention-id="Gamma" contenteditable="false">@Gamma</span> <a href="https://example.com">link</a></p>`
+
+	note, err := store.CreateNote(ctx, clean, mustParseTime(time.DateOnly, "2026-04-01"))
+	s.NoError(err)
+	s.Equal(clean, note.HTMLContent)
+	s.Empty(note.Stripped)
+
+	dirty := `<p onclick="steal()">Hi<script>alert(1)</script> <a href="javascript:alert(1)">x</a><font>kept</font></p><img src="x" onerror="alert(1)">`
+
+	note, err = store.CreateNote(ctx, dirty, mustParseTime(time.DateOnly, "2026-04-02"))
+	s.NoError(err)
+	s.Equal(`<p>Hi <a>x</a>kept</p><img src="x"/>`, note.HTMLContent)
+	s.ElementsMatch([]string{
+		"onclick attribute on <p>",
+		"<script> element",
+		"unsafe href on <a>",
+		"<font> tag",
+		"onerror attribute on <img>",
+	}, note.Stripped)
+
+	stored, err := store.GetNoteByDate(ctx, mustParseTime(time.DateOnly, "2026-04-02"))
+	s.NoError(err)
+	s.Equal(note.HTMLContent, stored.HTMLContent)
+
+	// Nothing is stripped, but the stored HTML is still the parsed tree, not the input
+	note, err = store.CreateNote(ctx, `<p><b>bold<p>next</b><a href=/x>`, mustParseTime(time.DateOnly, "2026-04-03"))
+	s.NoError(err)
+	s.Equal(`<p><b>bold</b></p><p><b>next</b><a href="/x"></a></p>`, note.HTMLContent)
+	s.Empty(note.Stripped)
+
+	// Links opening a new window cannot reach back through window.opener
+	note, err = store.CreateNote(ctx, `<p><a href="https://example.com" target="_blank" rel="opener">x</a></p>`, mustParseTime(time.DateOnly, "2026-04-04"))
+	s.NoError(err)
+	s.Equal(`<p><a href="https://example.com" target="_blank" rel="noopener noreferrer">x</a></p>`, note.HTMLContent)
+}
+
+func (s *NoteStoreSuite) TestAppendToNote_CreatesNote() {
+	store := NewNoteService(s.dbx)
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+	date := mustParseTime(time.DateOnly, "2026-04-03")
+
+	s.dbx.MustExecContext(ctx, "INSERT INTO project_aliases (alias, project_name) VALUES ('gam', 'Gamma')")
+
+	note, err := store.AppendToNote(ctx, date, "Called @gam about **pricing**, cc bob@example.com and @Nobody")
+	s.Require().NoError(err)
+	s.Equal(`<p>Called <span class="mention" data-type="mention" data-id="Gamma" data-label="gam" data-mention-id="Gamma">@gam</span> about <strong>pricing</strong>, cc bob@example.com and @Nobody</p>`, note.HTMLContent)
+
+	stored, err := store.GetNoteByDate(ctx, date)
+	s.Require().NoError(err)
+	s.Equal(note.HTMLContent, stored.HTMLContent)
+
+	excerpts, _, err := store.GetExcerptsForProject(ctx, "Gamma", ExcerptOptions{})
+	s.Require().NoError(err)
+	s.Require().Len(excerpts, 1)
+	s.Equal("2026-04-03", excerpts[0].Date[:10])
+
+	rendered, err := ExcerptHTML(excerpts[0].Excerpt)
+	s.NoError(err)
+	s.Contains(rendered, `data-mention-id="Gamma"`)
+}
+
+func (s *NoteStoreSuite) TestAppendToNote_ExtendsLists() {
+	store := NewNoteService(s.dbx)
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+	date := mustParseTime(time.DateOnly, "2026-01-01")
+
+	note, err := store.AppendToNote(ctx, date, "- first\n* second")
+	s.Require().NoError(err)
+	s.Equal(`<p>Note for 2026-01-01</p><ul><li><p>first</p></li><li><p>second</p></li></ul>`, note.HTMLContent)
+
+	note, err = store.AppendToNote(ctx, date, "- third\n- [ ] Chase @Gamma invoice by 2026-01-05\nDone for today")
+	s.Require().NoError(err)
+	s.Equal(`<p>Note for 2026-01-01</p><ul><li><p>first</p></li><li><p>second</p></li><li><p>third</p></li></ul>`+
+		`<ul data-type="taskList"><li data-type="taskItem" data-checked="false"><label><input type="checkbox"/></label><div><p>Chase `+
+		`<span class="mention" data-type="mention" data-id="Gamma" data-label="Gamma" data-mention-id="Gamma">@Gamma</span> invoice by 2026-01-05</p></div></li></ul>`+
+		`<p>Done for today</p>`, note.HTMLContent)
+
+	excerpts, _, err := store.GetExcerptsForProject(ctx, "Gamma", ExcerptOptions{})
+	s.Require().NoError(err)
+	s.Require().Len(excerpts, 1)
+	s.Equal(ExcerptContext{Before: "<p>third</p>", After: "<p>Done for today</p>"}, excerpts[0].Context)
+
+	var reminders int
+	s.Require().NoError(s.dbx.GetContext(ctx, &reminders, "SELECT COUNT(*) FROM reminders WHERE note_id = ? AND project_name = 'Gamma'", note.Id))
+	s.Equal(1, reminders)
+
+	_, err = store.AppendToNote(ctx, date, "  \n ")
+	s.ErrorIs(err, ErrEmptyText)
+}
+
+func (s *NoteStoreSuite) TestWorkspaces_Isolated() {
+	store := NewNoteService(s.dbx)
+	date := mustParseTime(time.DateOnly, "2026-01-02")
+
+	s.dbx.MustExecContext(s.T().Context(), `INSERT INTO workspaces (id, name) VALUES (2, 'Team')`)
+	team := workspaces.WithID(s.T().Context(), 2)
+
+	// The same day has a note in each workspace
+	_, err := store.GetNoteByDate(team, date)
+	s.ErrorIs(err, sql.ErrNoRows)
+
+	note, _, err := store.SaveNote(team, date, "<p>Team @Gamma</p>", []ExcerptNode{{Node: "<p>Team @Gamma</p>", Projects: []string{"Gamma"}}}, SaveExcerptsOptions{CreateProjects: true})
+	s.Require().NoError(err)
+
+	// Gamma is created in the team workspace rather than reusing the default workspace's
+	_, saved, err := store.SaveNote(team, date, "<p>Team @Gamma</p>", []ExcerptNode{{Node: "<p>Team @Gamma</p>", Projects: []string{"Gamma"}}}, SaveExcerptsOptions{})
+	s.Require().NoError(err)
+	s.Equal([]string{"Gamma"}, saved.Projects)
+
+	own, err := store.GetNoteByDate(workspaces.WithID(s.T().Context(), workspaces.DefaultID), date)
+	s.Require().NoError(err)
+	s.NotEqual(note.Id, own.Id)
+	s.Equal("<p>Note for 2026-01-02</p>", own.HTMLContent)
+
+	excerpts, _, err := store.GetExcerptsForProject(workspaces.WithID(s.T().Context(), workspaces.DefaultID), "Gamma", ExcerptOptions{})
+	s.Require().NoError(err)
+	s.Empty(excerpts)
+
+	excerpts, _, err = store.GetExcerptsForProject(team, "Gamma", ExcerptOptions{})
+	s.Require().NoError(err)
+	s.Len(excerpts, 1)
+
+	recent, err := store.GetRecentNotes(team, 10)
+	s.Require().NoError(err)
+	s.Len(recent, 1)
+}
+
+func (s *NoteStoreSuite) TestWorkspaces_FailClosed() {
+	store := NewNoteService(s.dbx)
+	date := mustParseTime(time.DateOnly, "2026-01-02")
+
+	// Without a workspace nothing is read or written, not even in the default workspace
+	_, err := store.GetNoteByDate(s.T().Context(), date)
+	s.ErrorIs(err, workspaces.ErrNoWorkspace)
+
+	_, _, err = store.SaveNote(s.T().Context(), date, "<p>Lost</p>", nil, SaveExcerptsOptions{})
+	s.ErrorIs(err, workspaces.ErrNoWorkspace)
+
+	own, err := store.GetNoteByDate(workspaces.WithID(s.T().Context(), workspaces.DefaultID), date)
+	s.Require().NoError(err)
+	s.Equal("<p>Note for 2026-01-02</p>", own.HTMLContent)
 }
 
 func TestNoteStoreSuite(t *testing.T) {
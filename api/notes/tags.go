@@ -0,0 +1,87 @@
+package notes
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// hashtagPattern matches #tag tokens that start a word, the tag must begin with a letter
+var hashtagPattern = regexp.MustCompile(`(?:^|[\s(])#(\p{L}[\p{L}\p{N}_\-/]*)`)
+
+// BlockTag is a tag found in one block of a note
+type BlockTag struct {
+	Tag   string
+	Block Block
+}
+
+// NormalizeTag lowercases a tag and strips a leading #
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+}
+
+// ExtractTags finds hashtags and tag marks in each block, each tag is reported once per block
+// Text inside code is ignored so snippets like "#include" are not picked up
+func ExtractTags(blocks []Block) []BlockTag {
+	var tags []BlockTag
+
+	for _, block := range blocks {
+		seen := make(map[string]bool)
+
+		add := func(tag string) {
+			tag = NormalizeTag(tag)
+
+			if tag == "" || seen[tag] {
+				return
+			}
+
+			seen[tag] = true
+			tags = append(tags, BlockTag{Tag: tag, Block: block})
+		}
+
+		if block.Node.DataAtom == atom.Pre {
+			continue
+		}
+
+		var walk func(*html.Node)
+		walk = func(n *html.Node) {
+			if n.Type == html.ElementNode {
+				if n.DataAtom == atom.Code {
+					return
+				}
+
+				// Dedicated tag marks carry the tag in data-id, falling back to their text
+				if attr(n, "data-type") == "tag" {
+					if id := attr(n, "data-id"); id != "" {
+						add(id)
+					} else {
+						add(nodeText(n))
+					}
+					return
+				}
+
+				// Mentions are projects, not tags
+				if attr(n, "data-type") == "mention" {
+					return
+				}
+			}
+
+			if n.Type == html.TextNode {
+				for _, match := range hashtagPattern.FindAllStringSubmatch(n.Data, -1) {
+					add(match[1])
+				}
+				return
+			}
+
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				walk(c)
+			}
+		}
+
+		walk(block.Node)
+	}
+
+	return tags
+}
@@ -31,6 +31,8 @@ type OtelConfig struct {
 	MetricsExporter ExporterType
 	MetricsEnabled  bool
 	LoggerEnabled   bool
+	// Endpoint overrides the OTLP exporter endpoint from the environment when set
+	Endpoint string
 }
 
 func SetupOtel(ctx context.Context, cfg OtelConfig) (func(context.Context) error, error) {
@@ -70,7 +72,7 @@ func SetupOtel(ctx context.Context, cfg OtelConfig) (func(context.Context) error
 
 	// Set up trace provider.
 	if cfg.TraceEnabled {
-		tracerProvider, err := newTracerProvider(ctx, traceExporter)
+		tracerProvider, err := newTracerProvider(ctx, traceExporter, cfg.Endpoint)
 		if err != nil {
 			handleErr(err)
 			return shutdown, err
@@ -81,7 +83,7 @@ func SetupOtel(ctx context.Context, cfg OtelConfig) (func(context.Context) error
 
 	// Set up meter provider.
 	if cfg.MetricsEnabled {
-		meterProvider, err := newMeterProvider(ctx, metricsExporter)
+		meterProvider, err := newMeterProvider(ctx, metricsExporter, cfg.Endpoint)
 		if err != nil {
 			handleErr(err)
 			return shutdown, err
@@ -109,11 +111,16 @@ func newPropagator() propagation.TextMapPropagator {
 	)
 }
 
-func newTracerProvider(ctx context.Context, exporter ExporterType) (*trace.TracerProvider, error) {
+func newTracerProvider(ctx context.Context, exporter ExporterType, endpoint string) (*trace.TracerProvider, error) {
 	var tracerExporter trace.SpanExporter
 
 	if exporter == OtlpGrpcExporter {
-		grpcExporter, err := otlptracegrpc.New(ctx)
+		var opts []otlptracegrpc.Option
+		if endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpointURL(endpoint))
+		}
+
+		grpcExporter, err := otlptracegrpc.New(ctx, opts...)
 
 		if err != nil {
 			return nil, err
@@ -137,11 +144,16 @@ func newTracerProvider(ctx context.Context, exporter ExporterType) (*trace.Trace
 	return tracerProvider, nil
 }
 
-func newMeterProvider(ctx context.Context, exporter ExporterType) (*metric.MeterProvider, error) {
+func newMeterProvider(ctx context.Context, exporter ExporterType, endpoint string) (*metric.MeterProvider, error) {
 	var metricExporter metric.Exporter
 
 	if exporter == OtlpGrpcExporter {
-		grpcExporter, err := otlpmetricgrpc.New(ctx)
+		var opts []otlpmetricgrpc.Option
+		if endpoint != "" {
+			opts = append(opts, otlpmetricgrpc.WithEndpointURL(endpoint))
+		}
+
+		grpcExporter, err := otlpmetricgrpc.New(ctx, opts...)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,92 @@
+// Package pagination implements cursor pagination for list endpoints
+//
+// A cursor is the sort key of the last item on a page, encoded opaquely, so pages stay
+// stable while rows are added or removed before them
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/maybemaby/workpad/api/utils"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 200
+)
+
+// ErrInvalidCursor is returned for cursors that were not issued for the listing they are used with
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Page is one page of a listing, NextCursor is empty on the last page
+type Page[T any] struct {
+	Items      []T    `json:"items" required:"true" nullable:"false"`
+	NextCursor string `json:"next_cursor,omitempty" required:"false" example:"WyJuYW1lIiwiT0tUMTIzIl0"`
+}
+
+// Params reads the limit and cursor query params of r, limit is DefaultLimit when not set
+func Params(r *http.Request) (limit int, cursor string, err error) {
+	query := r.URL.Query()
+	limit = DefaultLimit
+
+	if value := query.Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > MaxLimit {
+			return 0, "", fmt.Errorf("limit must be between 1 and %d", MaxLimit)
+		}
+	}
+
+	return limit, query.Get("cursor"), nil
+}
+
+// EncodeCursor encodes the sort key of the last item on a page
+func EncodeCursor(values ...string) string {
+	data, _ := json.Marshal(values)
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor decodes a cursor that must hold n values
+func DecodeCursor(cursor string, n int) ([]string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var values []string
+
+	if err := json.Unmarshal(data, &values); err != nil || len(values) != n {
+		return nil, ErrInvalidCursor
+	}
+
+	return values, nil
+}
+
+// Write sends page as JSON, with a Link header to the next page when there is one
+func Write[T any](w http.ResponseWriter, r *http.Request, page Page[T]) error {
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+
+	if page.NextCursor != "" {
+		// RequestURI keeps the path prefix that route groups strip from r.URL
+		next, err := url.ParseRequestURI(r.RequestURI)
+		if err != nil {
+			next = r.URL
+		}
+
+		query := next.Query()
+		query.Set("cursor", page.NextCursor)
+		next.RawQuery = query.Encode()
+
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.RequestURI()))
+	}
+
+	return utils.WriteJSON(w, r, page)
+}
@@ -2,8 +2,12 @@ package projects
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/maybemaby/workpad/api/pagination"
 	"github.com/maybemaby/workpad/api/utils"
 )
 
@@ -18,11 +22,20 @@ func NewHandler(store ProjectStore) *ProjectHandler {
 }
 
 type GetProjectRequest struct {
-	Name string `json:"name" path:"name" example:"Project A" required:"true"`
+	Name string `json:"name" path:"name" example:"Project A" required:"true" maxLength:"100"`
 }
 
 type ListProjectsRequest struct {
 	Prefix string `query:"prefix" example:"Proj" required:"false"`
+	// Tree nests sub-projects under their parents instead of returning a flat list, it is never paginated
+	Tree bool `query:"tree" example:"true" required:"false"`
+	// Activity adds each project's mention counts and first and last mentioned dates
+	Activity bool `query:"activity" example:"true" required:"false"`
+	// Sort orders names ascending and the others newest first, created_at by default.
+	// activity puts the projects mentioned most in the last 30 days first
+	Sort   string `query:"sort" example:"last_mentioned" required:"false" enum:"name,created_at,last_mentioned,activity"`
+	Limit  int    `query:"limit" example:"50" required:"false" minimum:"1" maximum:"200"`
+	Cursor string `query:"cursor" required:"false"`
 }
 
 // CreateProject handles POST /projects
@@ -31,7 +44,7 @@ func (h *ProjectHandler) CreateProject(w http.ResponseWriter, r *http.Request) {
 	var req CreateProjectRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		utils.WriteBodyError(w, err)
 		return
 	}
 
@@ -74,17 +87,60 @@ func (h *ProjectHandler) GetProject(w http.ResponseWriter, r *http.Request) {
 }
 
 // ListProjects handles GET /projects
+// Returns a page of projects, or every project nested by parent when tree is set
 func (h *ProjectHandler) ListProjects(w http.ResponseWriter, r *http.Request) {
-	namePrefix := r.URL.Query().Get("prefix")
+	opts := ListOptions{
+		Prefix: r.URL.Query().Get("prefix"),
+		Sort:   r.URL.Query().Get("sort"),
+	}
+
+	tree := false
+	if value := r.URL.Query().Get("tree"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			http.Error(w, "Invalid tree parameter", http.StatusBadRequest)
+			return
+		}
+		tree = parsed
+	}
+
+	if value := r.URL.Query().Get("activity"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			http.Error(w, "Invalid activity parameter", http.StatusBadRequest)
+			return
+		}
+		opts.Activity = parsed
+	}
+
+	// A page of a tree could cut children off from their parents, so trees are never paginated
+	if !tree {
+		limit, cursor, err := pagination.Params(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 
-	projects, err := h.store.GetAll(r.Context(), namePrefix)
+		opts.Limit = limit
+		opts.Cursor = cursor
+	}
+
+	projects, next, err := h.store.List(r.Context(), opts)
 
 	if err != nil {
+		if errors.Is(err, pagination.ErrInvalidCursor) {
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	err = utils.WriteJSON(w, r, projects)
+	if tree {
+		err = pagination.Write(w, r, pagination.Page[ProjectNode]{Items: BuildTree(projects)})
+	} else {
+		err = pagination.Write(w, r, pagination.Page[Project]{Items: projects, NextCursor: next})
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -97,7 +153,7 @@ func (h *ProjectHandler) CreateMultipleProjects(w http.ResponseWriter, r *http.R
 	var req CreateMultipleProjectsRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		utils.WriteBodyError(w, err)
 		return
 	}
 
@@ -133,3 +189,191 @@ func (h *ProjectHandler) DeleteProject(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// SetParent handles PUT /projects/{name}/parent
+func (h *ProjectHandler) SetParent(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	var req SetParentRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteBodyError(w, err)
+		return
+	}
+
+	project, err := h.store.SetParent(r.Context(), name, req.ParentName)
+	if err != nil {
+		if errors.Is(err, ErrProjectCycle) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err.Error() == "project not found" {
+			http.Error(w, "Project not found", http.StatusNotFound)
+			return
+		}
+		if err.Error() == "parent project not found" {
+			http.Error(w, "Parent project not found", http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = utils.WriteJSON(w, r, project)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// SetTargetDate handles PUT /projects/{name}/target-date
+func (h *ProjectHandler) SetTargetDate(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	var req SetTargetDateRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteBodyError(w, err)
+		return
+	}
+
+	var targetDate *time.Time
+
+	if req.TargetDate != nil {
+		parsed, err := time.Parse(time.DateOnly, *req.TargetDate)
+		if err != nil {
+			http.Error(w, "Invalid target date format, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		targetDate = &parsed
+	}
+
+	project, err := h.store.SetTargetDate(r.Context(), name, targetDate)
+	if err != nil {
+		if err.Error() == "project not found" {
+			http.Error(w, "Project not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = utils.WriteJSON(w, r, project)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// RenameProject handles PUT /projects/{name}/name
+func (h *ProjectHandler) RenameProject(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	var req RenameProjectRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteBodyError(w, err)
+		return
+	}
+
+	project, err := h.store.Rename(r.Context(), name, req.NewName)
+	if err != nil {
+		if errors.Is(err, ErrNameConflict) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err.Error() == "project not found" {
+			http.Error(w, "Project not found", http.StatusNotFound)
+			return
+		}
+		if err.Error() == "project name cannot be empty" {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = utils.WriteJSON(w, r, project)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// ListAliases handles GET /projects/{name}/aliases
+func (h *ProjectHandler) ListAliases(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	aliases, err := h.store.GetAliases(r.Context(), name)
+	if err != nil {
+		if err.Error() == "project not found" {
+			http.Error(w, "Project not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = utils.WriteJSON(w, r, aliases)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// AddAlias handles POST /projects/{name}/aliases
+func (h *ProjectHandler) AddAlias(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	var req AddAliasRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteBodyError(w, err)
+		return
+	}
+
+	alias, err := h.store.AddAlias(r.Context(), name, req.Alias)
+	if err != nil {
+		if errors.Is(err, ErrAliasConflict) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err.Error() == "project not found" {
+			http.Error(w, "Project not found", http.StatusNotFound)
+			return
+		}
+		if err.Error() == "alias cannot be empty" {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	err = json.NewEncoder(w).Encode(alias)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// RemoveAlias handles DELETE /projects/{name}/aliases/{alias}
+func (h *ProjectHandler) RemoveAlias(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	alias := r.PathValue("alias")
+
+	err := h.store.RemoveAlias(r.Context(), name, alias)
+	if err != nil {
+		if err.Error() == "project not found" {
+			http.Error(w, "Project not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -9,6 +9,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/maybemaby/workpad/api/pagination"
 )
 
 // mockStore is a mock implementation of ProjectStore for testing
@@ -17,7 +19,14 @@ type mockStore struct {
 	createMultipleFunc func(ctx context.Context, names []string) ([]Project, error)
 	getByNameFunc      func(ctx context.Context, name string) (*Project, error)
 	getAllFunc         func(ctx context.Context, namePrefix string) ([]Project, error)
+	listFunc           func(ctx context.Context, opts ListOptions) ([]Project, string, error)
 	deleteFunc         func(ctx context.Context, name string) error
+	setParentFunc      func(ctx context.Context, name string, parentName *string) (*Project, error)
+	setTargetDateFunc  func(ctx context.Context, name string, targetDate *time.Time) (*Project, error)
+	renameFunc         func(ctx context.Context, name string, newName string) (*Project, error)
+	getAliasesFunc     func(ctx context.Context, name string) ([]ProjectAlias, error)
+	addAliasFunc       func(ctx context.Context, name string, alias string) (*ProjectAlias, error)
+	removeAliasFunc    func(ctx context.Context, name string, alias string) error
 }
 
 func (m *mockStore) Create(ctx context.Context, name string) (*Project, error) {
@@ -48,6 +57,13 @@ func (m *mockStore) GetAll(ctx context.Context, namePrefix string) ([]Project, e
 	return nil, nil
 }
 
+func (m *mockStore) List(ctx context.Context, opts ListOptions) ([]Project, string, error) {
+	if m.listFunc != nil {
+		return m.listFunc(ctx, opts)
+	}
+	return nil, "", nil
+}
+
 func (m *mockStore) DeleteByName(ctx context.Context, name string) error {
 	if m.deleteFunc != nil {
 		return m.deleteFunc(ctx, name)
@@ -55,6 +71,48 @@ func (m *mockStore) DeleteByName(ctx context.Context, name string) error {
 	return nil
 }
 
+func (m *mockStore) SetParent(ctx context.Context, name string, parentName *string) (*Project, error) {
+	if m.setParentFunc != nil {
+		return m.setParentFunc(ctx, name, parentName)
+	}
+	return nil, nil
+}
+
+func (m *mockStore) SetTargetDate(ctx context.Context, name string, targetDate *time.Time) (*Project, error) {
+	if m.setTargetDateFunc != nil {
+		return m.setTargetDateFunc(ctx, name, targetDate)
+	}
+	return nil, nil
+}
+
+func (m *mockStore) Rename(ctx context.Context, name string, newName string) (*Project, error) {
+	if m.renameFunc != nil {
+		return m.renameFunc(ctx, name, newName)
+	}
+	return nil, nil
+}
+
+func (m *mockStore) GetAliases(ctx context.Context, name string) ([]ProjectAlias, error) {
+	if m.getAliasesFunc != nil {
+		return m.getAliasesFunc(ctx, name)
+	}
+	return nil, nil
+}
+
+func (m *mockStore) AddAlias(ctx context.Context, name string, alias string) (*ProjectAlias, error) {
+	if m.addAliasFunc != nil {
+		return m.addAliasFunc(ctx, name, alias)
+	}
+	return nil, nil
+}
+
+func (m *mockStore) RemoveAlias(ctx context.Context, name string, alias string) error {
+	if m.removeAliasFunc != nil {
+		return m.removeAliasFunc(ctx, name, alias)
+	}
+	return nil
+}
+
 // TestCreateProject_Success tests successful project creation
 func TestCreateProject_Success(t *testing.T) {
 	mock := &mockStore{
@@ -209,21 +267,23 @@ func TestGetProject_NotFound(t *testing.T) {
 	}
 }
 
-// TestListProjects_Success tests successful listing of all projects
+// TestListProjects_Success tests listing a page of projects
 func TestListProjects_Success(t *testing.T) {
 	projects := []Project{
 		{Name: "Project 1", CreatedAt: time.Now()},
 		{Name: "Project 2", CreatedAt: time.Now()},
 	}
 
+	var got ListOptions
 	mock := &mockStore{
-		getAllFunc: func(ctx context.Context, namePrefix string) ([]Project, error) {
-			return projects, nil
+		listFunc: func(ctx context.Context, opts ListOptions) ([]Project, string, error) {
+			got = opts
+			return projects, "next-page", nil
 		},
 	}
 
 	handler := NewHandler(mock)
-	req := httptest.NewRequest("GET", "/projects", nil)
+	req := httptest.NewRequest("GET", "/projects?prefix=Pro&sort=name&limit=2&activity=true", nil)
 	w := httptest.NewRecorder()
 
 	handler.ListProjects(w, req)
@@ -232,21 +292,34 @@ func TestListProjects_Success(t *testing.T) {
 		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
 	}
 
-	var result []Project
+	if got != (ListOptions{Prefix: "Pro", Sort: SortName, Limit: 2, Activity: true}) {
+		t.Errorf("unexpected options: %+v", got)
+	}
+
+	var result pagination.Page[Project]
 	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
 		t.Fatalf("failed to decode response: %v", err)
 	}
 
-	if len(result) != 2 {
-		t.Errorf("expected 2 projects, got %d", len(result))
+	if len(result.Items) != 2 {
+		t.Errorf("expected 2 projects, got %d", len(result.Items))
+	}
+
+	if result.NextCursor != "next-page" {
+		t.Errorf("expected next cursor, got %q", result.NextCursor)
+	}
+
+	link := w.Header().Get("Link")
+	if link != `</projects?activity=true&cursor=next-page&limit=2&prefix=Pro&sort=name>; rel="next"` {
+		t.Errorf("unexpected Link header %q", link)
 	}
 }
 
 // TestListProjects_Empty tests listing when no projects exist
 func TestListProjects_Empty(t *testing.T) {
 	mock := &mockStore{
-		getAllFunc: func(ctx context.Context, namePrefix string) ([]Project, error) {
-			return []Project{}, nil
+		listFunc: func(ctx context.Context, opts ListOptions) ([]Project, string, error) {
+			return nil, "", nil
 		},
 	}
 
@@ -260,21 +333,40 @@ func TestListProjects_Empty(t *testing.T) {
 		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
 	}
 
-	var result []Project
-	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
-		t.Fatalf("failed to decode response: %v", err)
+	if body := strings.TrimSpace(w.Body.String()); body != `{"items":[]}` {
+		t.Errorf("unexpected body %s", body)
+	}
+
+	if w.Header().Get("Link") != "" {
+		t.Errorf("expected no Link header on the last page")
+	}
+}
+
+// TestListProjects_InvalidPage tests rejecting bad limits and cursors
+func TestListProjects_InvalidPage(t *testing.T) {
+	mock := &mockStore{
+		listFunc: func(ctx context.Context, opts ListOptions) ([]Project, string, error) {
+			return nil, "", pagination.ErrInvalidCursor
+		},
 	}
 
-	if len(result) != 0 {
-		t.Errorf("expected 0 projects, got %d", len(result))
+	handler := NewHandler(mock)
+
+	for _, target := range []string{"/projects?limit=0", "/projects?limit=1000", "/projects?cursor=bogus"} {
+		w := httptest.NewRecorder()
+		handler.ListProjects(w, httptest.NewRequest("GET", target, nil))
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", target, http.StatusBadRequest, w.Code)
+		}
 	}
 }
 
 // TestListProjects_DatabaseError tests database error handling
 func TestListProjects_DatabaseError(t *testing.T) {
 	mock := &mockStore{
-		getAllFunc: func(ctx context.Context, namePrefix string) ([]Project, error) {
-			return nil, errors.New("database error")
+		listFunc: func(ctx context.Context, opts ListOptions) ([]Project, string, error) {
+			return nil, "", errors.New("database error")
 		},
 	}
 
@@ -449,3 +541,103 @@ func TestDeleteProject_DatabaseError(t *testing.T) {
 		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, w.Code)
 	}
 }
+
+// TestListProjects_Tree tests nesting sub-projects under their parents
+func TestListProjects_Tree(t *testing.T) {
+	client := "Client"
+	mock := &mockStore{
+		listFunc: func(ctx context.Context, opts ListOptions) ([]Project, string, error) {
+			if opts.Limit != 0 {
+				t.Errorf("expected trees to be unpaginated, got limit %d", opts.Limit)
+			}
+
+			return []Project{
+				{Name: "Client"},
+				{Name: "Engagement", ParentName: &client},
+				{Name: "Other"},
+			}, "", nil
+		},
+	}
+
+	handler := NewHandler(mock)
+	req := httptest.NewRequest("GET", "/projects?tree=true", nil)
+	w := httptest.NewRecorder()
+
+	handler.ListProjects(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var page pagination.Page[ProjectNode]
+	if err := json.NewDecoder(w.Body).Decode(&page); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	result := page.Items
+	if len(result) != 2 || len(result[0].Children) != 1 || result[0].Children[0].Name != "Engagement" {
+		t.Errorf("unexpected tree: %+v", result)
+	}
+}
+
+// TestSetParent_Cycle tests that cyclic parent assignments are rejected
+func TestSetParent_Cycle(t *testing.T) {
+	mock := &mockStore{
+		setParentFunc: func(ctx context.Context, name string, parentName *string) (*Project, error) {
+			return nil, ErrProjectCycle
+		},
+	}
+
+	handler := NewHandler(mock)
+	req := httptest.NewRequest("PUT", "/projects/Client/parent", strings.NewReader(`{"parent_name":"Client"}`))
+	req.SetPathValue("name", "Client")
+	w := httptest.NewRecorder()
+
+	handler.SetParent(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+}
+
+// TestAddAlias_Conflict tests that aliases colliding with other projects are rejected
+func TestAddAlias_Conflict(t *testing.T) {
+	mock := &mockStore{
+		addAliasFunc: func(ctx context.Context, name string, alias string) (*ProjectAlias, error) {
+			return nil, ErrAliasConflict
+		},
+	}
+
+	handler := NewHandler(mock)
+	req := httptest.NewRequest("POST", "/projects/OKT-123/aliases", strings.NewReader(`{"alias":"OKT"}`))
+	req.SetPathValue("name", "OKT-123")
+	w := httptest.NewRecorder()
+
+	handler.AddAlias(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+}
+
+func TestRenameProject_Conflict(t *testing.T) {
+	mock := &mockStore{
+		renameFunc: func(ctx context.Context, name string, newName string) (*Project, error) {
+			if name != "OKT-123" || newName != "OKT-124" {
+				t.Errorf("unexpected rename %q to %q", name, newName)
+			}
+			return nil, ErrNameConflict
+		},
+	}
+
+	handler := NewHandler(mock)
+	req := httptest.NewRequest("PUT", "/projects/OKT-123/name", strings.NewReader(`{"name":"OKT-124"}`))
+	req.SetPathValue("name", "OKT-123")
+	w := httptest.NewRecorder()
+
+	handler.RenameProject(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+}
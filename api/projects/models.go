@@ -1,17 +1,116 @@
 package projects
 
-import "time"
+import (
+	"errors"
+	"time"
+
+	"github.com/maybemaby/workpad/api/pagination"
+)
+
+var (
+	// ErrProjectCycle is returned when a parent assignment would make a project its own ancestor
+	ErrProjectCycle = errors.New("project cannot be nested under itself or its descendants")
+
+	// ErrAliasConflict is returned when an alias is already a project name or points at another project
+	ErrAliasConflict = errors.New("alias is already in use")
+
+	// ErrNameConflict is returned when a rename targets another project's name or alias
+	ErrNameConflict = errors.New("project name is already in use")
+)
+
+// Sorts for List, names ascending and the others newest first
+// SortActivity puts the projects mentioned most in the last 30 days first, then the most recently mentioned
+const (
+	SortName          = "name"
+	SortCreatedAt     = "created_at"
+	SortLastMentioned = "last_mentioned"
+	SortActivity      = "activity"
+)
+
+// ListOptions selects a page of projects
+type ListOptions struct {
+	// Prefix keeps projects whose name starts with it, case-insensitive
+	Prefix string
+	// Sort is SortName, SortCreatedAt, SortLastMentioned or SortActivity, SortCreatedAt when empty
+	Sort string
+	// Activity fills in each project's Activity
+	Activity bool
+	// Limit is the page size, every project is returned when it is 0
+	Limit int
+	// Cursor continues after the page it was returned with
+	Cursor string
+}
 
 type Project struct {
-	Name      string    `json:"name" required:"true"`
-	CreatedAt time.Time `json:"created_at" db:"created_at" required:"true"`
+	Name       string    `json:"name" required:"true"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at" required:"true"`
+	ParentName *string   `json:"parent_name,omitempty" db:"parent_name" required:"false"`
+	// TargetDate is the day the project is due to finish, shown in the calendar feed
+	TargetDate *time.Time `json:"target_date,omitempty" db:"target_date" required:"false"`
+	// Activity is only set by listings that ask for it
+	Activity *ProjectActivity `json:"activity,omitempty" db:"-" required:"false"`
+}
+
+// ProjectActivity summarizes how often a project is mentioned in notes
+// The dates are null for projects that were never mentioned
+type ProjectActivity struct {
+	FirstMentioned *string `json:"first_mentioned" db:"first_mentioned" required:"true" example:"2026-01-05" format:"date"`
+	LastMentioned  *string `json:"last_mentioned" db:"last_mentioned" required:"true" example:"2026-10-19" format:"date"`
+	Mentions       int     `json:"mentions" db:"mentions" required:"true" example:"42"`
+	// Mentions7d and Mentions30d count mentions in notes from the last 7 and 30 days, today included
+	Mentions7d  int `json:"mentions_7d" db:"mentions_7d" required:"true" example:"3"`
+	Mentions30d int `json:"mentions_30d" db:"mentions_30d" required:"true" example:"11"`
+}
+
+// ProjectPage is a page of GET /projects, named for the OpenAPI spec
+type ProjectPage pagination.Page[ProjectNode]
+
+// ProjectNode is a project with its sub-projects, as returned by tree listings
+// Flat listings return nodes without children
+type ProjectNode struct {
+	Project
+	Children []ProjectNode `json:"children,omitempty" required:"false"`
 }
 
 type CreateProjectRequest struct {
-	Name string `json:"name" required:"true"`
+	Name string `json:"name" required:"true" maxLength:"100"`
 }
 
 // CreateMultipleProjectsRequest is the request body for batch project creation
 type CreateMultipleProjectsRequest struct {
-	Projects []string `json:"projects" example:"[Project A, Project B]" required:"true"`
+	Projects []string `json:"projects" example:"[Project A, Project B]" required:"true" minItems:"1" maxItems:"100"`
+}
+
+// SetParentRequest moves a project under another one, a null parent makes it top level
+type SetParentRequest struct {
+	Name       string  `json:"-" path:"name" example:"Workstream A" required:"true"`
+	ParentName *string `json:"parent_name" example:"Client A" required:"true" maxLength:"100"`
+}
+
+// SetTargetDateRequest sets when a project is due to finish, a null date clears it
+type SetTargetDateRequest struct {
+	Name       string  `json:"-" path:"name" example:"OKT-123" required:"true"`
+	TargetDate *string `json:"target_date" example:"2026-12-01" required:"true" format:"date"`
+}
+
+type RenameProjectRequest struct {
+	Name    string `json:"-" path:"name" example:"OKT-123" required:"true"`
+	NewName string `json:"name" example:"OKT-124" required:"true" maxLength:"100"`
+}
+
+// ProjectAlias is an alternative spelling that resolves to a canonical project
+type ProjectAlias struct {
+	Alias       string    `json:"alias" required:"true"`
+	ProjectName string    `json:"project_name" db:"project_name" required:"true"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at" required:"true"`
+}
+
+type AddAliasRequest struct {
+	Name  string `json:"-" path:"name" example:"OKT-123" required:"true"`
+	Alias string `json:"alias" example:"OKT" required:"true" maxLength:"100"`
+}
+
+type RemoveAliasRequest struct {
+	Name  string `path:"name" example:"OKT-123" required:"true"`
+	Alias string `path:"alias" example:"OKT" required:"true"`
 }
@@ -6,53 +6,140 @@ import (
 	"fmt"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/maybemaby/workpad/api/pagination"
+	"github.com/maybemaby/workpad/api/tz"
+	"github.com/maybemaby/workpad/api/workspaces"
 )
 
 // SqliteStore implements the Store interface using SQLite
 type SqliteStore struct {
-	db *sqlx.DB
+	db     *sqlx.DB
+	events Events
 }
 
 // NewSqliteStore creates a new SQLite store
 func NewSqliteStore(db *sqlx.DB) *SqliteStore {
-	return &SqliteStore{db: db}
+	return &SqliteStore{db: db, events: noEvents{}}
+}
+
+// WithEvents reports project changes to events from within their transactions
+func (s *SqliteStore) WithEvents(events Events) *SqliteStore {
+	s.events = events
+	return s
+}
+
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type execQueryRower interface {
+	queryRower
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// resolveAlias returns the canonical project name for an alias in the workspace of ctx, or the name unchanged
+func resolveAlias(ctx context.Context, q queryRower, name string) (string, error) {
+	var canonical string
+
+	ws, err := workspaces.ID(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	err = q.QueryRowContext(ctx, `SELECT COALESCE((SELECT project_name FROM project_aliases WHERE workspace_id = ? AND alias = ?), ?)`, ws, name, name).Scan(&canonical)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve project alias: %w", err)
+	}
+
+	return canonical, nil
 }
 
 // Create inserts a new project or returns the existing one if name already exists
 // Uses SQLite UPSERT syntax: INSERT ... ON CONFLICT ... DO NOTHING
 // This is atomic and returns the project (new or existing)
+// Names matching an alias return the aliased project instead of creating a new one
 func (s *SqliteStore) Create(ctx context.Context, name string) (*Project, error) {
-	if name == "" {
-		return nil, fmt.Errorf("project name cannot be empty")
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	project, created, err := createProject(ctx, tx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if created {
+		if err := s.events.ProjectCreated(ctx, tx, *project); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
+	return project, nil
+}
+
+// CreateInTx is Create within tx, for writes that create projects along with other rows
+// It reports whether the project is new rather than an existing one or an alias
+func CreateInTx(ctx context.Context, tx *sqlx.Tx, name string) (*Project, bool, error) {
+	return createProject(ctx, tx, name)
+}
+
+// createProject trims name, resolves it as an alias, and inserts it unless the project exists
+func createProject(ctx context.Context, q execQueryRower, name string) (*Project, bool, error) {
 	cleanedName := strings.TrimSpace(name)
 
+	if cleanedName == "" {
+		return nil, false, fmt.Errorf("project name cannot be empty")
+	}
+
+	cleanedName, err := resolveAlias(ctx, q, cleanedName)
+	if err != nil {
+		return nil, false, err
+	}
+
 	// SQLite upsert: insert if not exists, do nothing if conflict on unique constraint
 	// Then retrieve the (existing or newly created) project
-	query := `INSERT INTO projects (name) VALUES (?) ON CONFLICT(name) DO NOTHING`
+	query := `INSERT INTO projects (workspace_id, name) VALUES (?, ?) ON CONFLICT(workspace_id, name) DO NOTHING`
+
+	ws, err := workspaces.ID(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	result, err := q.ExecContext(ctx, query, ws, cleanedName)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create project: %w", err)
+	}
 
-	_, err := s.db.ExecContext(ctx, query, cleanedName)
+	inserted, err := result.RowsAffected()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create project: %w", err)
+		return nil, false, fmt.Errorf("failed to create project: %w", err)
 	}
 
 	// Retrieve the project (existing or newly created) by name
-	getQuery := `SELECT name, created_at FROM projects WHERE name = ?`
+	getQuery := `SELECT name, created_at, parent_name, target_date FROM projects WHERE workspace_id = ? AND name = ?`
 	var project Project
-	err = s.db.QueryRowContext(ctx, getQuery, cleanedName).Scan(&project.Name, &project.CreatedAt)
+	err = q.QueryRowContext(ctx, getQuery, ws, cleanedName).Scan(&project.Name, &project.CreatedAt, &project.ParentName, &project.TargetDate)
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve project: %w", err)
+		return nil, false, fmt.Errorf("failed to retrieve project: %w", err)
 	}
 
-	return &project, nil
+	return &project, inserted > 0, nil
 }
 
 // CreateMultiple inserts multiple projects using SQLite upsert syntax
 // Uses a transaction to ensure atomicity
 // Projects with duplicate names will be ignored (no change if already exist)
+// Names matching an alias return the aliased project instead of creating a new one
 func (s *SqliteStore) CreateMultiple(ctx context.Context, names []string) ([]Project, error) {
 	if len(names) == 0 {
 		return []Project{}, nil
@@ -63,14 +150,8 @@ func (s *SqliteStore) CreateMultiple(ctx context.Context, names []string) ([]Pro
 		return nil, fmt.Errorf("project name cannot be empty")
 	}
 
-	cleanedNames := make([]string, len(names))
-
-	for i, name := range names {
-		cleanedNames[i] = strings.TrimSpace(name)
-	}
-
 	// Start a transaction
-	tx, err := s.db.BeginTx(ctx, nil)
+	tx, err := s.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -80,23 +161,19 @@ func (s *SqliteStore) CreateMultiple(ctx context.Context, names []string) ([]Pro
 	var projects []Project
 
 	// Insert each project using SQLite upsert syntax
-	for _, name := range cleanedNames {
-		query := `INSERT INTO projects (name) VALUES (?) ON CONFLICT(name) DO NOTHING`
-
-		_, err := tx.ExecContext(ctx, query, name)
+	for _, name := range names {
+		project, created, err := createProject(ctx, tx, name)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create project: %w", err)
+			return nil, err
 		}
 
-		// Retrieve the project (existing or newly created) by name
-		getQuery := `SELECT name, created_at FROM projects WHERE name = ?`
-		var project Project
-		err = tx.QueryRowContext(ctx, getQuery, name).Scan(&project.Name, &project.CreatedAt)
-		if err != nil {
-			return nil, fmt.Errorf("failed to retrieve project: %w", err)
+		if created {
+			if err := s.events.ProjectCreated(ctx, tx, *project); err != nil {
+				return nil, err
+			}
 		}
 
-		projects = append(projects, project)
+		projects = append(projects, *project)
 	}
 
 	// Commit the transaction
@@ -107,12 +184,18 @@ func (s *SqliteStore) CreateMultiple(ctx context.Context, names []string) ([]Pro
 	return projects, nil
 }
 
-// GetByName retrieves a project by its name
+// GetByName retrieves a project by its name or one of its aliases
 func (s *SqliteStore) GetByName(ctx context.Context, name string) (*Project, error) {
-	query := `SELECT name, created_at FROM projects WHERE name = ?`
+	query := `SELECT name, created_at, parent_name, target_date FROM projects
+		WHERE workspace_id = ? AND name = COALESCE((SELECT project_name FROM project_aliases WHERE workspace_id = ? AND alias = ?), ?)`
+
+	ws, err := workspaces.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	var project Project
-	err := s.db.QueryRowContext(ctx, query, name).Scan(&project.Name, &project.CreatedAt)
+	err = s.db.QueryRowContext(ctx, query, ws, ws, name, name).Scan(&project.Name, &project.CreatedAt, &project.ParentName, &project.TargetDate)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("project not found")
@@ -129,17 +212,23 @@ func (s *SqliteStore) GetAll(ctx context.Context, namePrefix string) ([]Project,
 	var query string
 	var args []any
 
+	ws, err := workspaces.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	if namePrefix != "" {
 		// Filter by name prefix (case-insensitive)
-		query = `SELECT name, created_at FROM projects WHERE LOWER(name) LIKE LOWER(?) ORDER BY created_at DESC`
-		args = []any{namePrefix + "%"}
+		query = `SELECT name, created_at, parent_name, target_date FROM projects WHERE workspace_id = ? AND LOWER(name) LIKE LOWER(?) ORDER BY created_at DESC`
+		args = []any{ws, namePrefix + "%"}
 	} else {
 		// Get all projects
-		query = `SELECT name, created_at FROM projects ORDER BY created_at DESC`
+		query = `SELECT name, created_at, parent_name, target_date FROM projects WHERE workspace_id = ? ORDER BY created_at DESC`
+		args = []any{ws}
 	}
 
 	var projects []Project
-	err := s.db.SelectContext(ctx, &projects, query, args...)
+	err = s.db.SelectContext(ctx, &projects, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get projects: %w", err)
 	}
@@ -152,13 +241,431 @@ func (s *SqliteStore) GetAll(ctx context.Context, namePrefix string) ([]Project,
 	return projects, nil
 }
 
+// projectSortKeys are the SQL sort keys of each sort and whether they sort newest first
+// Keys are text so they survive the round trip through a cursor, ties are broken by name
+var projectSortKeys = map[string]struct {
+	expr     string
+	desc     bool
+	activity bool // the key reads the activity join
+}{
+	SortName:          {expr: "p.name", desc: false},
+	SortCreatedAt:     {expr: "CAST(p.created_at AS TEXT)", desc: true},
+	SortLastMentioned: {expr: "COALESCE((SELECT CAST(MAX(e.note_date) AS TEXT) FROM project_excerpts e WHERE e.workspace_id = p.workspace_id AND e.project_name = p.name), '')", desc: true},
+	// Zero padding makes the text order of the counts match their numeric order
+	SortActivity: {expr: "printf('%010d|%s', COALESCE(a.mentions_30d, 0), COALESCE(a.last_mentioned, ''))", desc: true, activity: true},
+}
+
+// activityJoin aggregates the mentions of every project in one pass over the
+// (workspace_id, project_name, note_date) index, its args are the first days of the 7 and 30 day windows
+// and the workspace
+const activityJoin = ` LEFT JOIN (
+		SELECT project_name, date(MIN(note_date)) AS first_mentioned, date(MAX(note_date)) AS last_mentioned, COUNT(*) AS mentions,
+			SUM(date(note_date) >= ?) AS mentions_7d, SUM(date(note_date) >= ?) AS mentions_30d
+		FROM project_excerpts WHERE workspace_id = ? GROUP BY project_name
+	) a ON a.project_name = p.name`
+
+const activityColumns = `, a.first_mentioned, a.last_mentioned, COALESCE(a.mentions, 0) AS mentions,
+	COALESCE(a.mentions_7d, 0) AS mentions_7d, COALESCE(a.mentions_30d, 0) AS mentions_30d`
+
+type projectRow struct {
+	Project
+	ProjectActivity
+	SortKey string `db:"sort_key"`
+}
+
+// List returns a page of projects and the cursor of the next page, empty on the last page
+func (s *SqliteStore) List(ctx context.Context, opts ListOptions) ([]Project, string, error) {
+	sort := opts.Sort
+	if sort == "" {
+		sort = SortCreatedAt
+	}
+
+	key, ok := projectSortKeys[sort]
+	if !ok {
+		return nil, "", fmt.Errorf("unknown project sort %q", sort)
+	}
+
+	columns, join := "", ""
+	var args []any
+
+	ws, err := workspaces.ID(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if opts.Activity || key.activity {
+		today := tz.Today(ctx)
+
+		columns, join = activityColumns, activityJoin
+		args = append(args, today.AddDate(0, 0, -6).Format(time.DateOnly), today.AddDate(0, 0, -29).Format(time.DateOnly), ws)
+	}
+
+	query := `SELECT * FROM (
+			SELECT p.name, p.created_at, p.parent_name, p.target_date` + columns + `, ` + key.expr + ` AS sort_key
+			FROM projects p` + join + ` WHERE p.workspace_id = ? AND LOWER(p.name) LIKE LOWER(?)
+		)`
+	args = append(args, ws, opts.Prefix+"%")
+
+	order := "ASC"
+	after := ">"
+	if key.desc {
+		order = "DESC"
+		after = "<"
+	}
+
+	if opts.Cursor != "" {
+		values, err := pagination.DecodeCursor(opts.Cursor, 3)
+		if err != nil || values[0] != sort {
+			return nil, "", pagination.ErrInvalidCursor
+		}
+
+		query += ` WHERE sort_key ` + after + ` ? OR (sort_key = ? AND name > ?)`
+		args = append(args, values[1], values[1], values[2])
+	}
+
+	query += ` ORDER BY sort_key ` + order + `, name`
+
+	// One extra row tells whether there is a next page
+	if opts.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, opts.Limit+1)
+	}
+
+	var rows []projectRow
+	if err := s.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, "", fmt.Errorf("failed to list projects: %w", err)
+	}
+
+	next := ""
+	if opts.Limit > 0 && len(rows) > opts.Limit {
+		rows = rows[:opts.Limit]
+		last := rows[len(rows)-1]
+		next = pagination.EncodeCursor(sort, last.SortKey, last.Name)
+	}
+
+	projects := make([]Project, len(rows))
+	for i, row := range rows {
+		projects[i] = row.Project
+
+		if opts.Activity {
+			projects[i].Activity = &row.ProjectActivity
+		}
+	}
+
+	return projects, next, nil
+}
+
+// DeleteByName deletes the project with exactly name, an alias deletes nothing
 func (s *SqliteStore) DeleteByName(ctx context.Context, name string) error {
-	query := `DELETE FROM projects WHERE name = ?`
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	query := `DELETE FROM projects WHERE workspace_id = ? AND name = ?`
 
-	_, err := s.db.ExecContext(ctx, query, name)
+	ws, err := workspaces.ID(ctx)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, query, ws, name)
 	if err != nil {
 		return fmt.Errorf("failed to delete project: %w", err)
 	}
 
+	if deleted, err := result.RowsAffected(); err == nil && deleted > 0 {
+		if err := s.events.ProjectDeleted(ctx, tx, name); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// SetParent moves a project under parentName, or to the top level when parentName is nil
+// Returns ErrProjectCycle if the parent is the project itself or one of its descendants
+func (s *SqliteStore) SetParent(ctx context.Context, name string, parentName *string) (*Project, error) {
+	if _, err := s.GetByName(ctx, name); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	ws, err := workspaces.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if parentName != nil {
+		var parentExists bool
+
+		err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM projects WHERE workspace_id = ? AND name = ?)`, ws, *parentName).Scan(&parentExists)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get parent project: %w", err)
+		}
+
+		if !parentExists {
+			return nil, fmt.Errorf("parent project not found")
+		}
+
+		// Walk up from the new parent, if we reach the project itself the move would create a cycle
+		cycleQuery := `WITH RECURSIVE ancestors(name) AS (
+			SELECT ?
+			UNION
+			SELECT p.parent_name FROM projects p JOIN ancestors a ON p.name = a.name WHERE p.workspace_id = ? AND p.parent_name IS NOT NULL
+		)
+		SELECT EXISTS (SELECT 1 FROM ancestors WHERE name = ?)`
+
+		var cycle bool
+
+		if err := tx.QueryRowContext(ctx, cycleQuery, *parentName, ws, name).Scan(&cycle); err != nil {
+			return nil, fmt.Errorf("failed to check project ancestry: %w", err)
+		}
+
+		if cycle {
+			return nil, ErrProjectCycle
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE projects SET parent_name = ? WHERE workspace_id = ? AND name = ?`, parentName, ws, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update project parent: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return s.GetByName(ctx, name)
+}
+
+// SetTargetDate sets the date a project is due to finish, nil clears it
+func (s *SqliteStore) SetTargetDate(ctx context.Context, name string, targetDate *time.Time) (*Project, error) {
+	project, err := s.GetByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var value *string
+
+	if targetDate != nil {
+		formatted := targetDate.Format(time.DateOnly)
+		value = &formatted
+	}
+
+	ws, err := workspaces.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.db.ExecContext(ctx, `UPDATE projects SET target_date = ? WHERE workspace_id = ? AND name = ?`, value, ws, project.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update project target date: %w", err)
+	}
+
+	return s.GetByName(ctx, project.Name)
+}
+
+// renameReferences lists the columns holding project names, updated explicitly on rename
+// so renames work whether or not the connection enforces ON UPDATE CASCADE
+// scope limits the update to rows of the workspace, its one arg is the workspace id
+var renameReferences = []struct{ table, column, scope string }{
+	{"projects", "parent_name", "workspace_id = ?"},
+	{"project_aliases", "project_name", "workspace_id = ?"},
+	{"project_excerpts", "project_name", "workspace_id = ?"},
+	{"time_entries", "project_name", "workspace_id = ?"},
+	{"reminders", "project_name", "note_id IN (SELECT id FROM notes WHERE workspace_id = ?)"},
+}
+
+// Rename changes a project's name, carrying its excerpts, aliases, sub-projects and other references along
+// The old name becomes an alias, so mentions of it in existing notes keep resolving to the project
+// Returns ErrNameConflict if newName is another project's name or alias
+func (s *SqliteStore) Rename(ctx context.Context, name string, newName string) (*Project, error) {
+	newName = strings.TrimSpace(newName)
+
+	if newName == "" {
+		return nil, fmt.Errorf("project name cannot be empty")
+	}
+
+	project, err := s.GetByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if project.Name == newName {
+		return project, nil
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	ws, err := workspaces.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var conflict bool
+
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM projects WHERE workspace_id = ? AND LOWER(name) = LOWER(?) AND name != ?)
+		OR EXISTS (SELECT 1 FROM project_aliases WHERE workspace_id = ? AND alias = ? AND project_name != ?)`, ws, newName, project.Name, ws, newName, project.Name).Scan(&conflict)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check project name: %w", err)
+	}
+
+	if conflict {
+		return nil, ErrNameConflict
+	}
+
+	// An alias of this project becomes redundant once it is the name
+	if _, err := tx.ExecContext(ctx, `DELETE FROM project_aliases WHERE workspace_id = ? AND alias = ?`, ws, newName); err != nil {
+		return nil, fmt.Errorf("failed to remove alias: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE projects SET name = ? WHERE workspace_id = ? AND name = ?`, newName, ws, project.Name); err != nil {
+		return nil, fmt.Errorf("failed to rename project: %w", err)
+	}
+
+	for _, ref := range renameReferences {
+		query := fmt.Sprintf(`UPDATE %s SET %s = ? WHERE %s = ? AND %s`, ref.table, ref.column, ref.column, ref.scope)
+
+		if _, err := tx.ExecContext(ctx, query, newName, project.Name, ws); err != nil {
+			return nil, fmt.Errorf("failed to update %s: %w", ref.table, err)
+		}
+	}
+
+	// A change of case only needs no alias, names already resolve case-insensitively
+	if !strings.EqualFold(project.Name, newName) {
+		_, err := tx.ExecContext(ctx, `INSERT INTO project_aliases (workspace_id, alias, project_name) VALUES (?, ?, ?) ON CONFLICT(workspace_id, alias) DO NOTHING`, ws, project.Name, newName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to alias old project name: %w", err)
+		}
+	}
+
+	renamed := *project
+	renamed.Name = newName
+
+	if err := s.events.ProjectRenamed(ctx, tx, project.Name, renamed); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return s.GetByName(ctx, newName)
+}
+
+// GetAliases lists the aliases of a project
+func (s *SqliteStore) GetAliases(ctx context.Context, name string) ([]ProjectAlias, error) {
+	project, err := s.GetByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	aliases := []ProjectAlias{}
+
+	ws, err := workspaces.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.SelectContext(ctx, &aliases, `SELECT alias, project_name, created_at FROM project_aliases WHERE workspace_id = ? AND project_name = ? ORDER BY alias`, ws, project.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project aliases: %w", err)
+	}
+
+	return aliases, nil
+}
+
+// AddAlias makes alias resolve to the named project
+// Returns ErrAliasConflict if the alias is a project name or already points at another project
+func (s *SqliteStore) AddAlias(ctx context.Context, name string, alias string) (*ProjectAlias, error) {
+	alias = strings.TrimSpace(alias)
+
+	if alias == "" {
+		return nil, fmt.Errorf("alias cannot be empty")
+	}
+
+	project, err := s.GetByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	ws, err := workspaces.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var conflict bool
+
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM projects WHERE workspace_id = ? AND LOWER(name) = LOWER(?))
+		OR EXISTS (SELECT 1 FROM project_aliases WHERE workspace_id = ? AND alias = ? AND project_name != ?)`, ws, alias, ws, alias, project.Name).Scan(&conflict)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check alias: %w", err)
+	}
+
+	if conflict {
+		return nil, ErrAliasConflict
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO project_aliases (workspace_id, alias, project_name) VALUES (?, ?, ?) ON CONFLICT(workspace_id, alias) DO NOTHING`, ws, alias, project.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create alias: %w", err)
+	}
+
+	var created ProjectAlias
+
+	err = tx.QueryRowContext(ctx, `SELECT alias, project_name, created_at FROM project_aliases WHERE workspace_id = ? AND alias = ?`, ws, alias).Scan(&created.Alias, &created.ProjectName, &created.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve alias: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &created, nil
+}
+
+// RemoveAlias deletes an alias of the named project, missing aliases are ignored
+func (s *SqliteStore) RemoveAlias(ctx context.Context, name string, alias string) error {
+	project, err := s.GetByName(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	ws, err := workspaces.ID(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, `DELETE FROM project_aliases WHERE workspace_id = ? AND alias = ? AND project_name = ?`, ws, alias, project.Name)
+	if err != nil {
+		return fmt.Errorf("failed to delete alias: %w", err)
+	}
+
 	return nil
 }
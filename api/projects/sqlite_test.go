@@ -0,0 +1,178 @@
+package projects
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/maybemaby/workpad/api/pagination"
+	"github.com/maybemaby/workpad/api/utils"
+	"github.com/maybemaby/workpad/api/workspaces"
+	"github.com/stretchr/testify/suite"
+	_ "modernc.org/sqlite"
+)
+
+type ProjectStoreSuite struct {
+	suite.Suite
+	db  *sql.DB
+	dbx *sqlx.DB
+}
+
+func (s *ProjectStoreSuite) SetupTest() {
+	s.db, _ = sql.Open("sqlite", ":memory:")
+	s.db.SetMaxOpenConns(1)
+	s.dbx = sqlx.NewDb(s.db, "sqlite")
+
+	err := utils.SetupSqliteDb(s.db)
+
+	if err != nil {
+		panic(err)
+	}
+
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+
+	s.dbx.MustExecContext(ctx, `INSERT INTO projects (name, created_at) VALUES
+		('Bravo', '2026-01-01 09:00:00'), ('Alpha', '2026-01-02 09:00:00'), ('Delta', '2026-01-02 09:00:00'),
+		('Charlie', '2026-01-03 09:00:00'), ('Echo', '2026-01-04 09:00:00')`)
+	s.dbx.MustExecContext(ctx, `INSERT INTO notes (id, html_content, note_date) VALUES (1, '', '2026-02-01'), (2, '', '2026-03-01')`)
+	s.dbx.MustExecContext(ctx, `INSERT INTO project_excerpts (project_name, note_id, excerpt, note_date) VALUES
+		('Bravo', 1, 'b', '2026-02-01'), ('Delta', 2, 'd', '2026-03-01')`)
+}
+
+func (s *ProjectStoreSuite) TearDownTest() {
+	s.db.Close()
+}
+
+// listAll pages through every project two at a time
+func (s *ProjectStoreSuite) listAll(sort string) []string {
+	store := NewSqliteStore(s.dbx)
+	opts := ListOptions{Sort: sort, Limit: 2}
+
+	var names []string
+
+	for {
+		page, next, err := store.List(workspaces.WithID(s.T().Context(), workspaces.DefaultID), opts)
+		s.Require().NoError(err)
+		s.LessOrEqual(len(page), 2)
+
+		for _, project := range page {
+			names = append(names, project.Name)
+		}
+
+		if next == "" {
+			return names
+		}
+
+		opts.Cursor = next
+	}
+}
+
+func (s *ProjectStoreSuite) TestList_Sorts() {
+	s.Equal([]string{"Alpha", "Bravo", "Charlie", "Delta", "Echo"}, s.listAll(SortName))
+	s.Equal([]string{"Echo", "Charlie", "Alpha", "Delta", "Bravo"}, s.listAll(SortCreatedAt))
+	// Projects never mentioned come last
+	s.Equal([]string{"Delta", "Bravo", "Alpha", "Charlie", "Echo"}, s.listAll(SortLastMentioned))
+}
+
+func (s *ProjectStoreSuite) TestList_PrefixAndCursor() {
+	store := NewSqliteStore(s.dbx)
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+
+	all, next, err := store.List(ctx, ListOptions{Prefix: "d"})
+	s.Require().NoError(err)
+	s.Empty(next)
+	s.Len(all, 1)
+	s.Equal("Delta", all[0].Name)
+
+	_, next, err = store.List(ctx, ListOptions{Sort: SortName, Limit: 1})
+	s.Require().NoError(err)
+
+	_, _, err = store.List(ctx, ListOptions{Sort: SortCreatedAt, Limit: 1, Cursor: next})
+	s.ErrorIs(err, pagination.ErrInvalidCursor)
+
+	_, _, err = store.List(ctx, ListOptions{Limit: 1, Cursor: "not a cursor"})
+	s.ErrorIs(err, pagination.ErrInvalidCursor)
+}
+
+func (s *ProjectStoreSuite) TestList_Activity() {
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+	today := time.Now()
+	daysAgo := func(days int) string {
+		return today.AddDate(0, 0, -days).Format(time.DateOnly)
+	}
+
+	s.dbx.MustExecContext(ctx, `INSERT INTO notes (id, html_content, note_date) VALUES (3, '', ?), (4, '', ?), (5, '', ?)`,
+		daysAgo(0), daysAgo(10), daysAgo(40))
+	s.dbx.MustExecContext(ctx, `INSERT INTO project_excerpts (project_name, note_id, excerpt, note_date) VALUES
+		('Echo', 3, 'e', ?), ('Echo', 4, 'e', ?), ('Charlie', 4, 'c', ?), ('Charlie', 5, 'c', ?)`,
+		daysAgo(0), daysAgo(10), daysAgo(10), daysAgo(40))
+
+	store := NewSqliteStore(s.dbx)
+
+	projects, _, err := store.List(ctx, ListOptions{Sort: SortName, Activity: true})
+	s.Require().NoError(err)
+	s.Require().Len(projects, 5)
+
+	echo := projects[4].Activity
+	s.Require().NotNil(echo)
+	s.Equal(daysAgo(10), *echo.FirstMentioned)
+	s.Equal(daysAgo(0), *echo.LastMentioned)
+	s.Equal(ProjectActivity{FirstMentioned: echo.FirstMentioned, LastMentioned: echo.LastMentioned, Mentions: 2, Mentions7d: 1, Mentions30d: 2}, *echo)
+
+	charlie := projects[2].Activity
+	s.Equal(2, charlie.Mentions)
+	s.Equal(0, charlie.Mentions7d)
+	s.Equal(1, charlie.Mentions30d)
+
+	// Never mentioned
+	s.Equal(ProjectActivity{}, *projects[0].Activity)
+
+	// Without the option activity stays out of the listing
+	projects, _, err = store.List(ctx, ListOptions{Sort: SortName})
+	s.Require().NoError(err)
+	s.Nil(projects[0].Activity)
+
+	// Echo and Charlie by mentions in the last 30 days, then by last mentioned
+	s.Equal([]string{"Echo", "Charlie", "Delta", "Bravo", "Alpha"}, s.listAll(SortActivity))
+}
+
+func (s *ProjectStoreSuite) TestWorkspaces_Isolated() {
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+	store := NewSqliteStore(s.dbx)
+
+	s.dbx.MustExecContext(ctx, `INSERT INTO workspaces (id, name) VALUES (2, 'Team')`)
+	team := workspaces.WithID(ctx, 2)
+
+	_, err := store.GetByName(team, "Alpha")
+	s.Error(err)
+
+	// Names are unique per workspace, so the team gets its own Alpha
+	_, err = store.Create(team, "Alpha")
+	s.Require().NoError(err)
+	_, err = store.AddAlias(team, "Alpha", "Bravo")
+	s.Require().NoError(err)
+
+	projects, _, err := store.List(team, ListOptions{})
+	s.Require().NoError(err)
+	s.Len(projects, 1)
+
+	// Renames and deletes leave the default workspace alone
+	_, err = store.Rename(team, "Alpha", "Zulu")
+	s.Require().NoError(err)
+	s.Require().NoError(store.DeleteByName(team, "Zulu"))
+
+	project, err := store.GetByName(ctx, "Alpha")
+	s.Require().NoError(err)
+	s.Equal("Alpha", project.Name)
+
+	project, err = store.GetByName(ctx, "Bravo")
+	s.Require().NoError(err)
+	s.Equal("Bravo", project.Name)
+
+	s.Len(s.listAll(SortName), 5)
+}
+
+func TestProjectStoreSuite(t *testing.T) {
+	suite.Run(t, new(ProjectStoreSuite))
+}
@@ -1,6 +1,11 @@
 package projects
 
-import "context"
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
 
 // ProjectStore defines the interface for project data operations
 type ProjectStore interface {
@@ -11,12 +16,49 @@ type ProjectStore interface {
 	// Returns a slice of created projects or an error if any insertion fails
 	CreateMultiple(ctx context.Context, names []string) ([]Project, error)
 
-	// GetByName retrieves a project by its name
+	// GetByName retrieves a project by its name or one of its aliases
 	GetByName(ctx context.Context, name string) (*Project, error)
 
 	// GetAll retrieves all projects ordered by creation date (newest first)
 	// If namePrefix is not empty, filters projects by name prefix (case-insensitive)
 	GetAll(ctx context.Context, namePrefix string) ([]Project, error)
 
+	// List returns a page of projects and the cursor of the next page, empty on the last page
+	// Returns pagination.ErrInvalidCursor for a cursor from another sort
+	List(ctx context.Context, opts ListOptions) ([]Project, string, error)
+
 	DeleteByName(ctx context.Context, name string) error
+
+	// SetParent moves a project under parentName, or to the top level when parentName is nil
+	SetParent(ctx context.Context, name string, parentName *string) (*Project, error)
+
+	// SetTargetDate sets the date a project is due to finish, or clears it when targetDate is nil
+	SetTargetDate(ctx context.Context, name string, targetDate *time.Time) (*Project, error)
+
+	// Rename changes a project's name and every reference to it
+	Rename(ctx context.Context, name string, newName string) (*Project, error)
+
+	// GetAliases lists the aliases of a project
+	GetAliases(ctx context.Context, name string) ([]ProjectAlias, error)
+
+	// AddAlias makes alias resolve to the named project
+	AddAlias(ctx context.Context, name string, alias string) (*ProjectAlias, error)
+
+	// RemoveAlias deletes an alias of the named project
+	RemoveAlias(ctx context.Context, name string, alias string) error
+}
+
+// Events is told about project changes inside the transaction making them, so whatever it records
+// commits or rolls back with the change. An error fails the change
+type Events interface {
+	ProjectCreated(ctx context.Context, tx *sqlx.Tx, project Project) error
+	ProjectDeleted(ctx context.Context, tx *sqlx.Tx, name string) error
+	ProjectRenamed(ctx context.Context, tx *sqlx.Tx, oldName string, project Project) error
 }
+
+// noEvents is the Events of a store nothing listens to
+type noEvents struct{}
+
+func (noEvents) ProjectCreated(context.Context, *sqlx.Tx, Project) error         { return nil }
+func (noEvents) ProjectDeleted(context.Context, *sqlx.Tx, string) error          { return nil }
+func (noEvents) ProjectRenamed(context.Context, *sqlx.Tx, string, Project) error { return nil }
@@ -0,0 +1,39 @@
+package projects
+
+// BuildTree nests projects under their parents, keeping the input order among siblings
+// Projects whose parent is not in the list become roots
+func BuildTree(projects []Project) []ProjectNode {
+	present := make(map[string]bool, len(projects))
+	for _, project := range projects {
+		present[project.Name] = true
+	}
+
+	children := make(map[string][]Project)
+	roots := []Project{}
+
+	for _, project := range projects {
+		if project.ParentName != nil && present[*project.ParentName] {
+			children[*project.ParentName] = append(children[*project.ParentName], project)
+		} else {
+			roots = append(roots, project)
+		}
+	}
+
+	var build func(project Project) ProjectNode
+	build = func(project Project) ProjectNode {
+		node := ProjectNode{Project: project}
+
+		for _, child := range children[project.Name] {
+			node.Children = append(node.Children, build(child))
+		}
+
+		return node
+	}
+
+	nodes := make([]ProjectNode, 0, len(roots))
+	for _, root := range roots {
+		nodes = append(nodes, build(root))
+	}
+
+	return nodes
+}
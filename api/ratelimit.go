@@ -0,0 +1,209 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/justinas/alice"
+	"github.com/maybemaby/workpad/api/utils"
+	"github.com/maybemaby/workpad/api/workspaces"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+type RateLimitConfig struct {
+	// RequestsPerMinute is the sustained rate each client may send, 0 disables rate limiting
+	RequestsPerMinute int `yaml:"requests_per_minute" env:"RATE_LIMIT_PER_MINUTE"`
+	// Burst is how many requests a client may send at once after being idle
+	Burst int `yaml:"burst" env:"RATE_LIMIT_BURST"`
+	// TrustProxy keys clients by the first X-Forwarded-For address and builds feed links from the
+	// X-Forwarded-Proto and X-Forwarded-Host headers, only enable it behind a proxy that sets them
+	TrustProxy bool `yaml:"trust_proxy" env:"RATE_LIMIT_TRUST_PROXY"`
+}
+
+func (c RateLimitConfig) Enabled() bool {
+	return c.RequestsPerMinute > 0
+}
+
+// bucket holds a client's tokens as of updated, refilled lazily when the client returns
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// RateLimiter is a token bucket per client, keyed by the client's user or else its IP address
+type RateLimiter struct {
+	cfg   RateLimitConfig
+	rate  float64 // tokens per second
+	burst float64
+	now   func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+
+	requests metric.Int64Counter
+}
+
+func NewRateLimiter(cfg RateLimitConfig) (*RateLimiter, error) {
+	limiter := &RateLimiter{
+		cfg:     cfg,
+		rate:    float64(cfg.RequestsPerMinute) / 60,
+		burst:   float64(cfg.Burst),
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+
+	meter := otel.Meter("github.com/maybemaby/workpad/api")
+
+	requests, err := meter.Int64Counter("workpad.ratelimit.requests",
+		metric.WithDescription("API requests checked by the rate limiter, by result"),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	limiter.requests = requests
+
+	_, err = meter.Int64ObservableGauge("workpad.ratelimit.clients",
+		metric.WithDescription("Clients with a partly drained token bucket"),
+		metric.WithUnit("{client}"),
+		metric.WithInt64Callback(func(ctx context.Context, o metric.Int64Observer) error {
+			o.Observe(int64(limiter.Clients()))
+			return nil
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return limiter, nil
+}
+
+// Allow takes a token from key's bucket, or reports how long until one is available
+func (l *RateLimiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, updated: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = min(l.burst, b.tokens+now.Sub(b.updated).Seconds()*l.rate)
+	b.updated = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+}
+
+// Clients returns the number of buckets being tracked
+func (l *RateLimiter) Clients() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return len(l.buckets)
+}
+
+// Prune forgets clients whose buckets have refilled, they start over with a full bucket anyway
+func (l *RateLimiter) Prune() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	removed := 0
+
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.updated).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+			removed++
+		}
+	}
+
+	return removed
+}
+
+// clientKey identifies the client by the user workspaces.Authenticate verified, or by its IP address
+// Unverified tokens are not used, every made-up token would otherwise get a fresh bucket
+func (l *RateLimiter) clientKey(r *http.Request) string {
+	if user := workspaces.UserFrom(r.Context()); user != nil {
+		return "user:" + strconv.FormatInt(user.Id, 10)
+	}
+
+	if l.cfg.TrustProxy {
+		if forwarded, _, _ := strings.Cut(r.Header.Get("X-Forwarded-For"), ","); strings.TrimSpace(forwarded) != "" {
+			return "ip:" + strings.TrimSpace(forwarded)
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	return "ip:" + host
+}
+
+// Middleware rejects requests over the client's rate with 429 and a Retry-After header
+func (l *RateLimiter) Middleware() alice.Constructor {
+	return func(next http.Handler) http.Handler {
+		if !l.cfg.Enabled() {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			allowed, retryAfter := l.Allow(l.clientKey(r))
+
+			result := "allowed"
+			if !allowed {
+				result = "limited"
+			}
+
+			l.requests.Add(r.Context(), 1, metric.WithAttributes(attribute.String("result", result)))
+
+			if !allowed {
+				seconds := int(math.Ceil(retryAfter.Seconds()))
+
+				w.Header().Set("Retry-After", strconv.Itoa(seconds))
+				utils.ErrorJSON(w, utils.ErrorResponse{
+					Message: fmt.Sprintf("Too many requests, retry in %d seconds", seconds),
+					Status:  http.StatusTooManyRequests,
+				}, http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// MaxBodySize bounds request bodies to limit bytes. Bodies declared larger are rejected with
+// 413 up front, streamed bodies fail to read once over the limit and handlers respond 413
+func MaxBodySize(limit int64) alice.Constructor {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > limit {
+				utils.WriteTooLarge(w, limit)
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
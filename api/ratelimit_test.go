@@ -0,0 +1,122 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maybemaby/workpad/api/utils"
+	"github.com/maybemaby/workpad/api/workspaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter_Allow(t *testing.T) {
+	limiter, err := NewRateLimiter(RateLimitConfig{RequestsPerMinute: 60, Burst: 2})
+	require.NoError(t, err)
+
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+
+	ok, _ := limiter.Allow("a")
+	assert.True(t, ok)
+	ok, _ = limiter.Allow("a")
+	assert.True(t, ok)
+
+	ok, retryAfter := limiter.Allow("a")
+	assert.False(t, ok)
+	assert.Equal(t, time.Second, retryAfter)
+
+	// Other clients have their own bucket
+	ok, _ = limiter.Allow("b")
+	assert.True(t, ok)
+
+	now = now.Add(time.Second)
+	ok, _ = limiter.Allow("a")
+	assert.True(t, ok)
+
+	now = now.Add(time.Minute)
+	assert.Equal(t, 2, limiter.Prune())
+	assert.Equal(t, 0, limiter.Clients())
+}
+
+func TestRateLimiter_Middleware(t *testing.T) {
+	limiter, err := NewRateLimiter(RateLimitConfig{RequestsPerMinute: 1, Burst: 1})
+	require.NoError(t, err)
+
+	handler := limiter.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// user stands in for the user workspaces.Authenticate verified the token of
+	send := func(token string, user *workspaces.User, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/projects", nil)
+		req.RemoteAddr = remoteAddr
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if user != nil {
+			req = req.WithContext(workspaces.WithUser(req.Context(), user))
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, send("", nil, "10.0.0.1:5000").Code)
+
+	w := send("", nil, "10.0.0.1:5001")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var body utils.ErrorResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+	assert.Equal(t, http.StatusTooManyRequests, body.Status)
+
+	// Unverified tokens share their address's bucket, made-up tokens get no fresh one
+	assert.Equal(t, http.StatusTooManyRequests, send("made-up", nil, "10.0.0.1:5002").Code)
+
+	// A verified user is their own client, wherever they connect from
+	ada := &workspaces.User{Id: 1, Name: "ada"}
+	assert.Equal(t, http.StatusOK, send("ada-token", ada, "10.0.0.1:5003").Code)
+	assert.Equal(t, http.StatusTooManyRequests, send("ada-token", ada, "10.0.0.2:5000").Code)
+
+	disabled, err := NewRateLimiter(RateLimitConfig{})
+	require.NoError(t, err)
+
+	handler = disabled.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for range 5 {
+		assert.Equal(t, http.StatusOK, send("", nil, "10.0.0.3:5000").Code)
+	}
+}
+
+func TestMaxBodySize(t *testing.T) {
+	handler := MaxBodySize(16)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			utils.WriteBodyError(w, err)
+			return
+		}
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/notes", strings.NewReader(`{"text":"short"}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/notes", strings.NewReader(strings.Repeat("a", 17))))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Contains(t, w.Body.String(), "limit of 16 bytes")
+
+	// Streamed bodies have no length, so they are cut off while the handler reads them
+	req := httptest.NewRequest(http.MethodPost, "/api/notes", io.NopCloser(strings.NewReader(strings.Repeat("a", 17))))
+	req.ContentLength = -1
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+}
@@ -0,0 +1,41 @@
+package reminders
+
+import "sync"
+
+// Broker fans reminders out to subscribed event streams
+// Slow subscribers miss events rather than blocking the scheduler
+type Broker struct {
+	mu          sync.Mutex
+	subscribers map[chan Reminder]struct{}
+}
+
+func NewBroker() *Broker {
+	return &Broker{subscribers: make(map[chan Reminder]struct{})}
+}
+
+// Subscribe returns a channel receiving published reminders and a func to stop receiving them
+func (b *Broker) Subscribe() (<-chan Reminder, func()) {
+	ch := make(chan Reminder, 16)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		delete(b.subscribers, ch)
+		b.mu.Unlock()
+	}
+}
+
+func (b *Broker) Publish(reminder Reminder) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- reminder:
+		default:
+		}
+	}
+}
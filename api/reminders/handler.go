@@ -0,0 +1,174 @@
+package reminders
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/maybemaby/workpad/api/tz"
+	"github.com/maybemaby/workpad/api/utils"
+	"github.com/maybemaby/workpad/api/workspaces"
+)
+
+// keepAliveInterval keeps idle event streams from being closed by proxies
+const keepAliveInterval = 30 * time.Second
+
+type ReminderHandler struct {
+	store  ReminderStore
+	broker *Broker
+}
+
+func NewReminderHandler(store ReminderStore, broker *Broker) *ReminderHandler {
+	return &ReminderHandler{store: store, broker: broker}
+}
+
+func parseId(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid reminder id", http.StatusBadRequest)
+		return 0, false
+	}
+
+	return id, true
+}
+
+func (h *ReminderHandler) CreateReminder(w http.ResponseWriter, r *http.Request) {
+	var req CreateReminderRequest
+
+	if err := utils.ReadJSON(r, &req); err != nil {
+		utils.WriteBodyError(w, err)
+		return
+	}
+
+	reminder, err := h.store.Create(r.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidReminder):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, ErrNoteNotFound), errors.Is(err, ErrProjectNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	utils.WriteJSON(w, r, reminder)
+}
+
+func (h *ReminderHandler) ListDue(w http.ResponseWriter, r *http.Request) {
+	on := tz.Today(r.Context())
+
+	if v := r.URL.Query().Get("on"); v != "" {
+		parsed, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			http.Error(w, "Invalid on date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		on = parsed
+	}
+
+	reminders, err := h.store.ListDue(r.Context(), on)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	utils.WriteJSON(w, r, reminders)
+}
+
+func (h *ReminderHandler) CompleteReminder(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseId(w, r)
+	if !ok {
+		return
+	}
+
+	reminder, err := h.store.Complete(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Reminder not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	utils.WriteJSON(w, r, reminder)
+}
+
+func (h *ReminderHandler) DeleteReminder(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseId(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.store.Delete(r.Context(), id); err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// StreamEvents handles GET /reminders/events
+// Sends a "reminder" server-sent event each time a reminder of the request's workspace comes due
+func (h *ReminderHandler) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	workspace, err := workspaces.ID(r.Context())
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	events, unsubscribe := h.broker.Subscribe()
+	defer unsubscribe()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case reminder := <-events:
+			if reminder.WorkspaceId != workspace {
+				continue
+			}
+
+			data, err := json.Marshal(reminder)
+			if err != nil {
+				continue
+			}
+
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: reminder\ndata: %s\n\n", reminder.Id, data); err != nil {
+				return
+			}
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package reminders
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	SourceManual   = "manual"
+	SourceDetected = "detected"
+)
+
+var (
+	ErrNoteNotFound    = errors.New("note not found")
+	ErrProjectNotFound = errors.New("project not found")
+	ErrInvalidReminder = errors.New("invalid reminder")
+)
+
+type Reminder struct {
+	Id          int        `json:"id" required:"true"`
+	NoteId      int        `json:"note_id" required:"true" db:"note_id"`
+	WorkspaceId int64      `json:"-" db:"workspace_id"`
+	NoteDate    string     `json:"note_date" required:"true" db:"note_date" example:"2026-10-19"`
+	ProjectName *string    `json:"project_name,omitempty" required:"false" db:"project_name"`
+	Text        string     `json:"text" required:"true" example:"@OKT123 follow up with vendor by 2026-11-02"`
+	DueDate     string     `json:"due_date" required:"true" db:"due_date" example:"2026-11-02"`
+	Source      string     `json:"source" required:"true" enum:"manual,detected"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" required:"false" db:"completed_at"`
+	FiredAt     *time.Time `json:"fired_at,omitempty" required:"false" db:"fired_at"`
+	CreatedAt   time.Time  `json:"created_at" required:"true" db:"created_at"`
+}
+
+type CreateReminderRequest struct {
+	NoteDate    string  `json:"note_date" required:"true" example:"2026-10-19" format:"date"`
+	ProjectName *string `json:"project_name,omitempty" required:"false" example:"OKT123"`
+	Text        string  `json:"text" required:"true" example:"Follow up with vendor" maxLength:"1000"`
+	DueDate     string  `json:"due_date" required:"true" example:"2026-11-02" format:"date"`
+}
+
+type ListDueRequest struct {
+	// On defaults to today
+	On string `query:"on" example:"2026-11-02" required:"false" format:"date"`
+}
+
+type ReminderIdRequest struct {
+	Id int `path:"id" example:"1" required:"true" minimum:"1"`
+}
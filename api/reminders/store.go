@@ -0,0 +1,227 @@
+package reminders
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/maybemaby/workpad/api/workspaces"
+)
+
+// Reminders belong to the workspace of their note
+const selectReminder = `SELECT r.id, r.note_id, n.workspace_id, date(n.note_date) AS note_date, r.project_name, r.text, date(r.due_date) AS due_date,
+	r.source, r.completed_at, r.fired_at, r.created_at
+	FROM reminders r JOIN notes n ON n.id = r.note_id`
+
+// inWorkspace limits statements on reminders to those of the workspace given as its arg
+const inWorkspace = `note_id IN (SELECT id FROM notes WHERE workspace_id = ?)`
+
+type ReminderStore interface {
+	// Create adds a manual reminder to the note for the request's date
+	Create(ctx context.Context, req CreateReminderRequest) (*Reminder, error)
+
+	// ListDue returns open reminders due on or before date, oldest first
+	ListDue(ctx context.Context, date time.Time) ([]Reminder, error)
+
+	// ListOpen returns reminders not yet completed, optionally only those for projectName
+	ListOpen(ctx context.Context, projectName string) ([]Reminder, error)
+
+	// Complete marks a reminder done so it is no longer listed or fired
+	Complete(ctx context.Context, id int) (*Reminder, error)
+
+	Delete(ctx context.Context, id int) error
+
+	// FireDue marks open reminders due on or before date as fired and returns them
+	// Each reminder is only returned by one call, reminders of every workspace are fired
+	FireDue(ctx context.Context, date time.Time) ([]Reminder, error)
+}
+
+type ReminderService struct {
+	db *sqlx.DB
+}
+
+func NewReminderService(db *sqlx.DB) *ReminderService {
+	return &ReminderService{db: db}
+}
+
+func (s *ReminderService) get(ctx context.Context, id int) (*Reminder, error) {
+	var reminder Reminder
+
+	ws, err := workspaces.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.GetContext(ctx, &reminder, selectReminder+` WHERE n.workspace_id = ? AND r.id = ?`, ws, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get reminder: %w", err)
+	}
+
+	return &reminder, nil
+}
+
+func (s *ReminderService) Create(ctx context.Context, req CreateReminderRequest) (*Reminder, error) {
+	req.Text = strings.TrimSpace(req.Text)
+
+	if req.Text == "" {
+		return nil, errors.Join(ErrInvalidReminder, errors.New("text is required"))
+	}
+
+	if _, err := time.Parse(time.DateOnly, req.DueDate); err != nil {
+		return nil, errors.Join(ErrInvalidReminder, errors.New("due_date must be YYYY-MM-DD"))
+	}
+
+	ws, err := workspaces.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var noteId int
+
+	err = s.db.GetContext(ctx, &noteId, `SELECT id FROM notes WHERE workspace_id = ? AND date(note_date) = ?`, ws, req.NoteDate)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoteNotFound
+		}
+		return nil, fmt.Errorf("failed to get note: %w", err)
+	}
+
+	var projectName *string
+
+	if req.ProjectName != nil && *req.ProjectName != "" {
+		var canonical string
+
+		err := s.db.GetContext(ctx, &canonical, `SELECT name FROM projects WHERE workspace_id = ? AND name = COALESCE((SELECT project_name FROM project_aliases WHERE workspace_id = ? AND alias = ?), ?)`,
+			ws, ws, *req.ProjectName, *req.ProjectName)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, ErrProjectNotFound
+			}
+			return nil, fmt.Errorf("failed to get project: %w", err)
+		}
+
+		projectName = &canonical
+	}
+
+	var id int
+
+	err = s.db.QueryRowContext(ctx, `INSERT INTO reminders (note_id, project_name, text, due_date, source) VALUES (?, ?, ?, ?, ?) RETURNING id`,
+		noteId, projectName, req.Text, req.DueDate, SourceManual,
+	).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create reminder: %w", err)
+	}
+
+	return s.get(ctx, id)
+}
+
+func (s *ReminderService) ListDue(ctx context.Context, date time.Time) ([]Reminder, error) {
+	reminders := []Reminder{}
+
+	ws, err := workspaces.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.SelectContext(ctx, &reminders, selectReminder+` WHERE n.workspace_id = ? AND r.completed_at IS NULL AND date(r.due_date) <= ? ORDER BY r.due_date, r.id`,
+		ws, date.Format(time.DateOnly))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due reminders: %w", err)
+	}
+
+	return reminders, nil
+}
+
+func (s *ReminderService) ListOpen(ctx context.Context, projectName string) ([]Reminder, error) {
+	reminders := []Reminder{}
+
+	ws, err := workspaces.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := selectReminder + ` WHERE n.workspace_id = ? AND r.completed_at IS NULL`
+	args := []any{ws}
+
+	if projectName != "" {
+		query += ` AND LOWER(r.project_name) = LOWER(COALESCE((SELECT project_name FROM project_aliases WHERE workspace_id = ? AND alias = ?), ?))`
+		args = append(args, ws, projectName, projectName)
+	}
+
+	err = s.db.SelectContext(ctx, &reminders, query+` ORDER BY r.due_date, r.id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list open reminders: %w", err)
+	}
+
+	return reminders, nil
+}
+
+func (s *ReminderService) Complete(ctx context.Context, id int) (*Reminder, error) {
+	ws, err := workspaces.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.db.ExecContext(ctx, `UPDATE reminders SET completed_at = COALESCE(completed_at, CURRENT_TIMESTAMP) WHERE id = ? AND `+inWorkspace, id, ws)
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete reminder: %w", err)
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return s.get(ctx, id)
+}
+
+func (s *ReminderService) Delete(ctx context.Context, id int) error {
+	ws, err := workspaces.ID(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, `DELETE FROM reminders WHERE id = ? AND `+inWorkspace, id, ws)
+	if err != nil {
+		return fmt.Errorf("failed to delete reminder: %w", err)
+	}
+
+	return nil
+}
+
+func (s *ReminderService) FireDue(ctx context.Context, date time.Time) ([]Reminder, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	var due []Reminder
+
+	err = tx.SelectContext(ctx, &due, selectReminder+` WHERE r.completed_at IS NULL AND r.fired_at IS NULL AND date(r.due_date) <= ? ORDER BY r.due_date, r.id`, date.Format(time.DateOnly))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find due reminders: %w", err)
+	}
+
+	now := time.Now().UTC()
+
+	for i := range due {
+		if _, err := tx.ExecContext(ctx, `UPDATE reminders SET fired_at = ? WHERE id = ?`, now, due[i].Id); err != nil {
+			return nil, fmt.Errorf("failed to mark reminder fired: %w", err)
+		}
+
+		due[i].FiredAt = &now
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return due, nil
+}
@@ -0,0 +1,150 @@
+package reminders
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/maybemaby/workpad/api/notes"
+	"github.com/maybemaby/workpad/api/utils"
+	"github.com/maybemaby/workpad/api/workspaces"
+	"github.com/stretchr/testify/suite"
+	_ "modernc.org/sqlite"
+)
+
+const taskNote = `<ul data-type="taskList">` +
+	`<li data-type="taskItem" data-checked="false"><label><input type="checkbox"></label><div><p><span data-type="mention" data-id="OKT123">@OKT123</span> follow up with vendor by 2026-11-02</p></div></li>` +
+	`<li data-type="taskItem" data-checked="true"><label><input type="checkbox" checked></label><div><p>send invoice 2026-10-20</p></div></li>` +
+	`<li data-type="taskItem" data-checked="false"><label><input type="checkbox"></label><div><p>no date here</p></div></li>` +
+	`</ul>`
+
+func mustParseDate(value string) time.Time {
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		panic(err)
+	}
+
+	return t
+}
+
+type ReminderStoreSuite struct {
+	suite.Suite
+	db    *sql.DB
+	dbx   *sqlx.DB
+	notes *notes.NoteService
+	store *ReminderService
+}
+
+func (s *ReminderStoreSuite) SetupTest() {
+	s.db, _ = sql.Open("sqlite", ":memory:")
+	s.db.SetMaxOpenConns(1)
+	s.dbx = sqlx.NewDb(s.db, "sqlite")
+
+	err := utils.SetupSqliteDb(s.db)
+
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = s.db.Exec("INSERT INTO projects (name) VALUES ('OKT123')")
+
+	if err != nil {
+		panic(err)
+	}
+
+	s.notes = notes.NewNoteService(s.dbx)
+	s.store = NewReminderService(s.dbx)
+}
+
+func (s *ReminderStoreSuite) TearDownTest() {
+	s.db.Close()
+}
+
+func (s *ReminderStoreSuite) TestCreateNote_DetectsTaskReminders() {
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+
+	_, err := s.notes.CreateNote(ctx, taskNote, mustParseDate("2026-10-19"))
+	s.Require().NoError(err)
+
+	due, err := s.store.ListDue(ctx, mustParseDate("2026-12-31"))
+	s.Require().NoError(err)
+	s.Require().Len(due, 1)
+
+	s.Equal("@OKT123 follow up with vendor by 2026-11-02", due[0].Text)
+	s.Equal("2026-11-02", due[0].DueDate)
+	s.Equal("2026-10-19", due[0].NoteDate)
+	s.Equal(SourceDetected, due[0].Source)
+	s.Require().NotNil(due[0].ProjectName)
+	s.Equal("OKT123", *due[0].ProjectName)
+
+	notYetDue, err := s.store.ListDue(ctx, mustParseDate("2026-11-01"))
+	s.Require().NoError(err)
+	s.Empty(notYetDue)
+}
+
+func (s *ReminderStoreSuite) TestCreateNote_KeepsFiredReminders() {
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+
+	_, err := s.notes.CreateNote(ctx, taskNote, mustParseDate("2026-10-19"))
+	s.Require().NoError(err)
+
+	fired, err := s.store.FireDue(ctx, mustParseDate("2026-11-02"))
+	s.Require().NoError(err)
+	s.Require().Len(fired, 1)
+
+	// Saving the same content must not make the reminder fire again
+	_, err = s.notes.CreateNote(ctx, taskNote, mustParseDate("2026-10-19"))
+	s.Require().NoError(err)
+
+	fired, err = s.store.FireDue(ctx, mustParseDate("2026-11-02"))
+	s.Require().NoError(err)
+	s.Empty(fired)
+
+	// Checking the task off removes the detected reminder
+	_, err = s.notes.CreateNote(ctx, `<ul data-type="taskList"><li data-type="taskItem" data-checked="true"><p>follow up with vendor by 2026-11-02</p></li></ul>`, mustParseDate("2026-10-19"))
+	s.Require().NoError(err)
+
+	due, err := s.store.ListDue(ctx, mustParseDate("2026-12-31"))
+	s.Require().NoError(err)
+	s.Empty(due)
+}
+
+func (s *ReminderStoreSuite) TestCreate_Manual() {
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+
+	_, err := s.notes.CreateNote(ctx, "<p>Vendor call</p>", mustParseDate("2026-10-19"))
+	s.Require().NoError(err)
+
+	project := "OKT123"
+
+	reminder, err := s.store.Create(ctx, CreateReminderRequest{
+		NoteDate:    "2026-10-19",
+		ProjectName: &project,
+		Text:        "Chase contract",
+		DueDate:     "2026-10-25",
+	})
+	s.Require().NoError(err)
+	s.Equal(SourceManual, reminder.Source)
+
+	_, err = s.store.Create(ctx, CreateReminderRequest{NoteDate: "2026-10-18", Text: "Missing note", DueDate: "2026-10-25"})
+	s.ErrorIs(err, ErrNoteNotFound)
+
+	_, err = s.store.Create(ctx, CreateReminderRequest{NoteDate: "2026-10-19", Text: "Bad date", DueDate: "soon"})
+	s.ErrorIs(err, ErrInvalidReminder)
+
+	completed, err := s.store.Complete(ctx, reminder.Id)
+	s.Require().NoError(err)
+	s.NotNil(completed.CompletedAt)
+
+	fired, err := s.store.FireDue(ctx, mustParseDate("2026-10-25"))
+	s.Require().NoError(err)
+	s.Empty(fired)
+
+	_, err = s.store.Complete(ctx, reminder.Id+100)
+	s.ErrorIs(err, sql.ErrNoRows)
+}
+
+func TestReminderStoreSuite(t *testing.T) {
+	suite.Run(t, new(ReminderStoreSuite))
+}
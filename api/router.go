@@ -8,8 +8,20 @@ import (
 	"os"
 	"strings"
 
+	"github.com/justinas/alice"
+	"github.com/maybemaby/workpad/api/attachments"
+	"github.com/maybemaby/workpad/api/calendar"
+	"github.com/maybemaby/workpad/api/feeds"
 	"github.com/maybemaby/workpad/api/notes"
 	"github.com/maybemaby/workpad/api/projects"
+	"github.com/maybemaby/workpad/api/reminders"
+	"github.com/maybemaby/workpad/api/stats"
+	"github.com/maybemaby/workpad/api/timeentries"
+	"github.com/maybemaby/workpad/api/tz"
+	"github.com/maybemaby/workpad/api/utils"
+	"github.com/maybemaby/workpad/api/validate"
+	"github.com/maybemaby/workpad/api/webhooks"
+	"github.com/maybemaby/workpad/api/workspaces"
 	"github.com/maybemaby/workpad/frontend"
 	"github.com/oaswrap/spec-ui/config"
 	"github.com/oaswrap/spec/adapter/httpopenapi"
@@ -21,13 +33,27 @@ func httpSpanName(operation string, r *http.Request) string {
 	return fmt.Sprintf("HTTP %s %s", r.Method, r.URL.Path)
 }
 
+// HandleRequest registers a route whose requests are validated against prototype before handler
+// runs, and documents prototype as the route's request so the spec and the validation cannot drift apart
+func (t *RouteTable) HandleRequest(pattern string, chain alice.Chain, prototype any, handler http.HandlerFunc) httpopenapi.Route {
+	return t.Handle(pattern, chain.Append(validate.Request(prototype)).Then(handler)).With(option.Request(prototype))
+}
+
 func (s *Server) MountRoutesOapi() {
 
 	mux := http.NewServeMux()
 
-	rootMw := RootMiddleware(s.logger, MiddlewareConfig{
-		CorsOrigin: "http://localhost:5173",
-	})
+	rootMw := RootMiddleware(s.logger)
+
+	workspaceStore := workspaces.NewSqliteStore(s.sqliteDB)
+
+	// API routes are rate limited per user, or per address before a token is verified, dated in the
+	// client's zone and work in the user's workspace, uploads bound their own bodies. Feeds take their
+	// token and workspace from the query string, feed readers cannot send headers
+	clientMw := rootMw.Append(workspaces.Authenticate(workspaceStore), s.limiter.Middleware(), tz.Middleware(s.location))
+	uploadMw := clientMw.Append(workspaces.Middleware(workspaceStore))
+	apiMw := uploadMw.Append(MaxBodySize(int64(s.cfg.MaxBodyBytes)))
+	feedMw := clientMw.Append(MaxBodySize(int64(s.cfg.MaxBodyBytes)), workspaces.FeedMiddleware(workspaceStore, s.cfg.FeedToken))
 
 	r := httpopenapi.NewGenerator(mux,
 		option.WithTitle("workpad"),
@@ -60,91 +86,309 @@ func (s *Server) MountRoutesOapi() {
 		})
 	}
 
-	apiRoute := r.Group("/api")
+	s.spec = r
+
+	// Routes are recorded so CORS preflight requests can be answered with each path's methods
+	apiRoute := NewRouteTable("/api", r.Group("/api"))
 
 	// Projects routes
-	projectsStore := projects.NewSqliteStore(s.sqliteDB)
+	projectsStore := projects.NewSqliteStore(s.sqliteDB).WithEvents(s.services.webhooks)
 	projectsHandler := projects.NewHandler(projectsStore)
 
-	apiRoute.Handle("POST /projects", rootMw.ThenFunc(projectsHandler.CreateProject)).With(
-		option.Request(new(projects.CreateProjectRequest)),
+	apiRoute.HandleRequest("POST /projects", apiMw, new(projects.CreateProjectRequest), projectsHandler.CreateProject).With(
 		option.Response(201, new(projects.Project)),
 		option.Tags("Projects"),
 	)
 
-	apiRoute.Handle("GET /projects", rootMw.ThenFunc(projectsHandler.ListProjects)).With(
-		option.Request(new(projects.ListProjectsRequest)),
-		option.Response(200, new([]projects.Project)),
+	apiRoute.HandleRequest("GET /projects", apiMw, new(projects.ListProjectsRequest), projectsHandler.ListProjects).With(
+		option.Response(200, new(projects.ProjectPage)),
+		option.Response(400, "Bad Request"),
 		option.Tags("Projects"),
 	)
 
-	apiRoute.Handle("GET /projects/{name}", rootMw.ThenFunc(projectsHandler.GetProject)).With(
-		option.Request(new(projects.GetProjectRequest)),
+	apiRoute.HandleRequest("GET /projects/{name}", apiMw, new(projects.GetProjectRequest), projectsHandler.GetProject).With(
 		option.Response(200, new(projects.Project)),
 		option.Response(404, "Not Found"),
 		option.Tags("Projects"),
 	)
 
-	apiRoute.Handle("POST /projects/batch", rootMw.ThenFunc(projectsHandler.CreateMultipleProjects)).With(
-		option.Request(new(projects.CreateMultipleProjectsRequest)),
+	apiRoute.HandleRequest("POST /projects/batch", apiMw, new(projects.CreateMultipleProjectsRequest), projectsHandler.CreateMultipleProjects).With(
 		option.Response(201, new([]projects.Project)),
 		option.Tags("Projects"),
 	)
 
-	apiRoute.Handle("DELETE /projects/{name}", rootMw.ThenFunc(projectsHandler.DeleteProject)).With(
-		option.Request(new(projects.GetProjectRequest)),
+	apiRoute.HandleRequest("DELETE /projects/{name}", apiMw, new(projects.GetProjectRequest), projectsHandler.DeleteProject).With(
+		option.Response(204, nil),
+		option.Tags("Projects"),
+	)
+
+	apiRoute.HandleRequest("PUT /projects/{name}/parent", apiMw, new(projects.SetParentRequest), projectsHandler.SetParent).With(
+		option.Response(200, new(projects.Project)),
+		option.Response(404, "Not Found"),
+		option.Response(409, "Conflict"),
+		option.Tags("Projects"),
+	)
+
+	apiRoute.HandleRequest("PUT /projects/{name}/name", apiMw, new(projects.RenameProjectRequest), projectsHandler.RenameProject).With(
+		option.Response(200, new(projects.Project)),
+		option.Response(400, "Bad Request"),
+		option.Response(404, "Not Found"),
+		option.Response(409, "Conflict"),
+		option.Tags("Projects"),
+	)
+
+	apiRoute.HandleRequest("PUT /projects/{name}/target-date", apiMw, new(projects.SetTargetDateRequest), projectsHandler.SetTargetDate).With(
+		option.Response(200, new(projects.Project)),
+		option.Response(400, "Bad Request"),
+		option.Response(404, "Not Found"),
+		option.Tags("Projects"),
+	)
+
+	apiRoute.HandleRequest("GET /projects/{name}/aliases", apiMw, new(projects.GetProjectRequest), projectsHandler.ListAliases).With(
+		option.Response(200, new([]projects.ProjectAlias)),
+		option.Response(404, "Not Found"),
+		option.Tags("Projects"),
+	)
+
+	apiRoute.HandleRequest("POST /projects/{name}/aliases", apiMw, new(projects.AddAliasRequest), projectsHandler.AddAlias).With(
+		option.Response(201, new(projects.ProjectAlias)),
+		option.Response(404, "Not Found"),
+		option.Response(409, "Conflict"),
+		option.Tags("Projects"),
+	)
+
+	apiRoute.HandleRequest("DELETE /projects/{name}/aliases/{alias}", apiMw, new(projects.RemoveAliasRequest), projectsHandler.RemoveAlias).With(
 		option.Response(204, nil),
 		option.Tags("Projects"),
 	)
 
 	// Notes routes
-	noteStore := notes.NewNoteService(s.sqliteDB)
+	noteStore := notes.NewNoteService(s.sqliteDB).WithEvents(s.services.webhooks)
 	notesHandler := notes.NewNoteHandler(noteStore)
 
-	apiRoute.Handle("GET /notes/by-date", rootMw.ThenFunc(notesHandler.GetNoteByDate)).With(
-		option.Request(new(notes.GetNoteByDateRequest)),
+	apiRoute.HandleRequest("GET /notes/by-date", apiMw, new(notes.GetNoteByDateRequest), notesHandler.GetNoteByDate).With(
 		option.Response(200, new(notes.Note)),
 		option.Response(404, "Not Found"),
 		option.Tags("Notes"),
 	)
 
-	apiRoute.Handle("POST /notes", rootMw.ThenFunc(notesHandler.CreateNote)).With(
-		option.Request(new(notes.CreateNoteRequest)),
+	apiRoute.HandleRequest("POST /notes", apiMw, new(notes.CreateNoteRequest), notesHandler.CreateNote).With(
 		option.Response(201, new(notes.Note)),
 		option.Tags("Notes"),
 	)
 
-	apiRoute.Handle("GET /notes/for-month", rootMw.ThenFunc(notesHandler.GetMonthNotes)).With(
-		option.Request(new(notes.GetMonthNotesRequest)),
+	apiRoute.HandleRequest("POST /notes/{date}/append", apiMw, new(notes.AppendNoteRequest), notesHandler.AppendToNote).With(
+		option.Response(200, new(notes.Note)),
+		option.Response(400, "Bad Request"),
+		option.Tags("Notes"),
+	)
+
+	apiRoute.HandleRequest("GET /notes/for-month", apiMw, new(notes.GetMonthNotesRequest), notesHandler.GetMonthNotes).With(
 		option.Response(200, new([]int)),
 		option.Tags("Notes"),
 	)
 
-	apiRoute.Handle("PUT /notes/excerpts", rootMw.ThenFunc(notesHandler.UpdateNoteExcerpts)).With(
-		option.Request(new(notes.UpdateNoteExcerptRequest)),
+	apiRoute.HandleRequest("PUT /notes/excerpts", apiMw, new(notes.UpdateNoteExcerptRequest), notesHandler.UpdateNoteExcerpts).With(
 		option.Response(204, nil),
+		option.Response(404, "Not Found"),
+		option.Response(422, new(notes.ProjectErrorResponse)),
 		option.Tags("Notes"),
 	)
 
-	apiRoute.Handle("GET /notes/excerpts/{project}", rootMw.ThenFunc(notesHandler.GetExcerptsForProject)).With(
-		option.Request(new(notes.GetExcerptsForProjectRequest)),
-		option.Response(200, new([]notes.NoteExcerpt)),
+	apiRoute.HandleRequest("PUT /notes/{date}", apiMw, new(notes.SaveNoteRequest), notesHandler.SaveNote).With(
+		option.Response(200, new(notes.Note)),
+		option.Response(422, new(notes.ProjectErrorResponse)),
 		option.Tags("Notes"),
 	)
 
-	apiRoute.Handle("/", rootMw.ThenFunc(
-		func(w http.ResponseWriter, r *http.Request) {
-			slog.Default().Info("Handling CORS preflight")
-			if r.Method == http.MethodOptions {
-				w.WriteHeader(http.StatusOK)
-				return
-			}
+	apiRoute.HandleRequest("GET /notes/excerpts/{project}", apiMw, new(notes.GetExcerptsForProjectRequest), notesHandler.GetExcerptsForProject).With(
+		option.Response(200, new(notes.ExcerptPage)),
+		option.Response(400, "Bad Request"),
+		option.Tags("Notes"),
+	)
 
-			http.NotFound(w, r)
-		},
-	))
+	// Registered under /notes/backlinks/{date}, as /notes/{date}/backlinks would
+	// conflict with /notes/excerpts/{project} in the mux
+	apiRoute.HandleRequest("GET /notes/backlinks/{date}", apiMw, new(notes.GetBacklinksRequest), notesHandler.GetBacklinks).With(
+		option.Response(200, new([]notes.Backlink)),
+		option.Tags("Notes"),
+	)
+
+	apiRoute.Handle("GET /tags", apiMw.ThenFunc(notesHandler.GetTags)).With(
+		option.Response(200, new([]notes.TagCount)),
+		option.Tags("Tags"),
+	)
+
+	apiRoute.HandleRequest("GET /tags/{tag}/excerpts", apiMw, new(notes.GetExcerptsForTagRequest), notesHandler.GetExcerptsForTag).With(
+		option.Response(200, new([]notes.NoteExcerpt)),
+		option.Tags("Tags"),
+	)
+
+	// Attachment routes
+	attachmentsHandler := attachments.NewHandler(s.services.attachments, attachments.DefaultLimits)
+
+	apiRoute.Handle("POST /attachments", uploadMw.ThenFunc(attachmentsHandler.UploadAttachment)).With(
+		option.Request(new(attachments.UploadAttachmentRequest)),
+		option.Response(201, new(attachments.Attachment)),
+		option.Response(413, new(utils.ErrorResponse)),
+		option.Response(415, "Unsupported Media Type"),
+		option.Tags("Attachments"),
+	)
+
+	apiRoute.HandleRequest("GET /attachments/{hash}", apiMw, new(attachments.GetAttachmentRequest), attachmentsHandler.GetAttachment).With(
+		option.Response(200, "", option.ContentType("application/octet-stream")),
+		option.Response(404, "Not Found"),
+		option.Tags("Attachments"),
+	)
+
+	// Reminder routes
+	reminderHandler := reminders.NewReminderHandler(s.services.reminders, s.services.broker)
+
+	apiRoute.HandleRequest("POST /reminders", apiMw, new(reminders.CreateReminderRequest), reminderHandler.CreateReminder).With(
+		option.Response(201, new(reminders.Reminder)),
+		option.Response(400, "Bad Request"),
+		option.Response(404, "Not Found"),
+		option.Tags("Reminders"),
+	)
+
+	apiRoute.HandleRequest("GET /reminders/due", apiMw, new(reminders.ListDueRequest), reminderHandler.ListDue).With(
+		option.Response(200, new([]reminders.Reminder)),
+		option.Tags("Reminders"),
+	)
+
+	apiRoute.Handle("GET /reminders/events", apiMw.ThenFunc(reminderHandler.StreamEvents)).With(
+		option.Response(200, "text/event-stream of reminder events"),
+		option.Tags("Reminders"),
+	)
+
+	apiRoute.HandleRequest("PUT /reminders/{id}/complete", apiMw, new(reminders.ReminderIdRequest), reminderHandler.CompleteReminder).With(
+		option.Response(200, new(reminders.Reminder)),
+		option.Response(404, "Not Found"),
+		option.Tags("Reminders"),
+	)
+
+	apiRoute.HandleRequest("DELETE /reminders/{id}", apiMw, new(reminders.ReminderIdRequest), reminderHandler.DeleteReminder).With(
+		option.Response(204, nil),
+		option.Tags("Reminders"),
+	)
+
+	// Calendar feed, authenticated with the feed token instead of a session
+	calendarFeed := calendar.NewFeed(noteStore, projectsStore, s.services.reminders)
+	calendarHandler := calendar.NewCalendarHandler(calendarFeed)
+
+	apiRoute.HandleRequest("GET /calendar.ics", feedMw, new(calendar.GetCalendarRequest), calendarHandler.GetCalendar).With(
+		option.Response(200, "text/calendar"),
+		option.Response(401, "Unauthorized"),
+		option.Response(404, "Not Found"),
+		option.Tags("Calendar"),
+	)
+
+	// Atom feeds, authenticated with the feed token like the calendar
+	feedHandler := feeds.NewFeedHandler(noteStore, s.cfg.RateLimit.TrustProxy)
+
+	apiRoute.HandleRequest("GET /feeds/notes.atom", feedMw, new(feeds.GetNotesFeedRequest), feedHandler.GetNotesFeed).With(
+		option.Response(200, "application/atom+xml"),
+		option.Response(401, "Unauthorized"),
+		option.Tags("Feeds"),
+	)
+
+	// Wildcards must span a whole segment, so the handler strips the .atom suffix
+	apiRoute.HandleRequest("GET /feeds/projects/{name}", feedMw, new(feeds.GetProjectFeedRequest), feedHandler.GetProjectFeed).With(
+		option.Response(200, "application/atom+xml"),
+		option.Response(401, "Unauthorized"),
+		option.Response(404, "Not Found"),
+		option.Tags("Feeds"),
+	)
+
+	// Webhook routes
+	webhookHandler := webhooks.NewWebhookHandler(s.services.webhooks)
+
+	apiRoute.HandleRequest("POST /webhooks", apiMw, new(webhooks.CreateWebhookRequest), webhookHandler.CreateWebhook).With(
+		option.Response(201, new(webhooks.Webhook)),
+		option.Response(400, "Bad Request"),
+		option.Tags("Webhooks"),
+	)
+
+	apiRoute.Handle("GET /webhooks", apiMw.ThenFunc(webhookHandler.ListWebhooks)).With(
+		option.Response(200, new([]webhooks.Webhook)),
+		option.Tags("Webhooks"),
+	)
+
+	apiRoute.HandleRequest("DELETE /webhooks/{id}", apiMw, new(webhooks.WebhookIdRequest), webhookHandler.DeleteWebhook).With(
+		option.Response(204, nil),
+		option.Response(404, "Not Found"),
+		option.Tags("Webhooks"),
+	)
+
+	apiRoute.HandleRequest("GET /webhooks/{id}/deliveries", apiMw, new(webhooks.ListDeliveriesRequest), webhookHandler.ListDeliveries).With(
+		option.Response(200, new([]webhooks.Delivery)),
+		option.Response(404, "Not Found"),
+		option.Tags("Webhooks"),
+	)
+
+	// Workspace routes
+	workspaceHandler := workspaces.NewWorkspaceHandler(workspaceStore)
+
+	apiRoute.Handle("GET /workspaces", apiMw.ThenFunc(workspaceHandler.ListWorkspaces)).With(
+		option.Response(200, new([]workspaces.Workspace)),
+		option.Response(401, "Unauthorized"),
+		option.Tags("Workspaces"),
+	)
+
+	// Stats routes
+	statsStore := stats.NewStatsService(s.sqliteDB)
+	statsHandler := stats.NewStatsHandler(statsStore)
+
+	apiRoute.HandleRequest("GET /stats", apiMw, new(stats.GetStatsRequest), statsHandler.GetStats).With(
+		option.Response(200, new(stats.Stats)),
+		option.Tags("Stats"),
+	)
+
+	// Time entry routes
+	timeEntryStore := timeentries.NewSqliteStore(s.sqliteDB)
+	timeEntryHandler := timeentries.NewHandler(timeEntryStore)
+
+	apiRoute.HandleRequest("POST /time-entries", apiMw, new(timeentries.TimeEntryInput), timeEntryHandler.CreateTimeEntry).With(
+		option.Response(201, new(timeentries.TimeEntry)),
+		option.Response(400, "Bad Request"),
+		option.Tags("Time Entries"),
+	)
+
+	apiRoute.HandleRequest("GET /time-entries", apiMw, new(timeentries.ListTimeEntriesRequest), timeEntryHandler.ListTimeEntries).With(
+		option.Response(200, new([]timeentries.TimeEntry)),
+		option.Tags("Time Entries"),
+	)
+
+	apiRoute.HandleRequest("GET /time-entries/summary", apiMw, new(timeentries.SummaryRequest), timeEntryHandler.GetSummary).With(
+		option.Response(200, new([]timeentries.ProjectHours)),
+		option.Tags("Time Entries"),
+	)
+
+	apiRoute.HandleRequest("GET /time-entries/export", apiMw, new(timeentries.ListTimeEntriesRequest), timeEntryHandler.ExportCSV).With(
+		option.Response(200, "", option.ContentType("text/csv")),
+		option.Tags("Time Entries"),
+	)
+
+	apiRoute.HandleRequest("GET /time-entries/{id}", apiMw, new(timeentries.GetTimeEntryRequest), timeEntryHandler.GetTimeEntry).With(
+		option.Response(200, new(timeentries.TimeEntry)),
+		option.Response(404, "Not Found"),
+		option.Tags("Time Entries"),
+	)
+
+	apiRoute.HandleRequest("PUT /time-entries/{id}", apiMw, new(timeentries.UpdateTimeEntryRequest), timeEntryHandler.UpdateTimeEntry).With(
+		option.Response(200, new(timeentries.TimeEntry)),
+		option.Response(404, "Not Found"),
+		option.Tags("Time Entries"),
+	)
+
+	apiRoute.HandleRequest("DELETE /time-entries/{id}", apiMw, new(timeentries.GetTimeEntryRequest), timeEntryHandler.DeleteTimeEntry).With(
+		option.Response(204, nil),
+		option.Tags("Time Entries"),
+	)
+
+	// Unknown API paths are not found rather than falling through to the SPA
+	apiRoute.Handle("/", rootMw.ThenFunc(http.NotFound))
 
-	// For CORS preflight requests
 	r.Handle("/", rootMw.ThenFunc(
 		func(w http.ResponseWriter, r *http.Request) {
 
@@ -158,8 +402,8 @@ func (s *Server) MountRoutesOapi() {
 	))
 
 	srv := &http.Server{
-		Addr:    ":" + s.port,
-		Handler: otelhttp.NewHandler(mux, "server", otelhttp.WithSpanNameFormatter(httpSpanName)),
+		Addr:    ":" + s.cfg.Port,
+		Handler: otelhttp.NewHandler(s.cors.Handler(apiRoute, mux), "server", otelhttp.WithSpanNameFormatter(httpSpanName)),
 	}
 
 	s.srv = srv
@@ -3,35 +3,67 @@ package api
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"path/filepath"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/maybemaby/workpad/api/notes"
 	"github.com/maybemaby/workpad/migrations"
+	"github.com/oaswrap/spec/adapter/httpopenapi"
 )
 
 type Server struct {
 	logger   *slog.Logger
-	port     string
+	cfg      Config
 	srv      *http.Server
 	db       *sql.DB
 	sqliteDB *sqlx.DB
 	services *services
+	cors     *CORSPolicy
+	limiter  *RateLimiter
+	// location dates requests without an X-Timezone header, and background jobs
+	location *time.Location
 	prod     bool
+	// spec describes the routes mounted by MountRoutesOapi
+	spec httpopenapi.Generator
 }
 
-func NewServer(isProd bool) (*Server, error) {
+func NewServer(cfg Config) (*Server, error) {
 
 	server := &Server{
-		port: "8000",
-		prod: isProd,
+		cfg:  cfg,
+		prod: cfg.IsProd(),
 	}
 
-	server.WithLogger(isProd)
+	server.WithLogger(server.prod)
+
+	cors, err := NewCORSPolicy(cfg.CORS)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cors config: %w", err)
+	}
+
+	server.cors = cors
+
+	limiter, err := NewRateLimiter(cfg.RateLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rate limiter: %w", err)
+	}
+
+	server.limiter = limiter
+
+	location, err := cfg.Location()
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone: %w", err)
+	}
+
+	server.location = location
 
 	// Initialize SQLite connection
-	sqliteDB, sqlDB, err := NewSqliteDB(context.Background(), !isProd)
+	sqliteDB, sqlDB, err := NewSqliteDB(context.Background(), cfg.Database, !server.prod)
 	if err != nil {
 		return nil, err
 	}
@@ -39,7 +71,13 @@ func NewServer(isProd bool) (*Server, error) {
 	server.sqliteDB = sqliteDB
 	server.db = sqlDB
 
-	services := newServices(server.logger)
+	// Attachments live next to the database unless configured otherwise
+	attachmentsDir := cfg.AttachmentsDir
+	if attachmentsDir == "" {
+		attachmentsDir = filepath.Join(filepath.Dir(cfg.Database.Path), "attachments")
+	}
+
+	services := newServices(server.logger, sqliteDB, attachmentsDir)
 	server.services = services
 
 	return server, nil
@@ -49,19 +87,162 @@ func (s *Server) Start(ctx context.Context) error {
 
 	s.MountRoutesOapi()
 
-	err := migrations.RunMigrations(ctx, s.db)
-	
-	if err != nil {
-		return fmt.Errorf("failed to run migrations: %w", err)
+	if err := s.migrate(ctx); err != nil {
+		return err
 	}
 
-	s.logger.Info("Server started at http://localhost:" + s.port)
+	s.reindexNotes(ctx)
+
+	go s.collectAttachments(ctx, time.Hour, 24*time.Hour)
+	go s.fireReminders(ctx, time.Minute)
+	go s.deliverWebhooks(ctx, 15*time.Second)
+	go s.pruneRateLimits(ctx, time.Minute)
+
+	s.logger.Info("Server started at http://localhost:" + s.cfg.Port)
 	s.logger.Info(fmt.Sprintf("Server is running in production mode: %t", s.prod))
 	s.logger.Debug("Server is running in debug mode")
 
 	return s.srv.ListenAndServe()
 }
 
+// migrate brings the schema up to date, or only checks it when migrations are skipped
+// A schema newer than the binary is refused either way, as its queries may no longer match
+func (s *Server) migrate(ctx context.Context) error {
+	if s.cfg.AutoMigrate {
+		if err := migrations.RunMigrations(ctx, s.db); err != nil {
+			return fmt.Errorf("failed to run migrations: %w", err)
+		}
+
+		return nil
+	}
+
+	current, latest, err := migrations.CheckVersion(ctx, s.db)
+	if err != nil {
+		return fmt.Errorf("failed to check schema version: %w", err)
+	}
+
+	if current < latest {
+		s.logger.Warn("Database has pending migrations, run migrate up", "version", current, "latest", latest)
+	}
+
+	return nil
+}
+
+// reindexNotes brings notes saved by older versions up to date, sanitizing notes stored before
+// sanitizing and indexing tags written before tags were indexed. It runs before requests are served
+// so it never races a save
+func (s *Server) reindexNotes(ctx context.Context) {
+	count, err := notes.NewNoteService(s.sqliteDB).Reindex(ctx, 100)
+
+	if err != nil {
+		s.logger.Error("Error reindexing notes", "error", err)
+	}
+
+	if count > 0 {
+		s.logger.Info("Reindexed notes", "count", count)
+	}
+}
+
+// collectAttachments periodically deletes attachments no note of the uploading workspace references
+// Uploads younger than grace are kept so a note has time to be saved with them
+func (s *Server) collectAttachments(ctx context.Context, interval time.Duration, grace time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			removed, err := s.services.attachments.CollectGarbage(ctx, grace)
+
+			if err != nil {
+				s.logger.Error("Error collecting attachments", "error", err)
+				continue
+			}
+
+			if removed > 0 {
+				s.logger.Info("Collected unreferenced attachments", "count", removed)
+			}
+		}
+	}
+}
+
+// fireReminders publishes reminders to event streams as they come due
+// Each reminder fires once, the first tick on or after its due date
+func (s *Server) fireReminders(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		due, err := s.services.reminders.FireDue(ctx, time.Now().In(s.location))
+
+		if err != nil {
+			s.logger.Error("Error firing reminders", "error", err)
+		}
+
+		for _, reminder := range due {
+			s.services.broker.Publish(reminder)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliverWebhooks works through the webhook outbox, waking early when new events are queued
+// Retries are scheduled by the outbox, so interval only bounds how late they are attempted
+func (s *Server) deliverWebhooks(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.services.webhooks.Wake():
+			// Events are queued inside the transaction of their change, let it commit first
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(100 * time.Millisecond):
+			}
+		}
+
+		if _, err := s.services.webhooks.DeliverDue(ctx, time.Now()); err != nil {
+			s.logger.Error("Error delivering webhooks", "error", err)
+		}
+	}
+}
+
+// pruneRateLimits forgets idle clients so the limiter does not grow with every address seen
+func (s *Server) pruneRateLimits(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.limiter.Prune()
+		}
+	}
+}
+
+// OpenAPISpec returns the JSON OpenAPI document of the mounted routes
+func (s *Server) OpenAPISpec() ([]byte, error) {
+	if s.spec == nil {
+		return nil, errors.New("routes are not mounted")
+	}
+
+	return s.spec.MarshalJSON()
+}
+
 func (s *Server) WithLogger(isProd bool) {
 	format := JSONFormat
 	level := slog.LevelInfo
@@ -75,5 +256,5 @@ func (s *Server) WithLogger(isProd bool) {
 }
 
 func (s *Server) WithPort(port string) {
-	s.port = port
+	s.cfg.Port = port
 }
@@ -2,12 +2,27 @@ package api
 
 import (
 	"log/slog"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/maybemaby/workpad/api/attachments"
+	"github.com/maybemaby/workpad/api/reminders"
+	"github.com/maybemaby/workpad/api/webhooks"
 )
 
 type services struct {
+	attachments *attachments.AttachmentService
+	reminders   *reminders.ReminderService
+	broker      *reminders.Broker
+	webhooks    *webhooks.WebhookService
 }
 
-func newServices(logger *slog.Logger) *services {
+func newServices(logger *slog.Logger, db *sqlx.DB, attachmentsDir string) *services {
 
-	return &services{}
+	return &services{
+		attachments: attachments.NewAttachmentService(db, attachmentsDir),
+		reminders:   reminders.NewReminderService(db),
+		broker:      reminders.NewBroker(),
+		webhooks:    webhooks.NewWebhookService(db, 10*time.Second),
+	}
 }
@@ -4,20 +4,19 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"os"
 
 	_ "modernc.org/sqlite"
 
 	"github.com/jmoiron/sqlx"
 )
 
-func NewSqliteDB(ctx context.Context, trace bool) (*sqlx.DB, *sql.DB, error) {
-	dbPath := os.Getenv("SQLITE_DB_PATH")
-	if dbPath == "" {
-		return nil, nil, fmt.Errorf("SQLITE_DB_PATH environment variable not set")
+func NewSqliteDB(ctx context.Context, cfg DatabaseConfig, trace bool) (*sqlx.DB, *sql.DB, error) {
+	if cfg.Path == "" {
+		return nil, nil, fmt.Errorf("database path not set")
 	}
 
-	connStr := fmt.Sprintf("file:%s?cache=shared&mode=rwc", dbPath)
+	// Pragmas in the DSN apply to every pooled connection, not just the one that runs them
+	connStr := fmt.Sprintf("file:%s?cache=shared&mode=rwc&_pragma=foreign_keys(1)", cfg.Path)
 
 	// Open connection using stdlib sql driver directly
 	sqlDB, err := sql.Open("sqlite", connStr)
@@ -29,17 +28,21 @@ func NewSqliteDB(ctx context.Context, trace bool) (*sqlx.DB, *sql.DB, error) {
 	db := sqlx.NewDb(sqlDB, "sqlite")
 
 	// Configure connection pool
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(5)
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
 
 	// Verify connection
 	if err := db.PingContext(ctx); err != nil {
 		return nil, nil, fmt.Errorf("failed to ping sqlite database: %w", err)
 	}
 
-	// Enable foreign keys
-	if _, err := db.ExecContext(ctx, "PRAGMA foreign_keys = ON"); err != nil {
-		return nil, nil, fmt.Errorf("failed to enable foreign keys: %w", err)
+	var foreignKeys bool
+	if err := db.GetContext(ctx, &foreignKeys, "PRAGMA foreign_keys"); err != nil {
+		return nil, nil, fmt.Errorf("failed to check foreign keys: %w", err)
+	}
+
+	if !foreignKeys {
+		return nil, nil, fmt.Errorf("failed to enable foreign keys")
 	}
 
 	return db, sqlDB, nil
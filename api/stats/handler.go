@@ -0,0 +1,67 @@
+package stats
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/maybemaby/workpad/api/tz"
+	"github.com/maybemaby/workpad/api/utils"
+)
+
+type StatsHandler struct {
+	store StatsStore
+}
+
+func NewStatsHandler(store StatsStore) *StatsHandler {
+	return &StatsHandler{store: store}
+}
+
+// GetStats handles GET /stats
+// Defaults to the year ending today when from or to are omitted
+func (h *StatsHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	to := tz.Today(r.Context())
+
+	if value := query.Get("to"); value != "" {
+		parsed, err := time.Parse(time.DateOnly, value)
+
+		if err != nil {
+			http.Error(w, "Invalid to date format. Use YYYY-MM-DD.", http.StatusBadRequest)
+			return
+		}
+
+		to = parsed
+	}
+
+	from := to.AddDate(-1, 0, 1)
+
+	if value := query.Get("from"); value != "" {
+		parsed, err := time.Parse(time.DateOnly, value)
+
+		if err != nil {
+			http.Error(w, "Invalid from date format. Use YYYY-MM-DD.", http.StatusBadRequest)
+			return
+		}
+
+		from = parsed
+	}
+
+	if from.Format(time.DateOnly) > to.Format(time.DateOnly) {
+		http.Error(w, "from must not be after to", http.StatusBadRequest)
+		return
+	}
+
+	stats, err := h.store.GetStats(r.Context(), from, to)
+
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	err = utils.WriteJSON(w, r, stats)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
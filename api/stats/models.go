@@ -0,0 +1,32 @@
+package stats
+
+type GetStatsRequest struct {
+	From string `query:"from" example:"2026-01-01" required:"false" format:"date"`
+	To   string `query:"to" example:"2026-12-31" required:"false" format:"date"`
+}
+
+type DayStats struct {
+	Date         string `json:"date" required:"true" db:"note_date" example:"2026-01-01"`
+	WordCount    int    `json:"word_count" required:"true" db:"word_count"`
+	ExcerptCount int    `json:"excerpt_count" required:"true" db:"excerpt_count"`
+}
+
+type Streaks struct {
+	// Current counts consecutive days with a note ending on the range end, or the day before it
+	Current int `json:"current" required:"true"`
+	Longest int `json:"longest" required:"true"`
+}
+
+type ProjectWeek struct {
+	ProjectName string `json:"project_name" required:"true" db:"project_name"`
+	WeekStart   string `json:"week_start" required:"true" db:"week_start" example:"2025-12-29"`
+	Mentions    int    `json:"mentions" required:"true" db:"mentions"`
+}
+
+type Stats struct {
+	From         string        `json:"from" required:"true" example:"2026-01-01"`
+	To           string        `json:"to" required:"true" example:"2026-12-31"`
+	Days         []DayStats    `json:"days" required:"true" nullable:"false"`
+	Streaks      Streaks       `json:"streaks" required:"true"`
+	ProjectWeeks []ProjectWeek `json:"project_weeks" required:"true" nullable:"false"`
+}
@@ -0,0 +1,209 @@
+package stats
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/maybemaby/workpad/api/notes"
+	"github.com/maybemaby/workpad/api/workspaces"
+)
+
+type StatsStore interface {
+	GetStats(ctx context.Context, from time.Time, to time.Time) (Stats, error)
+}
+
+type StatsService struct {
+	db *sqlx.DB
+}
+
+func NewStatsService(db *sqlx.DB) *StatsService {
+	return &StatsService{db: db}
+}
+
+// GetStats returns journaling statistics for the inclusive date range
+// Per-day counts are served from note_stats, recomputing any rows the triggers invalidated
+func (s *StatsService) GetStats(ctx context.Context, from time.Time, to time.Time) (Stats, error) {
+	fromDate := from.Format(time.DateOnly)
+	toDate := to.Format(time.DateOnly)
+	ws, err := workspaces.ID(ctx)
+
+	if err != nil {
+		return Stats{}, err
+	}
+
+	if err := s.refreshStale(ctx, fromDate, toDate); err != nil {
+		return Stats{}, err
+	}
+
+	days := []DayStats{}
+
+	err = s.db.SelectContext(ctx, &days, `SELECT date(note_date) AS note_date, word_count, excerpt_count FROM note_stats
+		WHERE note_id IN (SELECT id FROM notes WHERE workspace_id = ?) AND date(note_date) >= ? AND date(note_date) <= ? ORDER BY note_date`, ws, fromDate, toDate)
+
+	if err != nil {
+		return Stats{}, err
+	}
+
+	projectWeeks := []ProjectWeek{}
+
+	// weekday 0 moves forward to Sunday, so -6 days lands on the Monday starting the week
+	err = s.db.SelectContext(ctx, &projectWeeks, `SELECT project_name, date(note_date, 'weekday 0', '-6 days') AS week_start, COUNT(*) AS mentions
+		FROM project_excerpts
+		WHERE workspace_id = ? AND date(note_date) >= ? AND date(note_date) <= ?
+		GROUP BY project_name, week_start
+		ORDER BY week_start, project_name`, ws, fromDate, toDate)
+
+	if err != nil {
+		return Stats{}, err
+	}
+
+	streaks, err := s.streaks(ctx, from, to)
+
+	if err != nil {
+		return Stats{}, err
+	}
+
+	return Stats{
+		From:         fromDate,
+		To:           toDate,
+		Days:         days,
+		Streaks:      streaks,
+		ProjectWeeks: projectWeeks,
+	}, nil
+}
+
+type staleNote struct {
+	Id           int    `db:"id"`
+	Date         string `db:"note_date"`
+	HTMLContent  string `db:"html_content"`
+	ExcerptCount int    `db:"excerpt_count"`
+}
+
+// refreshStale computes note_stats rows for notes of the workspace in the range that have none
+func (s *StatsService) refreshStale(ctx context.Context, fromDate string, toDate string) error {
+	var stale []staleNote
+
+	ws, err := workspaces.ID(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	err = s.db.SelectContext(ctx, &stale, `SELECT n.id, date(n.note_date) AS note_date, n.html_content,
+			(SELECT COUNT(*) FROM project_excerpts pe WHERE pe.note_id = n.id) AS excerpt_count
+		FROM notes n
+		LEFT JOIN note_stats ns ON ns.note_id = n.id
+		WHERE n.workspace_id = ? AND ns.note_id IS NULL AND date(n.note_date) >= ? AND date(n.note_date) <= ?`, ws, fromDate, toDate)
+
+	if err != nil {
+		return err
+	}
+
+	if len(stale) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	insertStmt, err := tx.PrepareContext(ctx, `INSERT INTO note_stats (note_id, note_date, word_count, excerpt_count) VALUES (?, ?, ?, ?)
+		ON CONFLICT (note_id) DO UPDATE SET note_date = excluded.note_date, word_count = excluded.word_count, excerpt_count = excluded.excerpt_count`)
+
+	if err != nil {
+		return err
+	}
+
+	defer insertStmt.Close()
+
+	for _, note := range stale {
+		_, err := insertStmt.ExecContext(ctx, note.Id, note.Date, notes.WordCount(note.HTMLContent), note.ExcerptCount)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// streaks finds the longest run of consecutive note days inside the range and the
+// run that is still going at the end of it, which may have started before the range
+func (s *StatsService) streaks(ctx context.Context, from time.Time, to time.Time) (Streaks, error) {
+	var streaks Streaks
+
+	fromDate := from.Format(time.DateOnly)
+	toDate := to.Format(time.DateOnly)
+
+	// Compare whole days, whatever time of day the caller passed in
+	end, err := time.Parse(time.DateOnly, toDate)
+
+	if err != nil {
+		return streaks, err
+	}
+
+	ws, err := workspaces.ID(ctx)
+
+	if err != nil {
+		return streaks, err
+	}
+
+	rows, err := s.db.QueryxContext(ctx, `SELECT date(note_date) FROM notes WHERE workspace_id = ? AND date(note_date) <= ? ORDER BY note_date DESC`, ws, toDate)
+
+	if err != nil {
+		return streaks, err
+	}
+
+	defer rows.Close()
+
+	currentOpen := true
+	run := 0
+	var previous time.Time
+
+	for rows.Next() {
+		var value string
+
+		if err := rows.Scan(&value); err != nil {
+			return streaks, err
+		}
+
+		day, err := time.Parse(time.DateOnly, value)
+
+		if err != nil {
+			return streaks, err
+		}
+
+		if run == 0 {
+			// The current streak may end today or yesterday, as today's note might not be written yet
+			if currentOpen && end.Sub(day) > 24*time.Hour {
+				currentOpen = false
+			}
+			run = 1
+		} else if previous.Sub(day) == 24*time.Hour {
+			run++
+		} else {
+			currentOpen = false
+			run = 1
+		}
+
+		if currentOpen {
+			streaks.Current = run
+		}
+
+		if value >= fromDate {
+			streaks.Longest = max(streaks.Longest, run)
+		} else if !currentOpen {
+			// Everything left is outside the range and can no longer affect either streak
+			break
+		}
+
+		previous = day
+	}
+
+	return streaks, rows.Err()
+}
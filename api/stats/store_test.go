@@ -0,0 +1,139 @@
+package stats
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/maybemaby/workpad/api/utils"
+	"github.com/maybemaby/workpad/api/workspaces"
+	"github.com/stretchr/testify/suite"
+	_ "modernc.org/sqlite"
+)
+
+func mustParseDate(value string) time.Time {
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		panic(err)
+	}
+
+	return t
+}
+
+func seedNotes(ctx context.Context, db *sqlx.DB, notes map[string]string) map[string]int {
+	ids := make(map[string]int, len(notes))
+
+	for date, content := range notes {
+		var id int
+		err := db.QueryRowContext(ctx, "INSERT INTO notes (html_content, note_date) VALUES (?, ?) RETURNING id", content, date).Scan(&id)
+
+		if err != nil {
+			panic(err)
+		}
+
+		ids[date] = id
+	}
+
+	return ids
+}
+
+type StatsStoreSuite struct {
+	suite.Suite
+	db    *sql.DB
+	dbx   *sqlx.DB
+	notes map[string]int
+}
+
+func (s *StatsStoreSuite) SetupTest() {
+	s.db, _ = sql.Open("sqlite", ":memory:")
+	s.db.SetMaxOpenConns(1)
+	s.dbx = sqlx.NewDb(s.db, "sqlite")
+
+	err := utils.SetupSqliteDb(s.db)
+
+	if err != nil {
+		panic(err)
+	}
+
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+
+	s.notes = seedNotes(ctx, s.dbx, map[string]string{
+		"2026-01-01": "<p>one two three</p>",
+		"2026-01-02": "<p>four</p><ul><li><p>five</p></li></ul>",
+		"2026-01-03": "<p>six</p>",
+		"2026-01-06": "<p>seven eight</p>",
+		"2026-01-07": "<p>nine</p>",
+	})
+
+	s.dbx.MustExecContext(ctx, "INSERT INTO projects (name) VALUES ('Alpha'), ('Beta')")
+	s.dbx.MustExecContext(ctx, "INSERT INTO project_excerpts (project_name, note_id, excerpt, note_date) VALUES ('Alpha', ?, 'a', '2026-01-01'), ('Beta', ?, 'b', '2026-01-01'), ('Alpha', ?, 'c', '2026-01-06')", s.notes["2026-01-01"], s.notes["2026-01-01"], s.notes["2026-01-06"])
+}
+
+func (s *StatsStoreSuite) TearDownTest() {
+	s.db.Close()
+}
+
+func (s *StatsStoreSuite) TestGetStats_Days() {
+	store := NewStatsService(s.dbx)
+
+	stats, err := store.GetStats(workspaces.WithID(s.T().Context(), workspaces.DefaultID), mustParseDate("2026-01-01"), mustParseDate("2026-01-02"))
+
+	s.NoError(err)
+	s.Equal([]DayStats{
+		{Date: "2026-01-01", WordCount: 3, ExcerptCount: 2},
+		{Date: "2026-01-02", WordCount: 2, ExcerptCount: 0},
+	}, stats.Days)
+}
+
+func (s *StatsStoreSuite) TestGetStats_RecomputesAfterNoteUpdate() {
+	store := NewStatsService(s.dbx)
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+
+	_, err := store.GetStats(ctx, mustParseDate("2026-01-01"), mustParseDate("2026-01-01"))
+	s.NoError(err)
+
+	s.dbx.MustExecContext(ctx, "UPDATE notes SET html_content = '<p>just two</p>' WHERE id = ?", s.notes["2026-01-01"])
+	s.dbx.MustExecContext(ctx, "DELETE FROM project_excerpts WHERE project_name = 'Beta'")
+
+	stats, err := store.GetStats(ctx, mustParseDate("2026-01-01"), mustParseDate("2026-01-01"))
+
+	s.NoError(err)
+	s.Equal([]DayStats{{Date: "2026-01-01", WordCount: 2, ExcerptCount: 1}}, stats.Days)
+}
+
+func (s *StatsStoreSuite) TestGetStats_Streaks() {
+	store := NewStatsService(s.dbx)
+
+	stats, err := store.GetStats(workspaces.WithID(s.T().Context(), workspaces.DefaultID), mustParseDate("2026-01-01"), mustParseDate("2026-01-08"))
+
+	s.NoError(err)
+	s.Equal(Streaks{Current: 2, Longest: 3}, stats.Streaks)
+}
+
+func (s *StatsStoreSuite) TestGetStats_StreakBroken() {
+	store := NewStatsService(s.dbx)
+
+	stats, err := store.GetStats(workspaces.WithID(s.T().Context(), workspaces.DefaultID), mustParseDate("2026-01-02"), mustParseDate("2026-01-05"))
+
+	s.NoError(err)
+	s.Equal(Streaks{Current: 0, Longest: 2}, stats.Streaks)
+}
+
+func (s *StatsStoreSuite) TestGetStats_ProjectWeeks() {
+	store := NewStatsService(s.dbx)
+
+	stats, err := store.GetStats(workspaces.WithID(s.T().Context(), workspaces.DefaultID), mustParseDate("2026-01-01"), mustParseDate("2026-01-31"))
+
+	s.NoError(err)
+	s.Equal([]ProjectWeek{
+		{ProjectName: "Alpha", WeekStart: "2025-12-29", Mentions: 1},
+		{ProjectName: "Beta", WeekStart: "2025-12-29", Mentions: 1},
+		{ProjectName: "Alpha", WeekStart: "2026-01-05", Mentions: 1},
+	}, stats.ProjectWeeks)
+}
+
+func TestStatsStoreSuite(t *testing.T) {
+	suite.Run(t, new(StatsStoreSuite))
+}
@@ -0,0 +1,230 @@
+package timeentries
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/maybemaby/workpad/api/utils"
+)
+
+// CSVHeader is the column layout used for time entry exports
+var CSVHeader = []string{"date", "project", "description", "started_at", "ended_at", "duration_minutes", "hours"}
+
+// TimeEntryHandler handles HTTP requests for time entries
+type TimeEntryHandler struct {
+	store TimeEntryStore
+}
+
+// NewHandler creates a new time entries handler
+func NewHandler(store TimeEntryStore) *TimeEntryHandler {
+	return &TimeEntryHandler{store: store}
+}
+
+func writeStoreError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, "Time entry not found", http.StatusNotFound)
+	case errors.Is(err, ErrInvalidEntry), errors.Is(err, ErrProjectNotFound):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+func parseId(r *http.Request) (int, error) {
+	return strconv.Atoi(r.PathValue("id"))
+}
+
+// parseFilter reads the optional from, to and project query parameters
+func parseFilter(r *http.Request) (TimeEntryFilter, error) {
+	var filter TimeEntryFilter
+	query := r.URL.Query()
+
+	if value := query.Get("from"); value != "" {
+		from, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid from date format, use YYYY-MM-DD")
+		}
+		filter.From = from
+	}
+
+	if value := query.Get("to"); value != "" {
+		to, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid to date format, use YYYY-MM-DD")
+		}
+		filter.To = to
+	}
+
+	filter.Project = query.Get("project")
+
+	return filter, nil
+}
+
+// CreateTimeEntry handles POST /time-entries
+func (h *TimeEntryHandler) CreateTimeEntry(w http.ResponseWriter, r *http.Request) {
+	var req TimeEntryInput
+
+	if err := utils.ReadJSON(r, &req); err != nil {
+		utils.WriteBodyError(w, err)
+		return
+	}
+
+	entry, err := h.store.Create(r.Context(), req)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	utils.WriteJSON(w, r, entry)
+}
+
+// GetTimeEntry handles GET /time-entries/{id}
+func (h *TimeEntryHandler) GetTimeEntry(w http.ResponseWriter, r *http.Request) {
+	id, err := parseId(r)
+	if err != nil {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return
+	}
+
+	entry, err := h.store.GetById(r.Context(), id)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, r, entry)
+}
+
+// ListTimeEntries handles GET /time-entries
+func (h *TimeEntryHandler) ListTimeEntries(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entries, err := h.store.List(r.Context(), filter)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, r, entries)
+}
+
+// UpdateTimeEntry handles PUT /time-entries/{id}
+func (h *TimeEntryHandler) UpdateTimeEntry(w http.ResponseWriter, r *http.Request) {
+	id, err := parseId(r)
+	if err != nil {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return
+	}
+
+	var req TimeEntryInput
+
+	if err := utils.ReadJSON(r, &req); err != nil {
+		utils.WriteBodyError(w, err)
+		return
+	}
+
+	entry, err := h.store.Update(r.Context(), id, req)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, r, entry)
+}
+
+// DeleteTimeEntry handles DELETE /time-entries/{id}
+func (h *TimeEntryHandler) DeleteTimeEntry(w http.ResponseWriter, r *http.Request) {
+	id, err := parseId(r)
+	if err != nil {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.store.Delete(r.Context(), id); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetSummary handles GET /time-entries/summary
+func (h *TimeEntryHandler) GetSummary(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if filter.From.IsZero() || filter.To.IsZero() {
+		http.Error(w, "from and to are required", http.StatusBadRequest)
+		return
+	}
+
+	summary, err := h.store.Summarize(r.Context(), filter.From, filter.To)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, r, summary)
+}
+
+// ExportCSV handles GET /time-entries/export
+// Accepts the same filters as ListTimeEntries
+func (h *TimeEntryHandler) ExportCSV(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entries, err := h.store.List(r.Context(), filter)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="time-entries.csv"`)
+
+	writer := csv.NewWriter(w)
+	writer.Write(CSVHeader)
+
+	for _, entry := range entries {
+		writer.Write(csvRecord(entry))
+	}
+
+	writer.Flush()
+}
+
+func csvRecord(entry TimeEntry) []string {
+	formatTime := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+
+	return []string{
+		entry.Date,
+		entry.ProjectName,
+		entry.Description,
+		formatTime(entry.StartedAt),
+		formatTime(entry.EndedAt),
+		strconv.Itoa(entry.DurationMinutes),
+		strconv.FormatFloat(float64(entry.DurationMinutes)/60, 'f', 2, 64),
+	}
+}
@@ -0,0 +1,94 @@
+package timeentries
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrProjectNotFound = errors.New("project not found")
+	ErrInvalidEntry    = errors.New("invalid time entry")
+)
+
+type TimeEntry struct {
+	Id              int        `json:"id" required:"true"`
+	ProjectName     string     `json:"project_name" required:"true" db:"project_name"`
+	Date            string     `json:"date" required:"true" db:"note_date" example:"2026-01-01"`
+	DurationMinutes int        `json:"duration_minutes" required:"true" db:"duration_minutes" example:"90"`
+	StartedAt       *time.Time `json:"started_at,omitempty" db:"started_at"`
+	EndedAt         *time.Time `json:"ended_at,omitempty" db:"ended_at"`
+	Description     string     `json:"description" required:"true"`
+	CreatedAt       time.Time  `json:"created_at" required:"true" db:"created_at"`
+}
+
+// TimeEntryInput is the writable part of a time entry, shared by create and update
+type TimeEntryInput struct {
+	ProjectName string `json:"project_name" required:"true" example:"Project A" maxLength:"100"`
+	Date        string `json:"date" required:"true" example:"2026-01-01" format:"date"`
+	// DurationMinutes may be omitted when both started_at and ended_at are set
+	DurationMinutes int        `json:"duration_minutes" required:"false" example:"90" minimum:"0"`
+	StartedAt       *time.Time `json:"started_at,omitempty" required:"false"`
+	EndedAt         *time.Time `json:"ended_at,omitempty" required:"false"`
+	Description     string     `json:"description" required:"false" example:"Vendor call" maxLength:"1000"`
+}
+
+type UpdateTimeEntryRequest struct {
+	Id int `path:"id" example:"1" required:"true" minimum:"1"`
+	TimeEntryInput
+}
+
+type GetTimeEntryRequest struct {
+	Id int `path:"id" example:"1" required:"true" minimum:"1"`
+}
+
+type ListTimeEntriesRequest struct {
+	From    string `query:"from" example:"2026-01-01" required:"false" format:"date"`
+	To      string `query:"to" example:"2026-01-31" required:"false" format:"date"`
+	Project string `query:"project" example:"Project A" required:"false"`
+}
+
+type SummaryRequest struct {
+	From string `query:"from" example:"2026-01-01" required:"true" format:"date"`
+	To   string `query:"to" example:"2026-01-31" required:"true" format:"date"`
+}
+
+// TimeEntryFilter narrows a listing, zero values are not applied
+type TimeEntryFilter struct {
+	From    time.Time
+	To      time.Time
+	Project string
+}
+
+type ProjectHours struct {
+	ProjectName string  `json:"project_name" required:"true" db:"project_name"`
+	Minutes     int     `json:"minutes" required:"true" db:"minutes"`
+	Hours       float64 `json:"hours" required:"true"`
+	Entries     int     `json:"entries" required:"true" db:"entries"`
+}
+
+// Validate fills in the duration from start and end times and checks the entry is usable
+func (in *TimeEntryInput) Validate() error {
+	if in.ProjectName == "" {
+		return errors.Join(ErrInvalidEntry, errors.New("project_name is required"))
+	}
+
+	if _, err := time.Parse(time.DateOnly, in.Date); err != nil {
+		return errors.Join(ErrInvalidEntry, errors.New("date must be YYYY-MM-DD"))
+	}
+
+	if in.StartedAt != nil && in.EndedAt != nil {
+		if !in.EndedAt.After(*in.StartedAt) {
+			return errors.Join(ErrInvalidEntry, errors.New("ended_at must be after started_at"))
+		}
+
+		if in.DurationMinutes == 0 {
+			in.DurationMinutes = int(in.EndedAt.Sub(*in.StartedAt).Round(time.Minute) / time.Minute)
+		}
+	}
+
+	if in.DurationMinutes <= 0 {
+		return errors.Join(ErrInvalidEntry, errors.New("duration_minutes must be positive"))
+	}
+
+	return nil
+}
@@ -0,0 +1,203 @@
+package timeentries
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/maybemaby/workpad/api/workspaces"
+)
+
+const selectColumns = `id, project_name, date(note_date) AS note_date, duration_minutes, started_at, ended_at, description, created_at`
+
+// SqliteStore implements the TimeEntryStore interface using SQLite
+type SqliteStore struct {
+	db *sqlx.DB
+}
+
+// NewSqliteStore creates a new SQLite store
+func NewSqliteStore(db *sqlx.DB) *SqliteStore {
+	return &SqliteStore{db: db}
+}
+
+func (s *SqliteStore) projectExists(ctx context.Context, name string) error {
+	var exists bool
+
+	ws, err := workspaces.ID(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.GetContext(ctx, &exists, `SELECT EXISTS (SELECT 1 FROM projects WHERE workspace_id = ? AND name = ?)`, ws, name)
+	if err != nil {
+		return fmt.Errorf("failed to look up project: %w", err)
+	}
+
+	if !exists {
+		return ErrProjectNotFound
+	}
+
+	return nil
+}
+
+// Create validates and inserts a time entry for an existing project
+func (s *SqliteStore) Create(ctx context.Context, input TimeEntryInput) (*TimeEntry, error) {
+	input.ProjectName = strings.TrimSpace(input.ProjectName)
+
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.projectExists(ctx, input.ProjectName); err != nil {
+		return nil, err
+	}
+
+	var id int
+
+	ws, err := workspaces.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.QueryRowContext(ctx, `INSERT INTO time_entries (workspace_id, project_name, note_date, duration_minutes, started_at, ended_at, description) VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING id`,
+		ws, input.ProjectName, input.Date, input.DurationMinutes, input.StartedAt, input.EndedAt, input.Description,
+	).Scan(&id)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create time entry: %w", err)
+	}
+
+	return s.GetById(ctx, id)
+}
+
+// GetById retrieves a single time entry
+func (s *SqliteStore) GetById(ctx context.Context, id int) (*TimeEntry, error) {
+	var entry TimeEntry
+
+	ws, err := workspaces.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.GetContext(ctx, &entry, `SELECT `+selectColumns+` FROM time_entries WHERE workspace_id = ? AND id = ?`, ws, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get time entry: %w", err)
+	}
+
+	return &entry, nil
+}
+
+// List retrieves entries matching the filter ordered by date and start time
+func (s *SqliteStore) List(ctx context.Context, filter TimeEntryFilter) ([]TimeEntry, error) {
+	ws, err := workspaces.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	conditions := []string{`workspace_id = ?`}
+	args := []any{ws}
+
+	if !filter.From.IsZero() {
+		conditions = append(conditions, `date(note_date) >= ?`)
+		args = append(args, filter.From.Format(time.DateOnly))
+	}
+
+	if !filter.To.IsZero() {
+		conditions = append(conditions, `date(note_date) <= ?`)
+		args = append(args, filter.To.Format(time.DateOnly))
+	}
+
+	if filter.Project != "" {
+		conditions = append(conditions, `LOWER(project_name) = LOWER(?)`)
+		args = append(args, filter.Project)
+	}
+
+	query := `SELECT ` + selectColumns + ` FROM time_entries WHERE ` + strings.Join(conditions, " AND ") + ` ORDER BY note_date, started_at, id`
+
+	entries := []TimeEntry{}
+
+	err = s.db.SelectContext(ctx, &entries, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list time entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+// Update replaces the writable fields of an entry
+func (s *SqliteStore) Update(ctx context.Context, id int, input TimeEntryInput) (*TimeEntry, error) {
+	input.ProjectName = strings.TrimSpace(input.ProjectName)
+
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.projectExists(ctx, input.ProjectName); err != nil {
+		return nil, err
+	}
+
+	ws, err := workspaces.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.db.ExecContext(ctx, `UPDATE time_entries SET project_name = ?, note_date = ?, duration_minutes = ?, started_at = ?, ended_at = ?, description = ? WHERE workspace_id = ? AND id = ?`,
+		input.ProjectName, input.Date, input.DurationMinutes, input.StartedAt, input.EndedAt, input.Description, ws, id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update time entry: %w", err)
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return s.GetById(ctx, id)
+}
+
+func (s *SqliteStore) Delete(ctx context.Context, id int) error {
+	ws, err := workspaces.ID(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, `DELETE FROM time_entries WHERE workspace_id = ? AND id = ?`, ws, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete time entry: %w", err)
+	}
+
+	return nil
+}
+
+// Summarize totals tracked time per project for the inclusive date range
+func (s *SqliteStore) Summarize(ctx context.Context, from time.Time, to time.Time) ([]ProjectHours, error) {
+	summary := []ProjectHours{}
+
+	ws, err := workspaces.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.SelectContext(ctx, &summary, `SELECT project_name, SUM(duration_minutes) AS minutes, COUNT(*) AS entries
+		FROM time_entries
+		WHERE workspace_id = ? AND date(note_date) >= ? AND date(note_date) <= ?
+		GROUP BY project_name
+		ORDER BY minutes DESC, project_name`, ws, from.Format(time.DateOnly), to.Format(time.DateOnly))
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize time entries: %w", err)
+	}
+
+	for i := range summary {
+		summary[i].Hours = float64(summary[i].Minutes) / 60
+	}
+
+	return summary, nil
+}
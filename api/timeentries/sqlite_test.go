@@ -0,0 +1,130 @@
+package timeentries
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/maybemaby/workpad/api/utils"
+	"github.com/maybemaby/workpad/api/workspaces"
+	"github.com/stretchr/testify/suite"
+	_ "modernc.org/sqlite"
+)
+
+func mustParseTime(layout, value string) time.Time {
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		panic(err)
+	}
+
+	return t
+}
+
+type TimeEntryStoreSuite struct {
+	suite.Suite
+	db  *sql.DB
+	dbx *sqlx.DB
+}
+
+func (s *TimeEntryStoreSuite) SetupTest() {
+	s.db, _ = sql.Open("sqlite", ":memory:")
+	s.db.SetMaxOpenConns(1)
+	s.dbx = sqlx.NewDb(s.db, "sqlite")
+
+	err := utils.SetupSqliteDb(s.db)
+
+	if err != nil {
+		panic(err)
+	}
+
+	s.dbx.MustExecContext(s.T().Context(), "INSERT INTO projects (name) VALUES ('Alpha'), ('Beta')")
+}
+
+func (s *TimeEntryStoreSuite) TearDownTest() {
+	s.db.Close()
+}
+
+func (s *TimeEntryStoreSuite) TestCreate_DurationFromTimes() {
+	store := NewSqliteStore(s.dbx)
+
+	start := mustParseTime(time.RFC3339, "2026-01-02T09:00:00Z")
+	end := mustParseTime(time.RFC3339, "2026-01-02T10:15:00Z")
+
+	entry, err := store.Create(workspaces.WithID(s.T().Context(), workspaces.DefaultID), TimeEntryInput{
+		ProjectName: "Alpha",
+		Date:        "2026-01-02",
+		StartedAt:   &start,
+		EndedAt:     &end,
+		Description: "Planning",
+	})
+
+	s.NoError(err)
+	s.Equal(75, entry.DurationMinutes)
+	s.Equal("2026-01-02", entry.Date)
+	s.True(start.Equal(*entry.StartedAt))
+}
+
+func (s *TimeEntryStoreSuite) TestCreate_UnknownProject() {
+	store := NewSqliteStore(s.dbx)
+
+	_, err := store.Create(workspaces.WithID(s.T().Context(), workspaces.DefaultID), TimeEntryInput{ProjectName: "Missing", Date: "2026-01-02", DurationMinutes: 10})
+
+	s.ErrorIs(err, ErrProjectNotFound)
+}
+
+func (s *TimeEntryStoreSuite) TestCreate_Invalid() {
+	store := NewSqliteStore(s.dbx)
+
+	start := mustParseTime(time.RFC3339, "2026-01-02T10:00:00Z")
+	end := mustParseTime(time.RFC3339, "2026-01-02T09:00:00Z")
+
+	_, err := store.Create(workspaces.WithID(s.T().Context(), workspaces.DefaultID), TimeEntryInput{ProjectName: "Alpha", Date: "2026-01-02", StartedAt: &start, EndedAt: &end})
+	s.ErrorIs(err, ErrInvalidEntry)
+
+	_, err = store.Create(workspaces.WithID(s.T().Context(), workspaces.DefaultID), TimeEntryInput{ProjectName: "Alpha", Date: "01/02/2026", DurationMinutes: 10})
+	s.ErrorIs(err, ErrInvalidEntry)
+}
+
+func (s *TimeEntryStoreSuite) TestUpdate_NotFound() {
+	store := NewSqliteStore(s.dbx)
+
+	_, err := store.Update(workspaces.WithID(s.T().Context(), workspaces.DefaultID), 42, TimeEntryInput{ProjectName: "Alpha", Date: "2026-01-02", DurationMinutes: 10})
+
+	s.ErrorIs(err, sql.ErrNoRows)
+}
+
+func (s *TimeEntryStoreSuite) TestListAndSummarize() {
+	store := NewSqliteStore(s.dbx)
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+
+	inputs := []TimeEntryInput{
+		{ProjectName: "Alpha", Date: "2026-01-02", DurationMinutes: 60},
+		{ProjectName: "Alpha", Date: "2026-01-03", DurationMinutes: 30},
+		{ProjectName: "Beta", Date: "2026-01-03", DurationMinutes: 45},
+		{ProjectName: "Beta", Date: "2026-02-01", DurationMinutes: 120},
+	}
+
+	for _, input := range inputs {
+		_, err := store.Create(ctx, input)
+		s.Require().NoError(err)
+	}
+
+	from := mustParseTime(time.DateOnly, "2026-01-01")
+	to := mustParseTime(time.DateOnly, "2026-01-31")
+
+	entries, err := store.List(ctx, TimeEntryFilter{From: from, To: to, Project: "alpha"})
+	s.NoError(err)
+	s.Len(entries, 2)
+
+	summary, err := store.Summarize(ctx, from, to)
+	s.NoError(err)
+	s.Equal([]ProjectHours{
+		{ProjectName: "Alpha", Minutes: 90, Hours: 1.5, Entries: 2},
+		{ProjectName: "Beta", Minutes: 45, Hours: 0.75, Entries: 1},
+	}, summary)
+}
+
+func TestTimeEntryStoreSuite(t *testing.T) {
+	suite.Run(t, new(TimeEntryStoreSuite))
+}
@@ -0,0 +1,26 @@
+package timeentries
+
+import (
+	"context"
+	"time"
+)
+
+// TimeEntryStore defines the interface for time entry data operations
+type TimeEntryStore interface {
+	// Create validates and inserts a time entry for an existing project
+	Create(ctx context.Context, input TimeEntryInput) (*TimeEntry, error)
+
+	// GetById retrieves a single time entry, returning sql.ErrNoRows if it does not exist
+	GetById(ctx context.Context, id int) (*TimeEntry, error)
+
+	// List retrieves entries matching the filter ordered by date and start time
+	List(ctx context.Context, filter TimeEntryFilter) ([]TimeEntry, error)
+
+	// Update replaces the writable fields of an entry, returning sql.ErrNoRows if it does not exist
+	Update(ctx context.Context, id int, input TimeEntryInput) (*TimeEntry, error)
+
+	Delete(ctx context.Context, id int) error
+
+	// Summarize totals tracked time per project for the inclusive date range
+	Summarize(ctx context.Context, from time.Time, to time.Time) ([]ProjectHours, error)
+}
@@ -0,0 +1,99 @@
+// Package tz carries the timezone a request's dates are computed in
+//
+// Clients send their IANA zone in the X-Timezone header so notes are filed on the client's
+// day wherever it is, requests without one use the server's configured zone
+package tz
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/justinas/alice"
+	"github.com/maybemaby/workpad/api/utils"
+
+	// Zones sent by clients must resolve on hosts without a zoneinfo database
+	_ "time/tzdata"
+)
+
+// Header is the request header clients send their IANA zone in
+const Header = "X-Timezone"
+
+type contextKey struct{}
+
+// WithLocation returns a copy of ctx whose dates are computed in loc
+func WithLocation(ctx context.Context, loc *time.Location) context.Context {
+	return context.WithValue(ctx, contextKey{}, loc)
+}
+
+// Location returns the zone of ctx, time.Local when none was set
+func Location(ctx context.Context) *time.Location {
+	if loc, ok := ctx.Value(contextKey{}).(*time.Location); ok {
+		return loc
+	}
+
+	return time.Local
+}
+
+// Today returns the current date in the zone of ctx, at midnight UTC like dates parsed from YYYY-MM-DD
+func Today(ctx context.Context) time.Time {
+	return Date(time.Now().In(Location(ctx)))
+}
+
+// Date returns the calendar date of t in its own zone, at midnight UTC
+func Date(t time.Time) time.Time {
+	year, month, day := t.Date()
+
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+var locations sync.Map
+
+// Load returns the zone named by an IANA name such as Europe/Berlin
+// Unlike time.LoadLocation it rejects "" and "Local", which name the server's zone rather than the client's
+func Load(name string) (*time.Location, error) {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+
+	if name == "" || name == "Local" {
+		return nil, fmt.Errorf("unknown timezone %q", name)
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q", name)
+	}
+
+	locations.Store(name, loc)
+
+	return loc, nil
+}
+
+// Middleware puts the zone of the X-Timezone header in the request context, or fallback when
+// the header is not sent. Unknown zones are rejected with 400
+func Middleware(fallback *time.Location) alice.Constructor {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			loc := fallback
+
+			if name := strings.TrimSpace(r.Header.Get(Header)); name != "" {
+				var err error
+
+				loc, err = Load(name)
+				if err != nil {
+					utils.ErrorJSON(w, utils.ErrorResponse{
+						Message: fmt.Sprintf("Unknown timezone %q in %s, expected an IANA name such as Europe/Berlin", name, Header),
+						Status:  http.StatusBadRequest,
+					}, http.StatusBadRequest)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithLocation(r.Context(), loc)))
+		})
+	}
+}
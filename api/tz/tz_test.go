@@ -0,0 +1,69 @@
+package tz
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	fallback, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	var got *time.Location
+
+	handler := Middleware(fallback)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = Location(r.Context())
+	}))
+
+	send := func(zone string) *httptest.ResponseRecorder {
+		got = nil
+
+		req := httptest.NewRequest(http.MethodGet, "/api/notes", nil)
+		if zone != "" {
+			req.Header.Set(Header, zone)
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		return w
+	}
+
+	w := send("America/New_York")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "America/New_York", got.String())
+
+	w = send("")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, fallback, got)
+
+	for _, zone := range []string{"Mars/Olympus", "Local", "../etc/passwd"} {
+		w = send(zone)
+		assert.Equal(t, http.StatusBadRequest, w.Code, zone)
+		assert.Contains(t, w.Body.String(), "Unknown timezone", zone)
+		assert.Nil(t, got, zone)
+	}
+}
+
+func TestToday(t *testing.T) {
+	// Fourteen hours ahead and eleven behind UTC are never on the same day
+	ahead, err := Load("Pacific/Kiritimati")
+	require.NoError(t, err)
+	behind, err := Load("Pacific/Pago_Pago")
+	require.NoError(t, err)
+
+	aheadToday := Today(WithLocation(context.Background(), ahead))
+	behindToday := Today(WithLocation(context.Background(), behind))
+
+	assert.Equal(t, aheadToday, behindToday.AddDate(0, 0, 1))
+	assert.Equal(t, time.UTC, aheadToday.Location())
+	assert.Equal(t, Date(time.Now().In(ahead)), aheadToday)
+
+	assert.Equal(t, time.Local, Location(context.Background()))
+}
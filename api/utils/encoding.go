@@ -2,6 +2,7 @@ package utils
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -24,10 +25,38 @@ func WriteJSON[T any](w http.ResponseWriter, r *http.Request, data T) error {
 }
 
 func ErrorJSON[T any](w http.ResponseWriter, error T, code int) {
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
 	_ = WriteJSON(w, nil, error)
 }
 
+// ErrorResponse is the JSON body of errors clients are expected to handle, such as rate limits
+type ErrorResponse struct {
+	Message string `json:"message" required:"true"`
+	Status  int    `json:"status" required:"true"`
+}
+
+// WriteTooLarge responds 413 for a body over limit bytes
+func WriteTooLarge(w http.ResponseWriter, limit int64) {
+	ErrorJSON(w, ErrorResponse{
+		Message: fmt.Sprintf("Request body exceeds the limit of %d bytes", limit),
+		Status:  http.StatusRequestEntityTooLarge,
+	}, http.StatusRequestEntityTooLarge)
+}
+
+// WriteBodyError responds to a body that could not be read, 413 when it was cut off
+// by http.MaxBytesReader and 400 otherwise
+func WriteBodyError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+
+	if errors.As(err, &maxBytesErr) {
+		WriteTooLarge(w, maxBytesErr.Limit)
+		return
+	}
+
+	http.Error(w, "Invalid request body", http.StatusBadRequest)
+}
+
 // CacheControlOpts represents Cache-Control response directives
 type CacheControlOpts struct {
 	Public          bool
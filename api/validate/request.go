@@ -0,0 +1,161 @@
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/justinas/alice"
+	"github.com/maybemaby/workpad/api/utils"
+)
+
+// ErrorResponse is returned with 400 when a request fails validation
+type ErrorResponse struct {
+	Message string       `json:"message" required:"true" example:"Invalid request"`
+	Status  int          `json:"status" required:"true" enum:"400"`
+	Errors  []FieldError `json:"errors" required:"true" nullable:"false"`
+}
+
+// Params reads the path and query params of r into v, a pointer to a struct, and checks them
+func Params(r *http.Request, v any) Errors {
+	value := reflect.ValueOf(v).Elem()
+
+	var errs Errors
+
+	for i := range value.NumField() {
+		field := value.Type().Field(i)
+
+		in, name, ok := paramName(field)
+		if !ok {
+			continue
+		}
+
+		raw := r.URL.Query().Get(name)
+		if in == "path" {
+			raw = r.PathValue(name)
+		}
+
+		if raw == "" {
+			if field.Tag.Get("required") == "true" {
+				errs = append(errs, FieldError{Field: name, Message: "is required"})
+			}
+			continue
+		}
+
+		if err := setParam(value.Field(i), raw); err != nil {
+			errs = append(errs, FieldError{Field: name, Message: err.Error()})
+			continue
+		}
+
+		checkValue(value.Field(i), field.Tag, name, &errs)
+	}
+
+	return errs
+}
+
+func setParam(field reflect.Value, raw string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Int, reflect.Int64:
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("must be an integer")
+		}
+		field.SetInt(parsed)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("must be true or false")
+		}
+		field.SetBool(parsed)
+	default:
+		return fmt.Errorf("has an unsupported type")
+	}
+
+	return nil
+}
+
+// jsonBody reports whether r's body should be decoded as JSON, bodies sent as text are left to the handler
+func jsonBody(r *http.Request) bool {
+	if r.Method != http.MethodPost && r.Method != http.MethodPut && r.Method != http.MethodPatch {
+		return false
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+
+	return err == nil && mediaType == "application/json"
+}
+
+// Request checks requests against the tags of prototype, a request struct or pointer to one,
+// before the handler runs. Invalid requests get 400 with every invalid field listed. The body
+// is read in full and replaced, so handlers decode it as before
+func Request(prototype any) alice.Constructor {
+	typ := reflect.TypeOf(prototype)
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	hasBody := false
+
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+
+		// Compile patterns now, so a broken one fails at startup instead of on the first request
+		if pattern := field.Tag.Get("pattern"); pattern != "" {
+			compilePattern(pattern)
+		}
+
+		if _, ok := bodyName(field); ok {
+			hasBody = true
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			req := reflect.New(typ)
+
+			var errs Errors
+
+			if hasBody && jsonBody(r) {
+				body, err := io.ReadAll(r.Body)
+				if err != nil {
+					utils.WriteBodyError(w, err)
+					return
+				}
+
+				r.Body = io.NopCloser(bytes.NewReader(body))
+
+				if err := json.Unmarshal(body, req.Interface()); err != nil {
+					utils.WriteBodyError(w, err)
+					return
+				}
+
+				errs = Struct(req.Interface())
+			}
+
+			errs = append(errs, Params(r, req.Interface())...)
+
+			if len(errs) > 0 {
+				utils.ErrorJSON(w, ErrorResponse{
+					Message: "Invalid request",
+					Status:  http.StatusBadRequest,
+					Errors:  errs,
+				}, http.StatusBadRequest)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,228 @@
+// Package validate enforces the constraints request structs declare for the OpenAPI spec
+//
+// The supported tags are those the spec generator reads:
+//
+//	required:"true"     path and query params must be present, body strings must not be blank
+//	                    and body slices must be set. Pointers may always be null
+//	enum:"a,b"          strings must be one of the listed values
+//	minLength, maxLength, pattern, format:"date"  constrain strings, lengths count characters
+//	minimum, maximum    constrain numbers
+//	minItems, maxItems  constrain slices
+//
+// Fields with a path or query tag are params, other fields are decoded from the JSON body
+// under their json name. Nested structs and slices of structs are checked too
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// FieldError is a constraint a field failed
+type FieldError struct {
+	Field   string `json:"field" required:"true" example:"name"`
+	Message string `json:"message" required:"true" example:"is required"`
+}
+
+// Errors lists every invalid field of a request
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+
+	for i, field := range e {
+		messages[i] = field.Field + " " + field.Message
+	}
+
+	return strings.Join(messages, ", ")
+}
+
+var patterns sync.Map
+
+func compilePattern(pattern string) *regexp.Regexp {
+	if compiled, ok := patterns.Load(pattern); ok {
+		return compiled.(*regexp.Regexp)
+	}
+
+	compiled := regexp.MustCompile(pattern)
+	patterns.Store(pattern, compiled)
+
+	return compiled
+}
+
+// paramName returns the path or query param a field is read from, if any
+func paramName(field reflect.StructField) (string, string, bool) {
+	if name := field.Tag.Get("path"); name != "" {
+		return "path", name, true
+	}
+
+	if name := field.Tag.Get("query"); name != "" {
+		return "query", name, true
+	}
+
+	return "", "", false
+}
+
+// bodyName returns the JSON name of a body field, false for params and ignored fields
+func bodyName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+
+	if _, _, ok := paramName(field); ok {
+		return "", false
+	}
+
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+
+	switch name {
+	case "-":
+		return "", false
+	case "":
+		return field.Name, true
+	}
+
+	return name, true
+}
+
+// Struct checks the body fields of v, a struct or pointer to one
+func Struct(v any) Errors {
+	value := reflect.Indirect(reflect.ValueOf(v))
+
+	var errs Errors
+	checkStruct(value, "", &errs)
+
+	return errs
+}
+
+func checkStruct(value reflect.Value, prefix string, errs *Errors) {
+	for i := range value.NumField() {
+		field := value.Type().Field(i)
+
+		name, ok := bodyName(field)
+		if !ok {
+			continue
+		}
+
+		// Embedded structs are flattened into the body like encoding/json does
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Tag.Get("json") == "" {
+			checkStruct(value.Field(i), prefix, errs)
+			continue
+		}
+
+		checkValue(value.Field(i), field.Tag, prefix+name, errs)
+	}
+}
+
+func checkValue(value reflect.Value, tag reflect.StructTag, name string, errs *Errors) {
+	fail := func(format string, args ...any) {
+		*errs = append(*errs, FieldError{Field: name, Message: fmt.Sprintf(format, args...)})
+	}
+
+	required := tag.Get("required") == "true"
+
+	switch value.Kind() {
+	case reflect.Pointer:
+		if !value.IsNil() {
+			checkValue(value.Elem(), tag, name, errs)
+		}
+
+	case reflect.String:
+		checkString(value.String(), tag, required, fail)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		checkNumber(float64(value.Int()), tag, fail)
+
+	case reflect.Float32, reflect.Float64:
+		checkNumber(value.Float(), tag, fail)
+
+	case reflect.Slice:
+		if value.IsNil() && required {
+			fail("is required")
+			return
+		}
+
+		if limit, ok := intTag(tag, "minItems"); ok && value.Len() < limit {
+			fail("must have at least %d items", limit)
+		}
+
+		if limit, ok := intTag(tag, "maxItems"); ok && value.Len() > limit {
+			fail("must have at most %d items", limit)
+		}
+
+		if value.Type().Elem().Kind() == reflect.Struct {
+			for i := range value.Len() {
+				checkStruct(value.Index(i), fmt.Sprintf("%s[%d].", name, i), errs)
+			}
+		}
+
+	case reflect.Struct:
+		if value.Type() != reflect.TypeFor[time.Time]() {
+			checkStruct(value, name+".", errs)
+		}
+	}
+}
+
+func checkString(value string, tag reflect.StructTag, required bool, fail func(string, ...any)) {
+	if strings.TrimSpace(value) == "" {
+		if required {
+			fail("is required")
+		}
+
+		// Optional strings are only constrained when set
+		return
+	}
+
+	length := utf8.RuneCountInString(value)
+
+	if limit, ok := intTag(tag, "minLength"); ok && length < limit {
+		fail("must be at least %d characters", limit)
+	}
+
+	if limit, ok := intTag(tag, "maxLength"); ok && length > limit {
+		fail("must be at most %d characters", limit)
+	}
+
+	if pattern := tag.Get("pattern"); pattern != "" && !compilePattern(pattern).MatchString(value) {
+		fail("must match %s", pattern)
+	}
+
+	if enum := tag.Get("enum"); enum != "" && !slices.Contains(strings.Split(enum, ","), value) {
+		fail("must be one of %s", strings.ReplaceAll(enum, ",", ", "))
+	}
+
+	if tag.Get("format") == "date" {
+		if _, err := time.Parse(time.DateOnly, value); err != nil {
+			fail("must be a date formatted YYYY-MM-DD")
+		}
+	}
+}
+
+func checkNumber(value float64, tag reflect.StructTag, fail func(string, ...any)) {
+	if limit, ok := floatTag(tag, "minimum"); ok && value < limit {
+		fail("must be at least %s", tag.Get("minimum"))
+	}
+
+	if limit, ok := floatTag(tag, "maximum"); ok && value > limit {
+		fail("must be at most %s", tag.Get("maximum"))
+	}
+}
+
+func intTag(tag reflect.StructTag, key string) (int, bool) {
+	value, err := strconv.Atoi(tag.Get(key))
+
+	return value, err == nil
+}
+
+func floatTag(tag reflect.StructTag, key string) (float64, bool) {
+	value, err := strconv.ParseFloat(tag.Get(key), 64)
+
+	return value, err == nil
+}
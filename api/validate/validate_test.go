@@ -0,0 +1,104 @@
+package validate
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type item struct {
+	Name string `json:"name" required:"true" maxLength:"5"`
+}
+
+type testRequest struct {
+	Id       int      `path:"id" required:"true" minimum:"1"`
+	Verbose  bool     `query:"verbose" required:"false"`
+	Date     string   `json:"date" required:"true" format:"date"`
+	Color    string   `json:"color" required:"false" enum:"red,green"`
+	Code     *string  `json:"code" required:"true" pattern:"^[A-Z]+$"`
+	Items    []item   `json:"items" required:"true" minItems:"1"`
+	Minutes  int      `json:"minutes" required:"false" minimum:"0" maximum:"600"`
+	Ignored  string   `json:"-"`
+	Projects []string `json:"projects" required:"false" maxItems:"2"`
+}
+
+func TestStruct(t *testing.T) {
+	code := "abc"
+
+	errs := Struct(&testRequest{
+		Date:     "19/10/2026",
+		Color:    "blue",
+		Code:     &code,
+		Items:    []item{{Name: "ok"}, {Name: "  "}, {Name: "toolong"}},
+		Minutes:  -1,
+		Projects: []string{"a", "b", "c"},
+	})
+
+	assert.Equal(t, Errors{
+		{Field: "date", Message: "must be a date formatted YYYY-MM-DD"},
+		{Field: "color", Message: "must be one of red, green"},
+		{Field: "code", Message: "must match ^[A-Z]+$"},
+		{Field: "items[1].name", Message: "is required"},
+		{Field: "items[2].name", Message: "must be at most 5 characters"},
+		{Field: "minutes", Message: "must be at least 0"},
+		{Field: "projects", Message: "must have at most 2 items"},
+	}, errs)
+
+	// Null pointers and unset optional fields are valid
+	assert.Empty(t, Struct(&testRequest{Date: "2026-10-19", Items: []item{{Name: "ok"}}}))
+
+	assert.Equal(t, Errors{
+		{Field: "date", Message: "is required"},
+		{Field: "items", Message: "is required"},
+	}, Struct(testRequest{}))
+}
+
+func serve(method string, target string, body string) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	mux.Handle("/things/{id}", Request(new(testRequest))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The handler still reads the body after validation
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	})))
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	return w
+}
+
+func TestRequest(t *testing.T) {
+	valid := `{"date":"2026-10-19","items":[{"name":"ok"}]}`
+
+	w := serve(http.MethodPost, "/things/1?verbose=true", valid)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, valid, w.Body.String())
+
+	w = serve(http.MethodPost, "/things/0?verbose=maybe", `{"items":[]}`)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var res ErrorResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&res))
+	assert.Equal(t, []FieldError{
+		{Field: "date", Message: "is required"},
+		{Field: "items", Message: "must have at least 1 items"},
+		{Field: "id", Message: "must be at least 1"},
+		{Field: "verbose", Message: "must be true or false"},
+	}, res.Errors)
+
+	w = serve(http.MethodPost, "/things/1", `{"date":`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Bodies are not decoded for reads, only params are checked
+	w = serve(http.MethodGet, "/things/abc", "")
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `{"field":"id","message":"must be an integer"}`)
+}
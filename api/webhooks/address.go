@@ -0,0 +1,113 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+var (
+	// errAddressNotAllowed is returned when a webhook points at the server's own network
+	errAddressNotAllowed = errors.New("destination address is not allowed")
+
+	errRequestTimeout = errors.New("request timed out")
+	errRequestFailed  = errors.New("request failed")
+)
+
+// reservedPrefixes are ranges outside the public internet that netip has no predicate for,
+// including the NAT64 and 6to4 ranges an IPv6 address can embed a private IPv4 address in
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("2002::/16"),
+}
+
+// publicAddr reports whether addr is on the public internet, rejecting loopback, private,
+// link-local (which holds cloud metadata services) and other reserved addresses
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// checkHost resolves host and fails unless every address it has is allowed
+func (s *WebhookService) checkHost(ctx context.Context, host string) error {
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return errors.New("url host could not be resolved")
+	}
+
+	for _, addr := range addrs {
+		if !s.allowed(addr) {
+			return errAddressNotAllowed
+		}
+	}
+
+	return nil
+}
+
+// newClient returns a client that refuses to connect to addresses s does not allow
+// Addresses are checked as each connection is made, after resolution, so a host that
+// resolves differently than when the webhook was created is still refused
+func (s *WebhookService) newClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil || !s.allowed(addrPort.Addr()) {
+				return errAddressNotAllowed
+			}
+
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// A proxy would make the connection on the dialer's behalf, unchecked
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			ForceAttemptHTTP2:   true,
+			TLSHandshakeTimeout: timeout,
+		},
+		// Redirects count as failed deliveries rather than being followed somewhere else
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// attemptError is the error recorded for a failed request, a fixed message so nothing the
+// destination or the network says is stored and shown back in the delivery log
+func attemptError(err error) error {
+	if errors.Is(err, errAddressNotAllowed) {
+		return errAddressNotAllowed
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return errRequestTimeout
+	}
+
+	return errRequestFailed
+}
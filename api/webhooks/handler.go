@@ -0,0 +1,111 @@
+package webhooks
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/maybemaby/workpad/api/utils"
+)
+
+const defaultDeliveryLimit = 50
+
+type WebhookHandler struct {
+	store WebhookStore
+}
+
+func NewWebhookHandler(store WebhookStore) *WebhookHandler {
+	return &WebhookHandler{store: store}
+}
+
+func parseId(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid webhook id", http.StatusBadRequest)
+		return 0, false
+	}
+
+	return id, true
+}
+
+func (h *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req CreateWebhookRequest
+
+	if err := utils.ReadJSON(r, &req); err != nil {
+		utils.WriteBodyError(w, err)
+		return
+	}
+
+	webhook, err := h.store.Create(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, ErrInvalidWebhook) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	utils.WriteJSON(w, r, webhook)
+}
+
+func (h *WebhookHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := h.store.List(r.Context())
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, r, webhooks)
+}
+
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseId(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.store.Delete(r.Context(), id); err != nil {
+		if errors.Is(err, ErrWebhookNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListDeliveries handles GET /webhooks/{id}/deliveries
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseId(w, r)
+	if !ok {
+		return
+	}
+
+	limit := defaultDeliveryLimit
+
+	if v := r.URL.Query().Get("limit"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 1 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = min(parsed, 500)
+	}
+
+	deliveries, err := h.store.Deliveries(r.Context(), id, limit)
+	if err != nil {
+		if errors.Is(err, ErrWebhookNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, r, deliveries)
+}
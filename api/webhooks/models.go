@@ -0,0 +1,81 @@
+package webhooks
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	EventNoteSaved       = "note.saved"
+	EventExcerptsUpdated = "excerpts.updated"
+	EventProjectCreated  = "project.created"
+	EventProjectDeleted  = "project.deleted"
+	EventProjectRenamed  = "project.renamed"
+)
+
+// Events lists every event a webhook can subscribe to
+var Events = []string{
+	EventNoteSaved,
+	EventExcerptsUpdated,
+	EventProjectCreated,
+	EventProjectDeleted,
+	EventProjectRenamed,
+}
+
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+)
+
+var (
+	ErrInvalidWebhook  = errors.New("invalid webhook")
+	ErrWebhookNotFound = errors.New("webhook not found")
+)
+
+type Webhook struct {
+	Id  int    `json:"id" required:"true"`
+	URL string `json:"url" required:"true" example:"https://chat.example.com/hooks/workpad"`
+	// Secret is only returned when the webhook is created
+	Secret string `json:"secret,omitempty" required:"false"`
+	// Events the webhook receives, empty means all of them
+	Events    []string  `json:"events" required:"true" db:"-" example:"[note.saved,project.created]"`
+	CreatedAt time.Time `json:"created_at" required:"true" db:"created_at"`
+}
+
+type CreateWebhookRequest struct {
+	URL string `json:"url" required:"true" example:"https://chat.example.com/hooks/workpad" pattern:"^https?://" maxLength:"2000"`
+	// Secret signs each delivery, one is generated when omitted
+	Secret string   `json:"secret,omitempty" required:"false"`
+	Events []string `json:"events,omitempty" required:"false" example:"[note.saved,project.created]"`
+}
+
+type WebhookIdRequest struct {
+	Id int `path:"id" example:"1" required:"true" minimum:"1"`
+}
+
+type ListDeliveriesRequest struct {
+	Id    int `path:"id" example:"1" required:"true" minimum:"1"`
+	Limit int `query:"limit" example:"50" required:"false" minimum:"1"`
+}
+
+// Delivery is one event queued for a webhook, along with the outcome of its latest attempt
+type Delivery struct {
+	Id             int        `json:"id" required:"true"`
+	WebhookId      int        `json:"webhook_id" required:"true" db:"webhook_id"`
+	Event          string     `json:"event" required:"true"`
+	Status         string     `json:"status" required:"true" enum:"pending,delivered,failed"`
+	Attempts       int        `json:"attempts" required:"true"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at" required:"true" db:"next_attempt_at"`
+	LastStatusCode *int       `json:"last_status_code,omitempty" required:"false" db:"last_status_code"`
+	LastError      *string    `json:"last_error,omitempty" required:"false" db:"last_error"`
+	CreatedAt      time.Time  `json:"created_at" required:"true" db:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty" required:"false" db:"delivered_at"`
+}
+
+// Envelope is the JSON body POSTed to webhooks
+type Envelope struct {
+	Event      string    `json:"event"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Data       any       `json:"data"`
+}
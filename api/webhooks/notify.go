@@ -0,0 +1,78 @@
+package webhooks
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/maybemaby/workpad/api/notes"
+	"github.com/maybemaby/workpad/api/projects"
+)
+
+type NoteSavedData struct {
+	NoteId      int       `json:"note_id"`
+	Date        string    `json:"date"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	HTMLContent string    `json:"html_content"`
+}
+
+type ExcerptsUpdatedData struct {
+	Date     string   `json:"date"`
+	Projects []string `json:"projects"`
+}
+
+type ProjectDeletedData struct {
+	Name string `json:"name"`
+}
+
+type ProjectRenamedData struct {
+	OldName string           `json:"old_name"`
+	Project projects.Project `json:"project"`
+}
+
+// WebhookService queues events for the changes notes and projects report to it, in the
+// transaction of the change, so the outbox never misses or invents a committed change
+var (
+	_ notes.Events    = (*WebhookService)(nil)
+	_ projects.Events = (*WebhookService)(nil)
+)
+
+func (s *WebhookService) NoteSaved(ctx context.Context, tx *sqlx.Tx, note notes.Note) error {
+	return s.Enqueue(ctx, tx, EventNoteSaved, NoteSavedData{
+		NoteId:      note.Id,
+		Date:        note.Date.Format(time.DateOnly),
+		UpdatedAt:   note.UpdatedAt,
+		HTMLContent: note.HTMLContent,
+	})
+}
+
+// ExcerptsSaved emits the projects created for an excerpt update, then the update itself
+func (s *WebhookService) ExcerptsSaved(ctx context.Context, tx *sqlx.Tx, date time.Time, saved notes.SavedExcerpts) error {
+	for _, project := range saved.Created {
+		if err := s.ProjectCreated(ctx, tx, project); err != nil {
+			return err
+		}
+	}
+
+	projectNames := saved.Projects
+	if projectNames == nil {
+		projectNames = []string{}
+	}
+
+	return s.Enqueue(ctx, tx, EventExcerptsUpdated, ExcerptsUpdatedData{
+		Date:     date.Format(time.DateOnly),
+		Projects: projectNames,
+	})
+}
+
+func (s *WebhookService) ProjectCreated(ctx context.Context, tx *sqlx.Tx, project projects.Project) error {
+	return s.Enqueue(ctx, tx, EventProjectCreated, project)
+}
+
+func (s *WebhookService) ProjectDeleted(ctx context.Context, tx *sqlx.Tx, name string) error {
+	return s.Enqueue(ctx, tx, EventProjectDeleted, ProjectDeletedData{Name: name})
+}
+
+func (s *WebhookService) ProjectRenamed(ctx context.Context, tx *sqlx.Tx, oldName string, project projects.Project) error {
+	return s.Enqueue(ctx, tx, EventProjectRenamed, ProjectRenamedData{OldName: oldName, Project: project})
+}
@@ -0,0 +1,381 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/maybemaby/workpad/api/workspaces"
+)
+
+const (
+	// SignatureHeader carries the hex HMAC-SHA256 of the body keyed with the webhook secret
+	SignatureHeader = "X-Workpad-Signature"
+	EventHeader     = "X-Workpad-Event"
+	DeliveryHeader  = "X-Workpad-Delivery"
+
+	// MaxAttempts is how many times a delivery is tried before it is marked failed
+	MaxAttempts = 8
+
+	baseRetryDelay = 30 * time.Second
+	maxRetryDelay  = time.Hour
+
+	// deliveryBatchSize bounds how many deliveries one DeliverDue call attempts
+	deliveryBatchSize = 50
+)
+
+type WebhookStore interface {
+	Create(ctx context.Context, req CreateWebhookRequest) (*Webhook, error)
+	List(ctx context.Context) ([]Webhook, error)
+	Delete(ctx context.Context, id int) error
+
+	// Deliveries returns the latest deliveries of a webhook, newest first
+	Deliveries(ctx context.Context, id int, limit int) ([]Delivery, error)
+}
+
+// WebhookService stores subscriptions and delivers events to them through an outbox table
+type WebhookService struct {
+	db     *sqlx.DB
+	client *http.Client
+	wake   chan struct{}
+	// allowed reports whether deliveries may connect to an address, publicAddr outside tests
+	allowed func(netip.Addr) bool
+}
+
+// NewWebhookService delivers with requests that give up after timeout
+// Webhooks can only point at public addresses, never the server's own network
+func NewWebhookService(db *sqlx.DB, timeout time.Duration) *WebhookService {
+	s := &WebhookService{
+		db:      db,
+		wake:    make(chan struct{}, 1),
+		allowed: publicAddr,
+	}
+
+	s.client = s.newClient(timeout)
+
+	return s
+}
+
+// Sign returns the signature of body sent in SignatureHeader, "sha256=" followed by the hex HMAC
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// retryDelay doubles the wait after each failed attempt, up to maxRetryDelay
+func retryDelay(attempts int) time.Duration {
+	delay := baseRetryDelay
+
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, maxRetryDelay)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.DateTime)
+}
+
+func splitEvents(events string) []string {
+	if events == "" {
+		return []string{}
+	}
+
+	return strings.Split(events, ",")
+}
+
+func (s *WebhookService) Create(ctx context.Context, req CreateWebhookRequest) (*Webhook, error) {
+	parsed, err := url.Parse(req.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, errors.Join(ErrInvalidWebhook, errors.New("url must be an absolute http or https URL"))
+	}
+
+	events := []string{}
+
+	for _, event := range req.Events {
+		if !slices.Contains(Events, event) {
+			return nil, errors.Join(ErrInvalidWebhook, fmt.Errorf("unknown event %q", event))
+		}
+
+		if !slices.Contains(events, event) {
+			events = append(events, event)
+		}
+	}
+
+	// Checked last, as it resolves the host
+	if err := s.checkHost(ctx, parsed.Hostname()); err != nil {
+		return nil, errors.Join(ErrInvalidWebhook, err)
+	}
+
+	secret := req.Secret
+
+	if secret == "" {
+		key := make([]byte, 32)
+		rand.Read(key)
+		secret = hex.EncodeToString(key)
+	}
+
+	webhook := Webhook{URL: req.URL, Secret: secret, Events: events}
+
+	ws, err := workspaces.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.QueryRowContext(ctx, `INSERT INTO webhooks (workspace_id, url, secret, events) VALUES (?, ?, ?, ?) RETURNING id, created_at`,
+		ws, req.URL, secret, strings.Join(events, ","),
+	).Scan(&webhook.Id, &webhook.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	return &webhook, nil
+}
+
+type webhookRow struct {
+	Id        int       `db:"id"`
+	URL       string    `db:"url"`
+	Secret    string    `db:"secret"`
+	Events    string    `db:"events"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func (s *WebhookService) List(ctx context.Context) ([]Webhook, error) {
+	var rows []webhookRow
+
+	ws, err := workspaces.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.SelectContext(ctx, &rows, `SELECT id, url, secret, events, created_at FROM webhooks WHERE workspace_id = ? ORDER BY id`, ws)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+
+	webhooks := make([]Webhook, 0, len(rows))
+
+	for _, row := range rows {
+		webhooks = append(webhooks, Webhook{
+			Id:        row.Id,
+			URL:       row.URL,
+			Events:    splitEvents(row.Events),
+			CreatedAt: row.CreatedAt,
+		})
+	}
+
+	return webhooks, nil
+}
+
+func (s *WebhookService) Delete(ctx context.Context, id int) error {
+	ws, err := workspaces.ID(ctx)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM webhooks WHERE workspace_id = ? AND id = ?`, ws, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+func (s *WebhookService) Deliveries(ctx context.Context, id int, limit int) ([]Delivery, error) {
+	var exists bool
+
+	ws, err := workspaces.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.GetContext(ctx, &exists, `SELECT EXISTS (SELECT 1 FROM webhooks WHERE workspace_id = ? AND id = ?)`, ws, id); err != nil {
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+
+	if !exists {
+		return nil, ErrWebhookNotFound
+	}
+
+	deliveries := []Delivery{}
+
+	err = s.db.SelectContext(ctx, &deliveries, `SELECT id, webhook_id, event, status, attempts, next_attempt_at,
+		last_status_code, last_error, created_at, delivered_at
+		FROM webhook_deliveries WHERE webhook_id = ? ORDER BY id DESC LIMIT ?`, id, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// Enqueue adds a delivery of the event to the outbox of every webhook of the workspace subscribed to it
+// The deliveries are written in tx, the transaction of the change the event describes
+func (s *WebhookService) Enqueue(ctx context.Context, tx *sqlx.Tx, event string, data any) error {
+	body, err := json.Marshal(Envelope{Event: event, OccurredAt: time.Now().UTC(), Data: data})
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	var subscribers []webhookRow
+
+	ws, err := workspaces.ID(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = tx.SelectContext(ctx, &subscribers, `SELECT id, url, secret, events, created_at FROM webhooks WHERE workspace_id = ?`, ws)
+	if err != nil {
+		return fmt.Errorf("failed to find webhooks: %w", err)
+	}
+
+	queued := false
+
+	for _, webhook := range subscribers {
+		events := splitEvents(webhook.Events)
+
+		if len(events) > 0 && !slices.Contains(events, event) {
+			continue
+		}
+
+		_, err := tx.ExecContext(ctx, `INSERT INTO webhook_deliveries (webhook_id, event, payload, next_attempt_at) VALUES (?, ?, ?, ?)`,
+			webhook.Id, event, string(body), formatTime(time.Now()),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to queue webhook delivery: %w", err)
+		}
+
+		queued = true
+	}
+
+	if queued {
+		// Wake the dispatcher without blocking, one pending signal is enough. It gives tx a moment
+		// to commit, deliveries of a transaction still open then are found on its next tick
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+
+	return nil
+}
+
+// Wake receives a value whenever new deliveries are queued
+func (s *WebhookService) Wake() <-chan struct{} {
+	return s.wake
+}
+
+type pendingDelivery struct {
+	Id       int    `db:"id"`
+	Event    string `db:"event"`
+	Payload  string `db:"payload"`
+	Attempts int    `db:"attempts"`
+	URL      string `db:"url"`
+	Secret   string `db:"secret"`
+}
+
+// DeliverDue attempts every pending delivery whose next attempt is due at now
+// Failed attempts are rescheduled with exponential backoff until MaxAttempts is reached
+// Returns the number of deliveries that succeeded
+func (s *WebhookService) DeliverDue(ctx context.Context, now time.Time) (int, error) {
+	var due []pendingDelivery
+
+	err := s.db.SelectContext(ctx, &due, `SELECT d.id, d.event, d.payload, d.attempts, w.url, w.secret
+		FROM webhook_deliveries d JOIN webhooks w ON w.id = d.webhook_id
+		WHERE d.status = 'pending' AND d.next_attempt_at <= ?
+		ORDER BY d.id LIMIT ?`, formatTime(now), deliveryBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find due deliveries: %w", err)
+	}
+
+	delivered := 0
+
+	for _, delivery := range due {
+		if ctx.Err() != nil {
+			return delivered, ctx.Err()
+		}
+
+		statusCode, attemptErr := s.send(ctx, delivery)
+		attempts := delivery.Attempts + 1
+
+		var code *int
+		if statusCode != 0 {
+			code = &statusCode
+		}
+
+		if attemptErr == nil {
+			_, err = s.db.ExecContext(ctx, `UPDATE webhook_deliveries SET status = 'delivered', attempts = ?, last_status_code = ?, last_error = NULL, delivered_at = ? WHERE id = ?`,
+				attempts, code, formatTime(now), delivery.Id)
+			if err != nil {
+				return delivered, fmt.Errorf("failed to record delivery: %w", err)
+			}
+
+			delivered++
+			continue
+		}
+
+		status := StatusPending
+		if attempts >= MaxAttempts {
+			status = StatusFailed
+		}
+
+		_, err = s.db.ExecContext(ctx, `UPDATE webhook_deliveries SET status = ?, attempts = ?, last_status_code = ?, last_error = ?, next_attempt_at = ? WHERE id = ?`,
+			status, attempts, code, attemptErr.Error(), formatTime(now.Add(retryDelay(attempts))), delivery.Id)
+		if err != nil {
+			return delivered, fmt.Errorf("failed to record delivery attempt: %w", err)
+		}
+	}
+
+	return delivered, nil
+}
+
+// send POSTs one delivery, any non 2xx response counts as a failure
+// Its error is stored in the delivery log, so it only ever says what kind of failure it was
+func (s *WebhookService) send(ctx context.Context, delivery pendingDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "workpad-webhooks")
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, fmt.Sprint(delivery.Id))
+	req.Header.Set(SignatureHeader, Sign(delivery.Secret, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, attemptError(err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp.StatusCode, nil
+	}
+
+	// Only the status is recorded, the body is never stored or shown back
+	return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+}
@@ -0,0 +1,386 @@
+package webhooks
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/maybemaby/workpad/api/notes"
+	"github.com/maybemaby/workpad/api/projects"
+	"github.com/maybemaby/workpad/api/utils"
+	"github.com/maybemaby/workpad/api/workspaces"
+	"github.com/stretchr/testify/suite"
+	_ "modernc.org/sqlite"
+)
+
+type received struct {
+	event     string
+	signature string
+	body      []byte
+}
+
+// receiver records webhook requests and answers with status
+type receiver struct {
+	mu       sync.Mutex
+	status   int
+	requests []received
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.requests = append(rc.requests, received{
+		event:     r.Header.Get(EventHeader),
+		signature: r.Header.Get(SignatureHeader),
+		body:      body,
+	})
+
+	w.WriteHeader(rc.status)
+}
+
+type WebhookStoreSuite struct {
+	suite.Suite
+	db       *sql.DB
+	dbx      *sqlx.DB
+	store    *WebhookService
+	receiver *receiver
+	server   *httptest.Server
+	notes    *notes.NoteService
+	projects *projects.SqliteStore
+}
+
+func (s *WebhookStoreSuite) SetupTest() {
+	s.db, _ = sql.Open("sqlite", ":memory:")
+	s.db.SetMaxOpenConns(1)
+	s.dbx = sqlx.NewDb(s.db, "sqlite")
+
+	err := utils.SetupSqliteDb(s.db)
+
+	if err != nil {
+		panic(err)
+	}
+
+	s.receiver = &receiver{status: http.StatusOK}
+	s.server = httptest.NewServer(s.receiver)
+	s.store = NewWebhookService(s.dbx, 5*time.Second)
+	// The receiver listens on loopback, which real webhooks cannot point at
+	s.store.allowed = func(addr netip.Addr) bool { return addr.IsLoopback() }
+
+	s.notes = notes.NewNoteService(s.dbx).WithEvents(s.store)
+	s.projects = projects.NewSqliteStore(s.dbx).WithEvents(s.store)
+}
+
+func (s *WebhookStoreSuite) TearDownTest() {
+	s.server.Close()
+	s.db.Close()
+}
+
+func (s *WebhookStoreSuite) TestDeliverDue_SignsAndDelivers() {
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+
+	webhook, err := s.store.Create(ctx, CreateWebhookRequest{URL: s.server.URL, Secret: "shh"})
+	s.Require().NoError(err)
+
+	_, err = s.projects.Create(ctx, "OKT123")
+	s.Require().NoError(err)
+
+	// Creating an existing project is an upsert and emits nothing
+	_, err = s.projects.Create(ctx, "OKT123")
+	s.Require().NoError(err)
+
+	_, err = s.notes.CreateNote(ctx, "<p>Vendor call</p>", time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC))
+	s.Require().NoError(err)
+
+	delivered, err := s.store.DeliverDue(ctx, time.Now())
+	s.Require().NoError(err)
+	s.Equal(2, delivered)
+
+	s.Require().Len(s.receiver.requests, 2)
+
+	first := s.receiver.requests[0]
+	s.Equal(EventProjectCreated, first.event)
+	s.Equal(Sign("shh", first.body), first.signature)
+
+	var envelope struct {
+		Event string `json:"event"`
+		Data  struct {
+			Name string `json:"name"`
+		} `json:"data"`
+	}
+	s.Require().NoError(json.Unmarshal(first.body, &envelope))
+	s.Equal(EventProjectCreated, envelope.Event)
+	s.Equal("OKT123", envelope.Data.Name)
+
+	s.Equal(EventNoteSaved, s.receiver.requests[1].event)
+
+	deliveries, err := s.store.Deliveries(ctx, webhook.Id, 10)
+	s.Require().NoError(err)
+	s.Require().Len(deliveries, 2)
+	s.Equal(StatusDelivered, deliveries[0].Status)
+	s.Equal(1, deliveries[0].Attempts)
+
+	// Nothing is redelivered
+	delivered, err = s.store.DeliverDue(ctx, time.Now())
+	s.Require().NoError(err)
+	s.Zero(delivered)
+}
+
+func (s *WebhookStoreSuite) TestDeliverDue_FiltersEvents() {
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+
+	_, err := s.store.Create(ctx, CreateWebhookRequest{URL: s.server.URL, Events: []string{EventProjectRenamed}})
+	s.Require().NoError(err)
+
+	_, err = s.projects.Create(ctx, "OKT123")
+	s.Require().NoError(err)
+
+	_, err = s.projects.Rename(ctx, "OKT123", "OKT124")
+	s.Require().NoError(err)
+
+	_, err = s.store.DeliverDue(ctx, time.Now())
+	s.Require().NoError(err)
+
+	s.Require().Len(s.receiver.requests, 1)
+	s.Equal(EventProjectRenamed, s.receiver.requests[0].event)
+	s.Contains(string(s.receiver.requests[0].body), `"old_name":"OKT123"`)
+}
+
+func (s *WebhookStoreSuite) TestSaveNote_EmitsCreatedProjects() {
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+
+	_, err := s.store.Create(ctx, CreateWebhookRequest{URL: s.server.URL})
+	s.Require().NoError(err)
+
+	_, _, err = s.notes.SaveNote(ctx, time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), "<p>Vendor call</p>",
+		[]notes.ExcerptNode{{Projects: []string{"OKT123"}, Node: "<p>Vendor call</p>"}}, notes.SaveExcerptsOptions{CreateProjects: true})
+	s.Require().NoError(err)
+
+	_, err = s.store.DeliverDue(ctx, time.Now())
+	s.Require().NoError(err)
+
+	s.Require().Len(s.receiver.requests, 3)
+	s.Equal(EventNoteSaved, s.receiver.requests[0].event)
+	s.Equal(EventProjectCreated, s.receiver.requests[1].event)
+	s.Contains(string(s.receiver.requests[1].body), `"name":"OKT123"`)
+	s.Equal(EventExcerptsUpdated, s.receiver.requests[2].event)
+	s.Contains(string(s.receiver.requests[2].body), `"projects":["OKT123"]`)
+}
+
+func (s *WebhookStoreSuite) TestSaveNote_UnchangedEmitsNothing() {
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+	date := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+
+	_, err := s.store.Create(ctx, CreateWebhookRequest{URL: s.server.URL})
+	s.Require().NoError(err)
+
+	save := func(content string, projectNames ...string) {
+		_, _, err := s.notes.SaveNote(ctx, date, content,
+			[]notes.ExcerptNode{{Projects: projectNames, Node: "<p>Vendor call</p>"}}, notes.SaveExcerptsOptions{CreateProjects: true})
+		s.Require().NoError(err)
+	}
+
+	save("<p>Vendor call</p>", "OKT123")
+
+	// Autosaves of the same content and excerpts
+	save("<p>Vendor call</p>", "OKT123")
+	save("<p>Vendor call</p>", "OKT123")
+
+	_, err = s.store.DeliverDue(ctx, time.Now())
+	s.Require().NoError(err)
+	s.Len(s.receiver.requests, 3)
+
+	// The note changes while the excerpts stay the same, then the excerpts change
+	save("<p>Vendor call</p><p>Follow up</p>", "OKT123")
+	save("<p>Vendor call</p><p>Follow up</p>", "OKT124")
+
+	_, err = s.store.DeliverDue(ctx, time.Now())
+	s.Require().NoError(err)
+
+	var events []string
+	for _, request := range s.receiver.requests[3:] {
+		events = append(events, request.event)
+	}
+
+	s.Equal([]string{EventNoteSaved, EventExcerptsUpdated, EventProjectCreated, EventExcerptsUpdated}, events)
+}
+
+func (s *WebhookStoreSuite) TestEnqueue_FailureRollsBackChange() {
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+
+	_, err := s.store.Create(ctx, CreateWebhookRequest{URL: s.server.URL})
+	s.Require().NoError(err)
+
+	_, err = s.dbx.ExecContext(ctx, `CREATE TRIGGER fail_deliveries BEFORE INSERT ON webhook_deliveries BEGIN SELECT RAISE(ABORT, 'outbox unavailable'); END`)
+	s.Require().NoError(err)
+
+	_, err = s.notes.CreateNote(ctx, "<p>Vendor call</p>", time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC))
+	s.ErrorContains(err, "outbox unavailable")
+
+	_, err = s.notes.GetNoteByDate(ctx, time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC))
+	s.ErrorIs(err, sql.ErrNoRows)
+
+	_, err = s.projects.Create(ctx, "OKT123")
+	s.ErrorContains(err, "outbox unavailable")
+
+	_, err = s.projects.GetByName(ctx, "OKT123")
+	s.Error(err)
+}
+
+func (s *WebhookStoreSuite) TestDeliverDue_RetriesWithBackoff() {
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+	s.receiver.status = http.StatusBadGateway
+
+	webhook, err := s.store.Create(ctx, CreateWebhookRequest{URL: s.server.URL})
+	s.Require().NoError(err)
+
+	_, err = s.projects.Create(ctx, "OKT123")
+	s.Require().NoError(err)
+
+	now := time.Now()
+
+	_, err = s.store.DeliverDue(ctx, now)
+	s.Require().NoError(err)
+
+	deliveries, err := s.store.Deliveries(ctx, webhook.Id, 10)
+	s.Require().NoError(err)
+	s.Require().Len(deliveries, 1)
+	s.Equal(StatusPending, deliveries[0].Status)
+	s.Equal(1, deliveries[0].Attempts)
+	s.Require().NotNil(deliveries[0].LastStatusCode)
+	s.Equal(http.StatusBadGateway, *deliveries[0].LastStatusCode)
+	s.Require().NotNil(deliveries[0].LastError)
+	s.Equal("unexpected status 502", *deliveries[0].LastError)
+
+	// Not retried before the backoff elapses
+	_, err = s.store.DeliverDue(ctx, now.Add(retryDelay(1)/2))
+	s.Require().NoError(err)
+	s.Len(s.receiver.requests, 1)
+
+	for attempt := 1; attempt < MaxAttempts; attempt++ {
+		now = now.Add(retryDelay(attempt))
+
+		_, err = s.store.DeliverDue(ctx, now)
+		s.Require().NoError(err)
+	}
+
+	s.Len(s.receiver.requests, MaxAttempts)
+
+	deliveries, err = s.store.Deliveries(ctx, webhook.Id, 10)
+	s.Require().NoError(err)
+	s.Equal(StatusFailed, deliveries[0].Status)
+	s.Equal(MaxAttempts, deliveries[0].Attempts)
+}
+
+func (s *WebhookStoreSuite) TestCreate_Validates() {
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+
+	_, err := s.store.Create(ctx, CreateWebhookRequest{URL: "ftp://example.com"})
+	s.ErrorIs(err, ErrInvalidWebhook)
+
+	_, err = s.store.Create(ctx, CreateWebhookRequest{URL: s.server.URL, Events: []string{"note.deleted"}})
+	s.ErrorIs(err, ErrInvalidWebhook)
+
+	webhook, err := s.store.Create(ctx, CreateWebhookRequest{URL: s.server.URL})
+	s.Require().NoError(err)
+	s.Len(webhook.Secret, 64)
+
+	listed, err := s.store.List(ctx)
+	s.Require().NoError(err)
+	s.Require().Len(listed, 1)
+	s.Empty(listed[0].Secret)
+}
+
+func (s *WebhookStoreSuite) TestCreate_RejectsPrivateAddresses() {
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+	s.store.allowed = publicAddr
+
+	for _, target := range []string{
+		s.server.URL,
+		"http://localhost/hook",
+		"http://10.1.2.3/hook",
+		"http://192.168.0.10/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[::1]/hook",
+		"http://[fd00::1]/hook",
+		"http://[::ffff:127.0.0.1]/hook",
+		"http://0.0.0.0/hook",
+	} {
+		_, err := s.store.Create(ctx, CreateWebhookRequest{URL: target})
+		s.ErrorIs(err, ErrInvalidWebhook, target)
+	}
+
+	s.True(publicAddr(netip.MustParseAddr("93.184.216.34")))
+	s.True(publicAddr(netip.MustParseAddr("2606:2800:220:1::1")))
+}
+
+func (s *WebhookStoreSuite) TestDeliverDue_RefusesPrivateAddresses() {
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+
+	webhook, err := s.store.Create(ctx, CreateWebhookRequest{URL: s.server.URL})
+	s.Require().NoError(err)
+
+	_, err = s.projects.Create(ctx, "OKT123")
+	s.Require().NoError(err)
+
+	// As if the host resolved to a public address when the webhook was created, and no longer does
+	s.store.allowed = publicAddr
+
+	delivered, err := s.store.DeliverDue(ctx, time.Now())
+	s.Require().NoError(err)
+	s.Zero(delivered)
+	s.Empty(s.receiver.requests)
+
+	deliveries, err := s.store.Deliveries(ctx, webhook.Id, 10)
+	s.Require().NoError(err)
+	s.Require().Len(deliveries, 1)
+	s.Nil(deliveries[0].LastStatusCode)
+	s.Require().NotNil(deliveries[0].LastError)
+	s.Equal(errAddressNotAllowed.Error(), *deliveries[0].LastError)
+}
+
+func (s *WebhookStoreSuite) TestRename_MovesReferences() {
+	ctx := workspaces.WithID(s.T().Context(), workspaces.DefaultID)
+
+	_, err := s.projects.CreateMultiple(ctx, []string{"OKT123", "Client"})
+	s.Require().NoError(err)
+
+	parent := "OKT123"
+	_, err = s.projects.SetParent(ctx, "Client", &parent)
+	s.Require().NoError(err)
+
+	_, err = s.notes.CreateNote(ctx, "<p>Vendor call</p>", time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC))
+	s.Require().NoError(err)
+
+	_, err = s.notes.UpdateExcerptsForDate(ctx, time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), []notes.ExcerptNode{{Projects: []string{"OKT123"}, Node: "<p>Vendor call</p>"}}, notes.SaveExcerptsOptions{})
+	s.Require().NoError(err)
+
+	_, err = s.projects.Rename(ctx, "OKT123", "Client")
+	s.ErrorIs(err, projects.ErrNameConflict)
+
+	renamed, err := s.projects.Rename(ctx, "OKT123", "OKT124")
+	s.Require().NoError(err)
+	s.Equal("OKT124", renamed.Name)
+
+	excerpts, _, err := s.notes.GetExcerptsForProject(ctx, "OKT124", notes.ExcerptOptions{})
+	s.Require().NoError(err)
+	s.Len(excerpts, 1)
+
+	child, err := s.projects.GetByName(ctx, "Client")
+	s.Require().NoError(err)
+	s.Require().NotNil(child.ParentName)
+	s.Equal("OKT124", *child.ParentName)
+}
+
+func TestWebhookStoreSuite(t *testing.T) {
+	suite.Run(t, new(WebhookStoreSuite))
+}
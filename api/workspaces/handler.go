@@ -0,0 +1,44 @@
+package workspaces
+
+import (
+	"net/http"
+
+	"github.com/maybemaby/workpad/api/utils"
+)
+
+type WorkspaceHandler struct {
+	store WorkspaceStore
+}
+
+func NewWorkspaceHandler(store WorkspaceStore) *WorkspaceHandler {
+	return &WorkspaceHandler{store: store}
+}
+
+// ListWorkspaces handles GET /workspaces, listing the workspaces the user can work in
+// In single-user mode that is every workspace
+func (h *WorkspaceHandler) ListWorkspaces(w http.ResponseWriter, r *http.Request) {
+	var userId int64
+
+	if user := UserFrom(r.Context()); user != nil {
+		userId = user.Id
+	}
+
+	workspaces, err := h.store.List(r.Context(), userId)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	active, err := ID(r.Context())
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	for i := range workspaces {
+		workspaces[i].Active = workspaces[i].Id == active
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	utils.WriteJSON(w, r, workspaces)
+}
@@ -0,0 +1,182 @@
+package workspaces
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/justinas/alice"
+)
+
+// Authenticate puts the user a request's bearer token belongs to in its context
+// Requests without a valid token continue anonymously, Middleware rejects them once users exist
+func Authenticate(store WorkspaceStore) alice.Constructor {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || token == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			user, err := store.UserByToken(r.Context(), token)
+			if err != nil {
+				if errors.Is(err, ErrUserNotFound) {
+					next.ServeHTTP(w, r)
+					return
+				}
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithUser(r.Context(), user)))
+		})
+	}
+}
+
+// Middleware requires the user Authenticate found once users exist, and puts the workspace the
+// request works in in its context. The workspace is the one named by the X-Workspace header, else
+// the user's first. In single-user mode requests work in the default workspace
+func Middleware(store WorkspaceStore) alice.Constructor {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			user := UserFrom(ctx)
+
+			if user == nil {
+				multiUser, err := store.HasUsers(ctx)
+				if err != nil {
+					http.Error(w, "Internal server error", http.StatusInternalServerError)
+					return
+				}
+
+				if multiUser {
+					http.Error(w, "Unauthorized", http.StatusUnauthorized)
+					return
+				}
+			}
+
+			id, ok := resolveWorkspace(w, r, store, user, strings.TrimSpace(r.Header.Get(Header)))
+			if !ok {
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithID(ctx, id)))
+		})
+	}
+}
+
+// resolveWorkspace returns the workspace named by raw, which user must be a member of, else the user's
+// first or the default workspace in single-user mode. It responds with the error itself when there is none
+func resolveWorkspace(w http.ResponseWriter, r *http.Request, store WorkspaceStore, user *User, raw string) (int64, bool) {
+	ctx := r.Context()
+
+	if raw == "" {
+		if user == nil {
+			return DefaultID, true
+		}
+
+		workspaces, err := store.List(ctx, user.Id)
+		if err != nil {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return 0, false
+		}
+
+		if len(workspaces) == 0 {
+			http.Error(w, "Not a member of any workspace", http.StatusForbidden)
+			return 0, false
+		}
+
+		return workspaces[0].Id, true
+	}
+
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || id < 1 {
+		http.Error(w, "Invalid workspace id", http.StatusBadRequest)
+		return 0, false
+	}
+
+	if _, err := store.Get(ctx, id); err != nil {
+		if errors.Is(err, ErrWorkspaceNotFound) {
+			http.Error(w, "Workspace not found", http.StatusNotFound)
+			return 0, false
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return 0, false
+	}
+
+	if user != nil {
+		member, err := store.IsMember(ctx, id, user.Id)
+		if err != nil {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return 0, false
+		}
+
+		if !member {
+			http.Error(w, "Not a member of the workspace", http.StatusForbidden)
+			return 0, false
+		}
+	}
+
+	return id, true
+}
+
+// FeedMiddleware authenticates feed readers, which cannot send headers, and puts the workspace named
+// by the workspace query parameter in the request context. Once users exist readers send a user's API
+// token as the token query parameter and get the workspaces of that user. In single-user mode they
+// send feedToken instead, and feeds are disabled when it is empty
+func FeedMiddleware(store WorkspaceStore, feedToken string) alice.Constructor {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			provided := r.URL.Query().Get("token")
+
+			if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+				provided = bearer
+			}
+
+			multiUser, err := store.HasUsers(ctx)
+			if err != nil {
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+
+			var user *User
+
+			switch {
+			case multiUser:
+				if provided != "" {
+					user, err = store.UserByToken(ctx, provided)
+				}
+
+				if provided == "" || errors.Is(err, ErrUserNotFound) {
+					http.Error(w, "Unauthorized", http.StatusUnauthorized)
+					return
+				}
+
+				if err != nil {
+					http.Error(w, "Internal server error", http.StatusInternalServerError)
+					return
+				}
+
+				ctx = WithUser(ctx, user)
+			case feedToken == "":
+				http.Error(w, "Feeds are disabled, set FEED_TOKEN to enable them", http.StatusNotFound)
+				return
+			case subtle.ConstantTimeCompare([]byte(provided), []byte(feedToken)) != 1:
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			id, ok := resolveWorkspace(w, r, store, user, r.URL.Query().Get("workspace"))
+			if !ok {
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithID(ctx, id)))
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package workspaces
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/justinas/alice"
+	"github.com/maybemaby/workpad/api/utils"
+	"github.com/stretchr/testify/suite"
+	_ "modernc.org/sqlite"
+)
+
+type MiddlewareSuite struct {
+	suite.Suite
+	db    *sql.DB
+	store *SqliteStore
+}
+
+func (s *MiddlewareSuite) SetupTest() {
+	s.db, _ = sql.Open("sqlite", ":memory:")
+	s.db.SetMaxOpenConns(1)
+
+	if err := utils.SetupSqliteDb(s.db); err != nil {
+		panic(err)
+	}
+
+	s.store = NewSqliteStore(sqlx.NewDb(s.db, "sqlite"))
+}
+
+func (s *MiddlewareSuite) TearDownTest() {
+	s.db.Close()
+}
+
+// send returns the response status and the workspace the request worked in, 0 when it was rejected
+func (s *MiddlewareSuite) send(token string, workspace string) (int, int64) {
+	var got int64
+
+	handler := alice.New(Authenticate(s.store), Middleware(s.store)).ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = ID(r.Context())
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/projects", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if workspace != "" {
+		req.Header.Set(Header, workspace)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	return w.Code, got
+}
+
+func (s *MiddlewareSuite) TestSingleUser() {
+	code, id := s.send("", "")
+	s.Equal(http.StatusOK, code)
+	s.Equal(DefaultID, id)
+
+	// Tokens meant for a proxy in front of the server are ignored
+	code, id = s.send("proxy-token", "")
+	s.Equal(http.StatusOK, code)
+	s.Equal(DefaultID, id)
+
+	team, err := s.store.Create(s.T().Context(), "Team")
+	s.Require().NoError(err)
+
+	code, id = s.send("", "2")
+	s.Equal(http.StatusOK, code)
+	s.Equal(team.Id, id)
+
+	code, _ = s.send("", "9")
+	s.Equal(http.StatusNotFound, code)
+
+	code, _ = s.send("", "team")
+	s.Equal(http.StatusBadRequest, code)
+}
+
+func (s *MiddlewareSuite) TestMultiUser() {
+	ctx := s.T().Context()
+
+	team, err := s.store.Create(ctx, "Team")
+	s.Require().NoError(err)
+
+	ada, adaToken, err := s.store.CreateUser(ctx, "ada")
+	s.Require().NoError(err)
+	s.Require().NoError(s.store.AddMember(ctx, team.Id, ada.Id))
+
+	_, bobToken, err := s.store.CreateUser(ctx, "bob")
+	s.Require().NoError(err)
+
+	code, _ := s.send("", "")
+	s.Equal(http.StatusUnauthorized, code)
+
+	code, _ = s.send("not-a-token", "")
+	s.Equal(http.StatusUnauthorized, code)
+
+	// Without the header users work in their first workspace
+	code, id := s.send(adaToken, "")
+	s.Equal(http.StatusOK, code)
+	s.Equal(team.Id, id)
+
+	code, _ = s.send(adaToken, "1")
+	s.Equal(http.StatusForbidden, code)
+
+	code, _ = s.send(bobToken, "")
+	s.Equal(http.StatusForbidden, code)
+
+	code, _ = s.send(bobToken, "2")
+	s.Equal(http.StatusForbidden, code)
+}
+
+// feed returns the response status and the workspace a feed request worked in, 0 when it was rejected
+func (s *MiddlewareSuite) feed(token string, workspace string) (int, int64) {
+	var got int64
+
+	handler := FeedMiddleware(s.store, "feed-token")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = ID(r.Context())
+	}))
+
+	query := url.Values{}
+	if token != "" {
+		query.Set("token", token)
+	}
+	if workspace != "" {
+		query.Set("workspace", workspace)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/feeds/notes.atom?"+query.Encode(), nil))
+
+	return w.Code, got
+}
+
+func (s *MiddlewareSuite) TestFeed() {
+	ctx := s.T().Context()
+
+	team, err := s.store.Create(ctx, "Team")
+	s.Require().NoError(err)
+
+	// In single-user mode the feed token opens every workspace
+	code, id := s.feed("feed-token", "2")
+	s.Equal(http.StatusOK, code)
+	s.Equal(team.Id, id)
+
+	code, _ = s.feed("wrong", "")
+	s.Equal(http.StatusUnauthorized, code)
+
+	ada, adaToken, err := s.store.CreateUser(ctx, "ada")
+	s.Require().NoError(err)
+	s.Require().NoError(s.store.AddMember(ctx, team.Id, ada.Id))
+
+	// Once users exist readers need a user's token and only get that user's workspaces
+	code, _ = s.feed("feed-token", "1")
+	s.Equal(http.StatusUnauthorized, code)
+
+	code, _ = s.feed(adaToken, "1")
+	s.Equal(http.StatusForbidden, code)
+
+	code, id = s.feed(adaToken, "")
+	s.Equal(http.StatusOK, code)
+	s.Equal(team.Id, id)
+}
+
+func (s *MiddlewareSuite) TestCreateUser() {
+	ctx := s.T().Context()
+
+	user, token, err := s.store.CreateUser(ctx, " ada ")
+	s.Require().NoError(err)
+	s.Equal("ada", user.Name)
+	s.Len(token, 64)
+
+	_, _, err = s.store.CreateUser(ctx, "Ada")
+	s.ErrorIs(err, ErrUserExists)
+
+	found, err := s.store.UserByToken(ctx, token)
+	s.Require().NoError(err)
+	s.Equal(user.Id, found.Id)
+
+	// Only the hash of the token is stored
+	var stored int
+	s.Require().NoError(s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE token_hash = ?`, token).Scan(&stored))
+	s.Zero(stored)
+}
+
+func (s *MiddlewareSuite) TestIDWithoutWorkspace() {
+	// Code that skipped Middleware must not fall back to the default workspace
+	_, err := ID(s.T().Context())
+	s.ErrorIs(err, ErrNoWorkspace)
+
+	id, err := ID(WithID(s.T().Context(), 2))
+	s.Require().NoError(err)
+	s.Equal(int64(2), id)
+}
+
+func TestMiddlewareSuite(t *testing.T) {
+	suite.Run(t, new(MiddlewareSuite))
+}
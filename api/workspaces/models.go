@@ -0,0 +1,26 @@
+package workspaces
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrWorkspaceNotFound = errors.New("workspace not found")
+	ErrUserNotFound      = errors.New("user not found")
+	ErrUserExists        = errors.New("user already exists")
+)
+
+type Workspace struct {
+	Id        int64     `json:"id" required:"true" example:"1"`
+	Name      string    `json:"name" required:"true" example:"Default"`
+	CreatedAt time.Time `json:"created_at" required:"true" db:"created_at"`
+	// Active is set on the workspace the request worked in
+	Active bool `json:"active" required:"true" db:"-"`
+}
+
+type User struct {
+	Id        int64     `json:"id" required:"true"`
+	Name      string    `json:"name" required:"true"`
+	CreatedAt time.Time `json:"created_at" required:"true" db:"created_at"`
+}
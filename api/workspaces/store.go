@@ -0,0 +1,205 @@
+package workspaces
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type WorkspaceStore interface {
+	// UserByToken returns the user an API token belongs to, ErrUserNotFound for unknown tokens
+	UserByToken(ctx context.Context, token string) (*User, error)
+
+	// HasUsers reports whether any user exists, the server is single-user until one does
+	HasUsers(ctx context.Context) (bool, error)
+
+	// Get returns a workspace, ErrWorkspaceNotFound when it does not exist
+	Get(ctx context.Context, id int64) (*Workspace, error)
+
+	// List returns every workspace, or those of userId when it is not zero, oldest first
+	List(ctx context.Context, userId int64) ([]Workspace, error)
+
+	// IsMember reports whether userId belongs to workspace id
+	IsMember(ctx context.Context, id int64, userId int64) (bool, error)
+}
+
+type SqliteStore struct {
+	db *sqlx.DB
+}
+
+func NewSqliteStore(db *sqlx.DB) *SqliteStore {
+	return &SqliteStore{db: db}
+}
+
+// hashToken is how API tokens are stored, so a leaked database does not leak them
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateUser adds a user and returns them with their API token, which is only available now
+// Returns ErrUserExists if the name is taken, names are compared case-insensitively
+func (s *SqliteStore) CreateUser(ctx context.Context, name string) (*User, string, error) {
+	name = strings.TrimSpace(name)
+
+	if name == "" {
+		return nil, "", fmt.Errorf("user name cannot be empty")
+	}
+
+	key := make([]byte, 32)
+	rand.Read(key)
+	token := hex.EncodeToString(key)
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	var exists bool
+
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE name = ?)`, name).Scan(&exists); err != nil {
+		return nil, "", fmt.Errorf("failed to check user name: %w", err)
+	}
+
+	if exists {
+		return nil, "", ErrUserExists
+	}
+
+	var user User
+
+	err = tx.GetContext(ctx, &user, `INSERT INTO users (name, token_hash) VALUES (?, ?) RETURNING id, name, created_at`, name, hashToken(token))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create user: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, "", fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &user, token, nil
+}
+
+func (s *SqliteStore) UserByToken(ctx context.Context, token string) (*User, error) {
+	var user User
+
+	err := s.db.GetContext(ctx, &user, `SELECT id, name, created_at FROM users WHERE token_hash = ?`, hashToken(token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return &user, nil
+}
+
+// UserByName returns a user by name, ErrUserNotFound when there is none
+func (s *SqliteStore) UserByName(ctx context.Context, name string) (*User, error) {
+	var user User
+
+	err := s.db.GetContext(ctx, &user, `SELECT id, name, created_at FROM users WHERE name = ?`, name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return &user, nil
+}
+
+func (s *SqliteStore) HasUsers(ctx context.Context) (bool, error) {
+	var exists bool
+
+	if err := s.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM users)`).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check users: %w", err)
+	}
+
+	return exists, nil
+}
+
+// Create adds an empty workspace
+func (s *SqliteStore) Create(ctx context.Context, name string) (*Workspace, error) {
+	name = strings.TrimSpace(name)
+
+	if name == "" {
+		return nil, fmt.Errorf("workspace name cannot be empty")
+	}
+
+	var workspace Workspace
+
+	err := s.db.GetContext(ctx, &workspace, `INSERT INTO workspaces (name) VALUES (?) RETURNING id, name, created_at`, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create workspace: %w", err)
+	}
+
+	return &workspace, nil
+}
+
+func (s *SqliteStore) Get(ctx context.Context, id int64) (*Workspace, error) {
+	var workspace Workspace
+
+	err := s.db.GetContext(ctx, &workspace, `SELECT id, name, created_at FROM workspaces WHERE id = ?`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWorkspaceNotFound
+		}
+		return nil, fmt.Errorf("failed to get workspace: %w", err)
+	}
+
+	return &workspace, nil
+}
+
+func (s *SqliteStore) List(ctx context.Context, userId int64) ([]Workspace, error) {
+	query := `SELECT id, name, created_at FROM workspaces ORDER BY id`
+	var args []any
+
+	if userId != 0 {
+		query = `SELECT w.id, w.name, w.created_at FROM workspaces w
+			JOIN workspace_members m ON m.workspace_id = w.id
+			WHERE m.user_id = ? ORDER BY w.id`
+		args = append(args, userId)
+	}
+
+	workspaces := []Workspace{}
+
+	if err := s.db.SelectContext(ctx, &workspaces, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to list workspaces: %w", err)
+	}
+
+	return workspaces, nil
+}
+
+func (s *SqliteStore) IsMember(ctx context.Context, id int64, userId int64) (bool, error) {
+	var member bool
+
+	err := s.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM workspace_members WHERE workspace_id = ? AND user_id = ?)`, id, userId).Scan(&member)
+	if err != nil {
+		return false, fmt.Errorf("failed to check workspace membership: %w", err)
+	}
+
+	return member, nil
+}
+
+// AddMember gives a user access to a workspace, adding an existing member again is a no-op
+func (s *SqliteStore) AddMember(ctx context.Context, id int64, userId int64) error {
+	if _, err := s.Get(ctx, id); err != nil {
+		return err
+	}
+
+	_, err := s.db.ExecContext(ctx, `INSERT INTO workspace_members (workspace_id, user_id) VALUES (?, ?) ON CONFLICT DO NOTHING`, id, userId)
+	if err != nil {
+		return fmt.Errorf("failed to add workspace member: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+// Package workspaces scopes projects, notes and everything hanging off them to a workspace
+//
+// Users authenticate with an API token and belong to one or more workspaces, each request
+// works in one of theirs, picked with the X-Workspace header. Until the first user is created
+// the server runs single-user: requests need no token and work in the default workspace
+package workspaces
+
+import (
+	"context"
+	"errors"
+)
+
+// Header is the request header clients pick their workspace with, by id
+const Header = "X-Workspace"
+
+// DefaultID is the workspace rows written before workspaces existed were moved to
+const DefaultID int64 = 1
+
+type contextKey struct{}
+
+type userContextKey struct{}
+
+// WithID returns a copy of ctx working in workspace id
+func WithID(ctx context.Context, id int64) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// ErrNoWorkspace is returned for contexts no workspace was resolved into, so code that skipped
+// Middleware fails instead of reading and writing the default workspace's data
+var ErrNoWorkspace = errors.New("no workspace was resolved for the request")
+
+// ID returns the workspace of ctx, ErrNoWorkspace when none was set
+// Middleware sets DefaultID in single-user mode, there is no fallback to it here
+func ID(ctx context.Context) (int64, error) {
+	if id, ok := ctx.Value(contextKey{}).(int64); ok {
+		return id, nil
+	}
+
+	return 0, ErrNoWorkspace
+}
+
+// WithUser returns a copy of ctx authenticated as user
+func WithUser(ctx context.Context, user *User) context.Context {
+	return context.WithValue(ctx, userContextKey{}, user)
+}
+
+// UserFrom returns the user ctx is authenticated as, nil in single-user mode
+func UserFrom(ctx context.Context) *User {
+	user, _ := ctx.Value(userContextKey{}).(*User)
+	return user
+}
@@ -9,29 +9,276 @@ import (
 	"log/slog"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
-	"time"
+	"text/tabwriter"
 
 	"github.com/joho/godotenv"
 	"github.com/maybemaby/workpad/api"
+	"github.com/maybemaby/workpad/api/workspaces"
+	"github.com/maybemaby/workpad/migrations"
 )
 
 type Args struct {
-	Port   string
-	DbPath string
-	TZ     string
+	ConfigPath string
+	Port       string
+	DbPath     string
+	TZ         string
+	NoMigrate  bool
+	// Command is the subcommand and its arguments, empty to run the server
+	Command []string
 }
 
+const usage = `Usage: workpad [flags] [command]
+
+Runs the server when no command is given.
+
+Commands:
+  migrate up              apply all pending migrations
+  migrate down            roll back the most recent migration
+  migrate redo            roll back the most recent migration and apply it again
+  migrate status          list migrations and when they were applied
+  migrate to <version>    migrate up or down to a version
+  config print            print the effective configuration with secrets redacted
+  users add <name> [id...]
+                          add a user to workspaces, the default one when none are given,
+                          and print their API token. The first user ends single-user mode
+  workspaces ls           list workspaces
+  workspaces add <name>   add a workspace and print its id
+  workspaces add-member <id> <user>
+                          give a user access to a workspace
+
+Configuration is read from workpad.yaml, or the file given by -config or $WORKPAD_CONFIG,
+then from the environment, then from flags. Environment variables:
+  %s
+
+Flags:
+`
+
 func argParse() Args {
 	var args Args
-	flag.StringVar(&args.Port, "port", "8000", "port to listen on")
-	flag.StringVar(&args.DbPath, "db", "app.db", "path to sqlite db")
+	flag.StringVar(&args.ConfigPath, "config", "", "path to a YAML config file, or $WORKPAD_CONFIG")
+	flag.StringVar(&args.Port, "port", "", "port to listen on (default 8000)")
+	flag.StringVar(&args.DbPath, "db", "", "path to sqlite db (default app.db)")
 	flag.StringVar(&args.TZ, "tz", "", "timezone for date handling")
+	flag.BoolVar(&args.NoMigrate, "no-migrate", false, "start without applying pending migrations")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), usage, api.ConfigEnvNames())
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
+	args.Command = flag.Args()
+
 	return args
 }
 
+// loadConfig builds the config from the file, the environment and the flags that were set
+func loadConfig(args Args) (api.Config, error) {
+	path, required := args.ConfigPath, true
+
+	if path == "" {
+		path = os.Getenv("WORKPAD_CONFIG")
+	}
+
+	if path == "" {
+		path, required = "workpad.yaml", false
+	}
+
+	cfg, err := api.LoadConfig(path, required, os.LookupEnv)
+	if err != nil {
+		return cfg, err
+	}
+
+	// Only flags given on the command line override, so their defaults do not mask the file
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "port":
+			cfg.Port = args.Port
+		case "db":
+			cfg.Database.Path = args.DbPath
+		case "tz":
+			cfg.Timezone = args.TZ
+		case "no-migrate":
+			cfg.AutoMigrate = !args.NoMigrate
+		}
+	})
+
+	return cfg, nil
+}
+
+// runMigrate runs a migrate subcommand against the configured database
+func runMigrate(ctx context.Context, cfg api.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New("missing migrate command, expected up, down, redo, status or to <version>")
+	}
+
+	_, db, err := api.NewSqliteDB(ctx, cfg.Database, false)
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	switch args[0] {
+	case "up":
+		return migrations.RunMigrations(ctx, db)
+	case "down":
+		return migrations.Down(ctx, db)
+	case "redo":
+		return migrations.Redo(ctx, db)
+	case "status":
+		return migrations.Status(ctx, db)
+	case "to":
+		if len(args) != 2 {
+			return errors.New("migrate to takes a version")
+		}
+
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+
+		return migrations.MigrateTo(ctx, db, version)
+	}
+
+	return fmt.Errorf("unknown migrate command %q", args[0])
+}
+
+// openWorkspaces opens the workspace store of the configured database, which must be migrated
+func openWorkspaces(ctx context.Context, cfg api.Config) (*workspaces.SqliteStore, func() error, error) {
+	dbx, db, err := api.NewSqliteDB(ctx, cfg.Database, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	current, latest, err := migrations.CheckVersion(ctx, db)
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+
+	if current != latest {
+		db.Close()
+		return nil, nil, fmt.Errorf("database is at version %d but %d is required, run migrate up first", current, latest)
+	}
+
+	return workspaces.NewSqliteStore(dbx), db.Close, nil
+}
+
+// runUsers runs a users subcommand against the configured database
+func runUsers(ctx context.Context, cfg api.Config, args []string) error {
+	if len(args) < 2 || args[0] != "add" {
+		return errors.New("expected users add <name> [workspace id...]")
+	}
+
+	ids := []int64{workspaces.DefaultID}
+
+	if len(args) > 2 {
+		ids = ids[:0]
+
+		for _, arg := range args[2:] {
+			id, err := strconv.ParseInt(arg, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid workspace id %q", arg)
+			}
+
+			ids = append(ids, id)
+		}
+	}
+
+	store, closeDB, err := openWorkspaces(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	defer closeDB()
+
+	// Check the workspaces first, so a typo does not leave a user behind
+	for _, id := range ids {
+		if _, err := store.Get(ctx, id); err != nil {
+			return fmt.Errorf("workspace %d: %w", id, err)
+		}
+	}
+
+	user, token, err := store.CreateUser(ctx, args[1])
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if err := store.AddMember(ctx, id, user.Id); err != nil {
+			return err
+		}
+	}
+
+	fmt.Println(token)
+
+	return nil
+}
+
+// runWorkspaces runs a workspaces subcommand against the configured database
+func runWorkspaces(ctx context.Context, cfg api.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New("missing workspaces command, expected ls, add <name> or add-member <id> <user>")
+	}
+
+	store, closeDB, err := openWorkspaces(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	defer closeDB()
+
+	switch args[0] {
+	case "ls":
+		list, err := store.List(ctx, 0)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME")
+
+		for _, workspace := range list {
+			fmt.Fprintf(w, "%d\t%s\n", workspace.Id, workspace.Name)
+		}
+
+		return w.Flush()
+	case "add":
+		if len(args) != 2 {
+			return errors.New("workspaces add takes a name")
+		}
+
+		workspace, err := store.Create(ctx, args[1])
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(workspace.Id)
+
+		return nil
+	case "add-member":
+		if len(args) != 3 {
+			return errors.New("workspaces add-member takes a workspace id and a user name")
+		}
+
+		id, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid workspace id %q", args[1])
+		}
+
+		user, err := store.UserByName(ctx, args[2])
+		if err != nil {
+			return err
+		}
+
+		return store.AddMember(ctx, id, user.Id)
+	}
+
+	return fmt.Errorf("unknown workspaces command %q", args[0])
+}
+
 func loadEnv() {
 	err := godotenv.Load()
 	if err != nil {
@@ -55,30 +302,70 @@ func main() {
 
 	loadEnv()
 
-	// Dates will be based off specified timezone, uses local timezone of computer if none specified
-	if args.TZ != "" {
-		location, err := time.LoadLocation(args.TZ)
+	cfg, err := loadConfig(args)
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
 
-		if err != nil {
-			panic(err)
+	if len(args.Command) > 0 && args.Command[0] == "config" {
+		if len(args.Command) != 2 || args.Command[1] != "print" {
+			fmt.Fprintln(os.Stderr, "expected config print")
+			os.Exit(2)
 		}
 
-		time.Local = location
+		if err := cfg.WriteYAML(os.Stdout); err != nil {
+			log.Fatalf("Error printing config: %v", err)
+		}
+
+		if err := cfg.Validate(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Error loading config: %v", err)
 	}
 
 	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
 		Level: slog.LevelDebug,
 	})))
 
+	if len(args.Command) > 0 {
+		switch args.Command[0] {
+		case "migrate":
+			if err := runMigrate(ctx, cfg, args.Command[1:]); err != nil {
+				log.Fatalf("Error running migrations: %v", err)
+			}
+		case "users":
+			if err := runUsers(ctx, cfg, args.Command[1:]); err != nil {
+				log.Fatalf("Error managing users: %v", err)
+			}
+		case "workspaces":
+			if err := runWorkspaces(ctx, cfg, args.Command[1:]); err != nil {
+				log.Fatalf("Error managing workspaces: %v", err)
+			}
+		default:
+			fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args.Command[0])
+			flag.Usage()
+			os.Exit(2)
+		}
+
+		return
+	}
+
 	// Otel
 
-	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" {
+	if cfg.OtelEndpoint != "" {
 		otelShutdown, err := api.SetupOtel(ctx, api.OtelConfig{
 			TraceExporter:   api.OtlpGrpcExporter,
 			MetricsExporter: api.OtlpGrpcExporter,
 			TraceEnabled:    true,
 			MetricsEnabled:  true,
 			LoggerEnabled:   false,
+			Endpoint:        cfg.OtelEndpoint,
 		})
 
 		if err != nil {
@@ -91,28 +378,26 @@ func main() {
 	}
 
 	// Server
-	appEnv := os.Getenv("APP_ENV")
-
-	isDebug := appEnv == "development"
-
-	server, err := api.NewServer(!isDebug)
+	server, err := api.NewServer(cfg)
 
 	if err != nil {
 		log.Fatalf("Error creating server: %v", err)
 		os.Exit(1)
 	}
 
-	server.WithPort(args.Port)
+	errs := make(chan error, 1)
 
 	go func() {
-		err := server.Start(ctx)
-
-		if err != nil {
-			log.Println(fmt.Printf("Error starting server: %v", err))
-		}
+		errs <- server.Start(ctx)
 	}()
 
-	<-ctx.Done()
+	// A schema the binary cannot serve stops startup instead of leaving a half started process
+	select {
+	case <-ctx.Done():
+	case err := <-errs:
+		log.Fatalf("Error starting server: %v", err)
+	}
+
 	stop()
 
 }
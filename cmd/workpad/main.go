@@ -0,0 +1,353 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/maybemaby/workpad/api/client"
+	"github.com/maybemaby/workpad/api/notes"
+)
+
+const usage = `Usage: workpad [flags] <command> [args]
+
+Commands:
+  note today                  print today's note
+  note get <date>             print the note for a YYYY-MM-DD date
+  note set                    replace today's note with HTML read from stdin
+  note append [text]          append text or Markdown to today's note, reads stdin when text is omitted or -
+  projects ls [-prefix P]     list projects
+  excerpts <project> [-since YYYY-MM-DD] [-descendants]
+                              list excerpts mentioning a project
+
+Flags:
+`
+
+type globalArgs struct {
+	URL       string
+	Token     string
+	Output    string
+	TZ        string
+	Workspace int64
+}
+
+func envOr(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+
+	return fallback
+}
+
+// parseInterspersed parses flags that may appear before, between or after positional arguments
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+
+		args = fs.Args()
+
+		if len(args) == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func main() {
+	var global globalArgs
+
+	flag.StringVar(&global.URL, "url", envOr("WORKPAD_URL", "http://localhost:8000"), "workpad server URL, or $WORKPAD_URL")
+	flag.StringVar(&global.Token, "token", os.Getenv("WORKPAD_TOKEN"), "bearer token, or $WORKPAD_TOKEN")
+	flag.StringVar(&global.Output, "o", "table", "output format, table or json")
+	flag.StringVar(&global.TZ, "tz", os.Getenv("WORKPAD_TZ"), "IANA timezone today's note is dated in, or $WORKPAD_TZ, the local zone when empty")
+	workspace := flag.String("workspace", os.Getenv("WORKPAD_WORKSPACE"), "id of the workspace to work in, or $WORKPAD_WORKSPACE, the user's first when empty")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if global.Output != "table" && global.Output != "json" {
+		fmt.Fprintln(os.Stderr, "workpad: -o must be table or json")
+		os.Exit(2)
+	}
+
+	if *workspace != "" {
+		id, err := strconv.ParseInt(*workspace, 10, 64)
+		if err != nil || id < 1 {
+			fmt.Fprintf(os.Stderr, "workpad: invalid workspace id %q\n", *workspace)
+			os.Exit(2)
+		}
+
+		global.Workspace = id
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var opts []client.Option
+
+	if global.Token != "" {
+		opts = append(opts, client.WithToken(global.Token))
+	}
+
+	if global.TZ != "" {
+		location, err := time.LoadLocation(global.TZ)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "workpad: unknown timezone %q\n", global.TZ)
+			os.Exit(2)
+		}
+
+		opts = append(opts, client.WithLocation(location))
+	}
+
+	if global.Workspace != 0 {
+		opts = append(opts, client.WithWorkspace(global.Workspace))
+	}
+
+	cli := &cli{
+		client: client.New(global.URL, opts...),
+		output: global.Output,
+		stdin:  os.Stdin,
+		stdout: os.Stdout,
+	}
+
+	if err := cli.run(ctx, flag.Args()); err != nil {
+		var usageErr usageError
+		if errors.As(err, &usageErr) {
+			fmt.Fprintf(os.Stderr, "workpad: %s\n\n", err)
+			flag.Usage()
+			os.Exit(2)
+		}
+
+		fmt.Fprintf(os.Stderr, "workpad: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+type usageError string
+
+func (e usageError) Error() string {
+	return string(e)
+}
+
+type cli struct {
+	client *client.Client
+	output string
+	stdin  io.Reader
+	stdout io.Writer
+}
+
+func (c *cli) run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return usageError("missing command")
+	}
+
+	switch args[0] {
+	case "note":
+		return c.note(ctx, args[1:])
+	case "projects":
+		return c.projects(ctx, args[1:])
+	case "excerpts":
+		return c.excerpts(ctx, args[1:])
+	}
+
+	return usageError(fmt.Sprintf("unknown command %q", args[0]))
+}
+
+func (c *cli) note(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return usageError("missing note subcommand")
+	}
+
+	switch args[0] {
+	case "today":
+		note, err := c.client.GetNote(ctx, c.client.Now())
+		if err != nil {
+			return err
+		}
+		return c.printNote(note)
+	case "get":
+		if len(args) != 2 {
+			return usageError("note get takes a date")
+		}
+
+		date, err := time.Parse(time.DateOnly, args[1])
+		if err != nil {
+			return usageError("dates must be YYYY-MM-DD")
+		}
+
+		note, err := c.client.GetNote(ctx, date)
+		if err != nil {
+			return err
+		}
+		return c.printNote(note)
+	case "set":
+		content, err := io.ReadAll(c.stdin)
+		if err != nil {
+			return err
+		}
+
+		note, err := c.client.SaveTodayNote(ctx, string(content))
+		if err != nil {
+			return err
+		}
+		return c.printNote(note)
+	case "append":
+		text := strings.Join(args[1:], " ")
+
+		if text == "" || text == "-" {
+			content, err := io.ReadAll(c.stdin)
+			if err != nil {
+				return err
+			}
+			text = string(content)
+		}
+
+		text = strings.TrimSpace(text)
+
+		if text == "" {
+			return usageError("nothing to append")
+		}
+
+		note, err := c.client.AppendToToday(ctx, text)
+		if err != nil {
+			return err
+		}
+		return c.printNote(note)
+	}
+
+	return usageError(fmt.Sprintf("unknown note subcommand %q", args[0]))
+}
+
+func (c *cli) projects(ctx context.Context, args []string) error {
+	if len(args) == 0 || args[0] != "ls" {
+		return usageError("expected projects ls")
+	}
+
+	fs := flag.NewFlagSet("projects ls", flag.ContinueOnError)
+	prefix := fs.String("prefix", "", "only list projects starting with prefix")
+
+	if _, err := parseInterspersed(fs, args[1:]); err != nil {
+		return usageError(err.Error())
+	}
+
+	list, err := c.client.ListProjects(ctx, *prefix)
+	if err != nil {
+		return err
+	}
+
+	if c.output == "json" {
+		return c.printJSON(list)
+	}
+
+	tw := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tPARENT\tTARGET\tCREATED")
+
+	for _, project := range list {
+		parent, target := "-", "-"
+
+		if project.ParentName != nil {
+			parent = *project.ParentName
+		}
+
+		if project.TargetDate != nil {
+			target = project.TargetDate.Format(time.DateOnly)
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", project.Name, parent, target, project.CreatedAt.Local().Format(time.DateOnly))
+	}
+
+	return tw.Flush()
+}
+
+func (c *cli) excerpts(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("excerpts", flag.ContinueOnError)
+	since := fs.String("since", "", "only list excerpts from this YYYY-MM-DD date on")
+	descendants := fs.Bool("descendants", false, "include excerpts of sub-projects")
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return usageError(err.Error())
+	}
+
+	if len(positional) != 1 {
+		return usageError("excerpts takes one project name")
+	}
+
+	opts := client.ExcerptOptions{IncludeDescendants: *descendants}
+
+	// The table shows excerpts as text, JSON output keeps them as stored
+	if c.output != "json" {
+		opts.Format = notes.FormatText
+	}
+
+	if *since != "" {
+		opts.Since, err = time.Parse(time.DateOnly, *since)
+		if err != nil {
+			return usageError("dates must be YYYY-MM-DD")
+		}
+	}
+
+	excerpts, err := c.client.GetExcerpts(ctx, positional[0], opts)
+	if err != nil {
+		return err
+	}
+
+	if c.output == "json" {
+		return c.printJSON(excerpts)
+	}
+
+	tw := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "DATE\tPROJECT\tEXCERPT")
+
+	for _, excerpt := range excerpts {
+		date := excerpt.Date[:min(len(excerpt.Date), len(time.DateOnly))]
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", date, excerpt.ProjectName, excerpt.Excerpt)
+	}
+
+	return tw.Flush()
+}
+
+func (c *cli) printNote(note *notes.Note) error {
+	if c.output == "json" {
+		return c.printJSON(note)
+	}
+
+	fmt.Fprintf(c.stdout, "%s\n\n", note.Date.Format("Monday, 2 January 2006"))
+
+	blocks, err := notes.Blocks(note.HTMLContent)
+	if err != nil {
+		return err
+	}
+
+	// One line per paragraph, heading or task keeps the note readable in a terminal
+	for _, block := range blocks {
+		fmt.Fprintln(c.stdout, block.Text)
+	}
+
+	return nil
+}
+
+func (c *cli) printJSON(value any) error {
+	enc := json.NewEncoder(c.stdout)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(value)
+}
@@ -4,5 +4,9 @@ import type { paths } from './spec';
 export const apiClient = createClient<paths>({
 	baseUrl: import.meta.env.DEV ? 'http://localhost:8000' : window.location.origin,
 	credentials: 'include',
-	mode: 'cors'
+	mode: 'cors',
+	// Notes are dated in the browser's timezone rather than the server's
+	headers: {
+		'X-Timezone': Intl.DateTimeFormat().resolvedOptions().timeZone
+	}
 });
@@ -1,4 +1,4 @@
-import { createMutation, createQuery } from '@tanstack/svelte-query';
+import { createInfiniteQuery, createMutation, createQuery } from '@tanstack/svelte-query';
 import { apiClient } from './client';
 import type { components } from './spec';
 import type { Getter } from 'runed';
@@ -20,7 +20,7 @@ export const createGetProjectsQuery = (query?: Getter<string>, options?: Getter<
 				throw new Error('Failed to fetch projects');
 			}
 
-			return res.data;
+			return res.data.items;
 		},
 		enabled: options?.()?.enabled ?? true
 	}));
@@ -99,6 +99,39 @@ export const getMonthNotes = (props: Getter<GetMonthNotesParams>) => {
 	}));
 };
 
+// UnknownProjectsError is thrown when a save mentions projects that do not exist
+export class UnknownProjectsError extends Error {
+	constructor(public projects: string[]) {
+		super(`Unknown projects: ${projects.join(', ')}`);
+	}
+}
+
+export const createSaveNoteMutation = () => {
+	return createMutation(() => ({
+		mutationFn: async ({
+			date,
+			...body
+		}: components['schemas']['NotesSaveNoteRequest'] & { date: string }) => {
+			const res = await apiClient.PUT('/api/notes/{date}', {
+				params: {
+					path: {
+						date
+					}
+				},
+				body
+			});
+
+			if (res.error && res.response.status === 422) {
+				throw new UnknownProjectsError(res.error.errors.map((error) => error.project));
+			} else if (res.error) {
+				throw new Error('Failed to save note');
+			}
+
+			return res.data;
+		}
+	}));
+};
+
 export const createUpdateExcerptMutation = () => {
 	return createMutation(() => ({
 		mutationFn: async (data: components['schemas']['NotesUpdateNoteExcerptRequest']) => {
@@ -115,14 +148,22 @@ export const createUpdateExcerptMutation = () => {
 	}));
 };
 
+// excerptPageSize is how many excerpts the project page loads at a time
+const excerptPageSize = 50;
+
 export const getExcerptsQuery = (name: Getter<string>) => {
-	return createQuery(() => ({
-		queryKey: ['note-excerpts', name],
-		queryFn: async () => {
+	return createInfiniteQuery(() => ({
+		queryKey: ['note-excerpts', name()],
+		queryFn: async ({ pageParam }) => {
 			const res = await apiClient.GET('/api/notes/excerpts/{project}', {
 				params: {
 					path: {
 						project: name()
+					},
+					query: {
+						limit: excerptPageSize,
+						cursor: pageParam,
+						format: 'html'
 					}
 				}
 			});
@@ -132,6 +173,8 @@ export const getExcerptsQuery = (name: Getter<string>) => {
 			}
 
 			return res.data;
-		}
+		},
+		initialPageParam: undefined as string | undefined,
+		getNextPageParam: (lastPage) => lastPage.next_cursor || undefined
 	}));
 };
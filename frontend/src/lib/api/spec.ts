@@ -135,7 +135,13 @@ export interface paths {
         };
         get: {
             parameters: {
-                query?: never;
+                query?: {
+                    include_descendants?: boolean;
+                    sort?: "newest" | "oldest";
+                    limit?: number;
+                    cursor?: string;
+                    format?: "json" | "html" | "text";
+                };
                 header?: never;
                 path: {
                     project: string;
@@ -150,8 +156,15 @@ export interface paths {
                         [name: string]: unknown;
                     };
                     content: {
-                        "application/json": components["schemas"]["NotesNoteExcerpt"][];
+                        "application/json": components["schemas"]["NotesExcerptPage"];
+                    };
+                };
+                /** @description Bad Request */
+                400: {
+                    headers: {
+                        [name: string]: unknown;
                     };
+                    content?: never;
                 };
             };
         };
@@ -201,6 +214,57 @@ export interface paths {
         patch?: never;
         trace?: never;
     };
+    "/api/notes/{date}": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        get?: never;
+        put: {
+            parameters: {
+                query?: never;
+                header?: never;
+                path: {
+                    /** @example 2026-01-01 */
+                    date: string;
+                };
+                cookie?: never;
+            };
+            requestBody?: {
+                content: {
+                    "application/json": components["schemas"]["NotesSaveNoteRequest"];
+                };
+            };
+            responses: {
+                /** @description OK */
+                200: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content: {
+                        "application/json": components["schemas"]["NotesNote"];
+                    };
+                };
+                /** @description Unprocessable Entity */
+                422: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content: {
+                        "application/json": components["schemas"]["NotesProjectErrorResponse"];
+                    };
+                };
+            };
+        };
+        post?: never;
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
     "/api/projects": {
         parameters: {
             query?: never;
@@ -212,6 +276,12 @@ export interface paths {
             parameters: {
                 query?: {
                     prefix?: string;
+                    tree?: boolean;
+                    /** @example true */
+                    activity?: boolean;
+                    sort?: "name" | "created_at" | "last_mentioned" | "activity";
+                    limit?: number;
+                    cursor?: string;
                 };
                 header?: never;
                 path?: never;
@@ -225,9 +295,16 @@ export interface paths {
                         [name: string]: unknown;
                     };
                     content: {
-                        "application/json": components["schemas"]["ProjectsProject"][];
+                        "application/json": components["schemas"]["ProjectsProjectPage"];
                     };
                 };
+                /** @description Bad Request */
+                400: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content?: never;
+                };
             };
         };
         put?: never;
@@ -559,14 +636,46 @@ export interface components {
             /** Format: date-time */
             note_date: string;
         };
+        NotesExcerptContext: {
+            after?: string;
+            before?: string;
+            /** @example <h2>Standup</h2> */
+            heading?: string;
+            /** @example <p>Vendor follow-ups</p> */
+            parent?: string;
+        };
         NotesNoteExcerpt: {
+            context: components["schemas"]["NotesExcerptContext"];
             date: string;
             excerpt: string;
             id: number;
             note_id: number;
             project_name: string;
         };
+        NotesExcerptPage: {
+            items: components["schemas"]["NotesNoteExcerpt"][];
+            next_cursor?: string;
+        };
+        NotesProjectError: {
+            /** @example project does not exist */
+            message: string;
+            /** @example OKT123 */
+            project: string;
+        };
+        NotesProjectErrorResponse: {
+            errors: components["schemas"]["NotesProjectError"][];
+            /** @example Excerpts mention projects that cannot be saved */
+            message: string;
+            /** @enum {integer} */
+            status: 422;
+        };
+        NotesSaveNoteRequest: {
+            create_projects?: boolean;
+            excerpts: components["schemas"]["NotesExcerptNode"][];
+            html_content: string;
+        };
         NotesUpdateNoteExcerptRequest: {
+            create_projects?: boolean;
             /** @example 2026-01-01 */
             date: string;
             excerpts: components["schemas"]["NotesExcerptNode"][];
@@ -584,10 +693,36 @@ export interface components {
             name: string;
         };
         ProjectsProject: {
+            activity?: components["schemas"]["ProjectsProjectActivity"];
             /** Format: date-time */
             created_at: string;
             name: string;
         };
+        ProjectsProjectActivity: {
+            /**
+             * Format: date
+             * @example 2026-01-05
+             */
+            first_mentioned: string | null;
+            /**
+             * Format: date
+             * @example 2026-10-19
+             */
+            last_mentioned: string | null;
+            /** @example 42 */
+            mentions: number;
+            /** @example 11 */
+            mentions_30d: number;
+            /** @example 3 */
+            mentions_7d: number;
+        };
+        ProjectsProjectNode: components["schemas"]["ProjectsProject"] & {
+            children?: components["schemas"]["ProjectsProjectNode"][];
+        };
+        ProjectsProjectPage: {
+            items: components["schemas"]["ProjectsProjectNode"][];
+            next_cursor?: string;
+        };
     };
     responses: never;
     parameters: never;
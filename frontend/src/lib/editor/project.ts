@@ -38,7 +38,9 @@ class ToolTip {
 	}
 
 	async setOptions(options: string[]) {
-		this.el.innerHTML = options.map((option) => `<button>${option}</button>`).join('');
+		this.el.innerHTML = options
+			.map((option) => `<button>${knownProjects.has(option) ? option : `Create ${option}`}</button>`)
+			.join('');
 
 		const children = this.el.children;
 
@@ -86,6 +88,32 @@ class ToolTip {
 // Cache to store the most recent project results
 const projectCache: Map<string, string[]> = new Map();
 
+// Names of projects known to exist, other mention options create a project when picked
+const knownProjects = new Set<string>();
+
+const rememberProjects = (names: string[]) => {
+	names.forEach((name) => knownProjects.add(name));
+	return names;
+};
+
+// createMentionedProject creates a project picked from the mention list that does not exist yet,
+// picking it is the confirmation, notes are saved without creating projects
+const createMentionedProject = async (name: string) => {
+	if (knownProjects.has(name)) {
+		return;
+	}
+
+	const res = await apiClient.POST('/api/projects', {
+		body: {
+			name
+		}
+	});
+
+	if (!res.error) {
+		knownProjects.add(res.data.name);
+	}
+};
+
 // Debounced API call to fetch projects and update cache
 const debouncedProjectQuery = debounce(async (query: string) => {
 	const res = await apiClient.GET('/api/projects', {
@@ -99,7 +127,7 @@ const debouncedProjectQuery = debounce(async (query: string) => {
 	if (!res.error && res.data) {
 		projectCache.set(
 			query,
-			res.data.map((project) => project.name)
+			rememberProjects(res.data.items.map((project) => project.name))
 		);
 	}
 }, 300);
@@ -132,7 +160,7 @@ export const ProjectTag = Mention.configure({
 					if (res.error) {					
 						return [];
 					}
-					return res.data.map((project) => project.name);
+					return rememberProjects(res.data.items.map((project) => project.name));
 				});
 
 			// Include the current query as an option if it's non-empty
@@ -147,7 +175,13 @@ export const ProjectTag = Mention.configure({
 			let toolTip: ToolTip;
 			return {
 				onStart: async (props) => {
-					toolTip = new ToolTip(props.command);
+					toolTip = new ToolTip(async (attrs) => {
+						if (attrs.id) {
+							await createMentionedProject(attrs.id);
+						}
+
+						props.command(attrs);
+					});
 
 					if (props.decorationNode) {
 						toolTip.mount();
@@ -0,0 +1,133 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+
+	"github.com/pressly/goose/v3"
+)
+
+// dateColumns are the columns holding calendar dates, by table. They were declared DATETIME,
+// which reads as a point in time when they only ever held a day in the client's zone
+var dateColumns = []struct {
+	table   string
+	columns []string
+}{
+	{"projects", []string{"target_date"}},
+	{"notes", []string{"note_date"}},
+	{"project_excerpts", []string{"note_date"}},
+	{"note_stats", []string{"note_date"}},
+	{"time_entries", []string{"note_date"}},
+	{"note_tags", []string{"note_date"}},
+	{"note_links", []string{"source_date", "target_date"}},
+	{"reminders", []string{"due_date"}},
+}
+
+func init() {
+	// Registered from Go as SQL migrations run in a transaction, where foreign keys cannot be switched off
+	goose.AddNamedMigrationNoTxContext("20261019107000_date_columns.go",
+		func(ctx context.Context, db *sql.DB) error {
+			return retypeDateColumns(ctx, db, "DATETIME", "DATE")
+		},
+		func(ctx context.Context, db *sql.DB) error {
+			return retypeDateColumns(ctx, db, "DATE", "DATETIME")
+		},
+	)
+}
+
+// retypeDateColumns declares the date columns as to instead of from and trims their values to YYYY-MM-DD
+// SQLite cannot change a column's type in place, so each table is rebuilt and its indexes and triggers recreated
+func retypeDateColumns(ctx context.Context, db *sql.DB, from string, to string) error {
+	return withoutForeignKeys(ctx, db, func(tx *sql.Tx) error {
+		for _, t := range dateColumns {
+			if err := rebuildTable(ctx, tx, t.table, t.columns, from, to); err != nil {
+				return fmt.Errorf("failed to rebuild %s: %w", t.table, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func rebuildTable(ctx context.Context, tx *sql.Tx, table string, columns []string, from string, to string) error {
+	var create string
+
+	if err := tx.QueryRowContext(ctx, `SELECT sql FROM sqlite_schema WHERE type = 'table' AND name = ?`, table).Scan(&create); err != nil {
+		return err
+	}
+
+	var objects []string
+
+	rows, err := tx.QueryContext(ctx, `SELECT sql FROM sqlite_schema WHERE tbl_name = ? AND type IN ('index', 'trigger') AND sql IS NOT NULL`, table)
+	if err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		var object string
+		if err := rows.Scan(&object); err != nil {
+			rows.Close()
+			return err
+		}
+		objects = append(objects, object)
+	}
+
+	if err := rows.Close(); err != nil {
+		return err
+	}
+
+	rebuilt := table + "_rebuilt"
+
+	create, err = replaceOnce(create, `^CREATE TABLE "?`+regexp.QuoteMeta(table)+`"?`, "CREATE TABLE "+rebuilt)
+	if err != nil {
+		return err
+	}
+
+	for _, column := range columns {
+		create, err = replaceOnce(create, `(?i)\b`+regexp.QuoteMeta(column)+`\s+`+from+`\b`, column+" "+to)
+		if err != nil {
+			return err
+		}
+	}
+
+	statements := []string{
+		create,
+		fmt.Sprintf(`INSERT INTO %s SELECT * FROM %s`, rebuilt, table),
+	}
+
+	for _, column := range columns {
+		// Values written as times keep the day they were written on, which is what they meant
+		statements = append(statements, fmt.Sprintf(`UPDATE %[1]s SET %[2]s = substr(%[2]s, 1, 10) WHERE typeof(%[2]s) = 'text' AND length(%[2]s) > 10`, rebuilt, column))
+	}
+
+	statements = append(statements,
+		// Keep AUTOINCREMENT counters, so ids of deleted rows are not handed out again
+		fmt.Sprintf(`DELETE FROM sqlite_sequence WHERE name = '%s'`, rebuilt),
+		fmt.Sprintf(`INSERT INTO sqlite_sequence (name, seq) SELECT '%s', seq FROM sqlite_sequence WHERE name = '%s'`, rebuilt, table),
+		fmt.Sprintf(`DROP TABLE %s`, table),
+		fmt.Sprintf(`ALTER TABLE %s RENAME TO %s`, rebuilt, table),
+	)
+
+	statements = append(statements, objects...)
+
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("%w: %s", err, statement)
+		}
+	}
+
+	return nil
+}
+
+// replaceOnce replaces the single match of pattern in s, failing when the schema does not look as expected
+func replaceOnce(s string, pattern string, replacement string) (string, error) {
+	re := regexp.MustCompile(pattern)
+
+	if matches := re.FindAllStringIndex(s, -1); len(matches) != 1 {
+		return "", fmt.Errorf("expected one match of %s in %q, found %d", pattern, s, len(matches))
+	}
+
+	return re.ReplaceAllLiteralString(s, replacement), nil
+}
@@ -0,0 +1,125 @@
+package migrations
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	_ "modernc.org/sqlite"
+)
+
+type DateColumnsSuite struct {
+	suite.Suite
+	db *sql.DB
+}
+
+func (s *DateColumnsSuite) SetupTest() {
+	s.db, _ = sql.Open("sqlite", "file::memory:?_pragma=foreign_keys(1)")
+	s.db.SetMaxOpenConns(1)
+
+	ctx := s.T().Context()
+
+	s.Require().NoError(setup())
+	s.Require().NoError(MigrateTo(ctx, s.db, 20261019106000))
+
+	// Dates written as times by older builds sit next to plain ones
+	for _, statement := range []string{
+		`INSERT INTO projects (name, target_date) VALUES ('Alpha', '2026-11-01 00:00:00+00:00'), ('Bravo', NULL)`,
+		`INSERT INTO notes (id, html_content, note_date) VALUES (1, '<p>a</p>', '2026-10-18'), (2, '<p>b</p>', '2026-10-19 23:30:00-05:00'), (7, '', '2026-10-20')`,
+		`DELETE FROM notes WHERE id = 7`,
+		`INSERT INTO project_excerpts (project_name, note_id, excerpt, note_date) VALUES ('Alpha', 1, 'a', '2026-10-18'), ('Alpha', 2, 'b', '2026-10-19T23:30:00Z')`,
+		`INSERT INTO note_links (source_note_id, source_date, target_date, block_index, snippet) VALUES (2, '2026-10-19', '2026-10-18 00:00:00', 0, 'b')`,
+		`INSERT INTO reminders (note_id, project_name, text, due_date, source) VALUES (1, 'Alpha', 'ship', '2026-11-01', 'manual')`,
+	} {
+		_, err := s.db.ExecContext(ctx, statement)
+		s.Require().NoError(err, statement)
+	}
+}
+
+func (s *DateColumnsSuite) TearDownTest() {
+	s.db.Close()
+}
+
+func (s *DateColumnsSuite) columnType(table string, column string) string {
+	var declared string
+
+	err := s.db.QueryRowContext(s.T().Context(), `SELECT type FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&declared)
+	s.Require().NoError(err)
+
+	return declared
+}
+
+func (s *DateColumnsSuite) strings(query string) []string {
+	rows, err := s.db.QueryContext(s.T().Context(), query)
+	s.Require().NoError(err)
+	defer rows.Close()
+
+	var values []string
+
+	for rows.Next() {
+		var value sql.NullString
+		s.Require().NoError(rows.Scan(&value))
+		values = append(values, value.String)
+	}
+
+	return values
+}
+
+func (s *DateColumnsSuite) TestUp_DeclaresDateText() {
+	ctx := s.T().Context()
+
+	s.Require().NoError(MigrateTo(ctx, s.db, 20261019107000))
+
+	for _, t := range dateColumns {
+		for _, column := range t.columns {
+			s.Equal("DATE", s.columnType(t.table, column), t.table+"."+column)
+		}
+	}
+
+	// Values keep the day they were written on, not the day in UTC
+	s.Equal([]string{"2026-10-18", "2026-10-19"}, s.strings(`SELECT CAST(note_date AS TEXT) FROM notes ORDER BY id`))
+	s.Equal([]string{"2026-10-18", "2026-10-19"}, s.strings(`SELECT CAST(note_date AS TEXT) FROM project_excerpts ORDER BY id`))
+	s.Equal([]string{"2026-10-18"}, s.strings(`SELECT CAST(target_date AS TEXT) FROM note_links`))
+	s.Equal([]string{"2026-11-01", ""}, s.strings(`SELECT CAST(target_date AS TEXT) FROM projects ORDER BY name`))
+
+	// Children were kept and still cascade from the rebuilt parents
+	_, err := s.db.ExecContext(ctx, `UPDATE projects SET name = 'Renamed' WHERE name = 'Alpha'`)
+	s.Require().NoError(err)
+	s.Equal([]string{"Renamed", "Renamed"}, s.strings(`SELECT project_name FROM project_excerpts`))
+
+	_, err = s.db.ExecContext(ctx, `DELETE FROM notes WHERE id = 1`)
+	s.Require().NoError(err)
+	s.Equal([]string{"b"}, s.strings(`SELECT excerpt FROM project_excerpts`))
+	s.Empty(s.strings(`SELECT text FROM reminders`))
+
+	// Triggers and AUTOINCREMENT counters survive the rebuild
+	s.ElementsMatch([]string{"note_stats_invalidate_excerpt_delete", "note_stats_invalidate_excerpt_insert", "note_stats_invalidate_note_update", "notes_default_updated_at"},
+		s.strings(`SELECT name FROM sqlite_schema WHERE type = 'trigger'`))
+
+	var id int
+	s.Require().NoError(s.db.QueryRowContext(ctx, `INSERT INTO notes (html_content, note_date) VALUES ('', '2026-10-21') RETURNING id`).Scan(&id))
+	s.Equal(8, id)
+
+	var foreignKeys bool
+	s.Require().NoError(s.db.QueryRowContext(ctx, `PRAGMA foreign_keys`).Scan(&foreignKeys))
+	s.True(foreignKeys)
+}
+
+func (s *DateColumnsSuite) TestDown_RestoresDatetime() {
+	ctx := s.T().Context()
+
+	s.Require().NoError(MigrateTo(ctx, s.db, 20261019107000))
+	s.Require().NoError(Down(ctx, s.db))
+
+	for _, t := range dateColumns {
+		for _, column := range t.columns {
+			s.Equal("DATETIME", s.columnType(t.table, column), t.table+"."+column)
+		}
+	}
+
+	s.Equal([]string{"2026-10-18", "2026-10-19"}, s.strings(`SELECT CAST(note_date AS TEXT) FROM notes ORDER BY id`))
+}
+
+func TestDateColumnsSuite(t *testing.T) {
+	suite.Run(t, new(DateColumnsSuite))
+}
@@ -0,0 +1,75 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// withoutForeignKeys runs fn in a transaction on a connection with foreign keys switched off,
+// as rebuilding a table that others reference would otherwise cascade its drop to their rows
+// Foreign keys can only be switched off outside a transaction, so migrations doing this are
+// registered from Go to run without goose's transaction. Violations left behind fail the migration
+func withoutForeignKeys(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) (err error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	var foreignKeys bool
+	if err := conn.QueryRowContext(ctx, `PRAGMA foreign_keys`).Scan(&foreignKeys); err != nil {
+		return err
+	}
+
+	if _, err := conn.ExecContext(ctx, `PRAGMA foreign_keys = OFF`); err != nil {
+		return err
+	}
+
+	// The connection goes back to the pool, so restore it even when the migration fails
+	defer func() {
+		restoreCtx := context.WithoutCancel(ctx)
+
+		if _, restoreErr := conn.ExecContext(restoreCtx, `PRAGMA legacy_alter_table = OFF`); restoreErr != nil && err == nil {
+			err = restoreErr
+		}
+
+		if _, restoreErr := conn.ExecContext(restoreCtx, fmt.Sprintf(`PRAGMA foreign_keys = %t`, foreignKeys)); restoreErr != nil && err == nil {
+			err = restoreErr
+		}
+	}()
+
+	// Renames would otherwise check triggers on other tables that name the table being rebuilt
+	if _, err := conn.ExecContext(ctx, `PRAGMA legacy_alter_table = ON`); err != nil {
+		return err
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	rows, err := tx.QueryContext(ctx, `PRAGMA foreign_key_check`)
+	if err != nil {
+		return err
+	}
+
+	violation := rows.Next()
+	if err := rows.Close(); err != nil {
+		return err
+	}
+
+	if violation {
+		return errors.New("migration left foreign key violations")
+	}
+
+	return tx.Commit()
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"embed"
+	"errors"
+	"fmt"
 
 	"github.com/pressly/goose/v3"
 )
@@ -11,15 +13,99 @@ import (
 //go:embed sqlite/*.sql
 var migrations embed.FS
 
-func RunMigrations(ctx context.Context, db *sql.DB) error {
+// ErrSchemaTooNew is returned when the database was migrated by a newer build
+var ErrSchemaTooNew = errors.New("database schema is newer than this binary")
 
+func setup() error {
 	goose.SetBaseFS(migrations)
 
-	err := goose.SetDialect("sqlite3")
+	return goose.SetDialect("sqlite3")
+}
+
+// Latest returns the version of the newest embedded migration
+func Latest() (int64, error) {
+	if err := setup(); err != nil {
+		return 0, err
+	}
+
+	collected, err := goose.CollectMigrations("sqlite", 0, goose.MaxVersion)
+	if err != nil {
+		return 0, err
+	}
+
+	last, err := collected.Last()
+	if err != nil {
+		return 0, err
+	}
+
+	return last.Version, nil
+}
 
+// CheckVersion returns the database and latest embedded versions, failing with
+// ErrSchemaTooNew when the database has migrations this binary does not know about
+func CheckVersion(ctx context.Context, db *sql.DB) (current int64, latest int64, err error) {
+	latest, err = Latest()
 	if err != nil {
+		return 0, 0, err
+	}
+
+	current, err = goose.GetDBVersionContext(ctx, db)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if current > latest {
+		return current, latest, fmt.Errorf("%w: database is at version %d, newest known migration is %d", ErrSchemaTooNew, current, latest)
+	}
+
+	return current, latest, nil
+}
+
+func RunMigrations(ctx context.Context, db *sql.DB) error {
+	if _, _, err := CheckVersion(ctx, db); err != nil {
 		return err
 	}
 
 	return goose.UpContext(ctx, db, "sqlite")
 }
+
+// Down rolls back the most recent migration
+func Down(ctx context.Context, db *sql.DB) error {
+	if err := setup(); err != nil {
+		return err
+	}
+
+	return goose.DownContext(ctx, db, "sqlite")
+}
+
+// Redo rolls back the most recent migration and applies it again
+func Redo(ctx context.Context, db *sql.DB) error {
+	if err := setup(); err != nil {
+		return err
+	}
+
+	return goose.RedoContext(ctx, db, "sqlite")
+}
+
+// MigrateTo migrates up or down until version is the latest applied migration
+func MigrateTo(ctx context.Context, db *sql.DB, version int64) error {
+	current, _, err := CheckVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	if version >= current {
+		return goose.UpToContext(ctx, db, "sqlite", version)
+	}
+
+	return goose.DownToContext(ctx, db, "sqlite", version)
+}
+
+// Status logs every embedded migration and when it was applied
+func Status(ctx context.Context, db *sql.DB) error {
+	if err := setup(); err != nil {
+		return err
+	}
+
+	return goose.StatusContext(ctx, db, "sqlite")
+}
@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE note_stats (
+    note_id INTEGER PRIMARY KEY NOT NULL REFERENCES notes(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    note_date DATETIME NOT NULL,
+    word_count INTEGER NOT NULL DEFAULT 0,
+    excerpt_count INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX idx_note_stats_note_date ON note_stats(note_date);
+
+CREATE INDEX idx_project_excerpts_note_id ON project_excerpts(note_id);
+
+-- Stats rows are a cache. Any write that changes a note or its excerpts drops the
+-- row so it is recomputed the next time the range is read.
+CREATE TRIGGER note_stats_invalidate_note_update AFTER UPDATE ON notes
+BEGIN
+    DELETE FROM note_stats WHERE note_id = OLD.id;
+END;
+
+CREATE TRIGGER note_stats_invalidate_excerpt_insert AFTER INSERT ON project_excerpts
+BEGIN
+    DELETE FROM note_stats WHERE note_id = NEW.note_id;
+END;
+
+CREATE TRIGGER note_stats_invalidate_excerpt_delete AFTER DELETE ON project_excerpts
+BEGIN
+    DELETE FROM note_stats WHERE note_id = OLD.note_id;
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER note_stats_invalidate_excerpt_delete;
+DROP TRIGGER note_stats_invalidate_excerpt_insert;
+DROP TRIGGER note_stats_invalidate_note_update;
+DROP INDEX idx_project_excerpts_note_id;
+DROP TABLE note_stats;
+-- +goose StatementEnd
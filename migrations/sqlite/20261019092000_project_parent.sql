@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE projects ADD COLUMN parent_name TEXT REFERENCES projects(name) ON DELETE SET NULL ON UPDATE CASCADE;
+
+CREATE INDEX idx_projects_parent_name ON projects(parent_name);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_projects_parent_name;
+ALTER TABLE projects DROP COLUMN parent_name;
+-- +goose StatementEnd
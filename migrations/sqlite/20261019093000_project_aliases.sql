@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE project_aliases (
+    alias TEXT PRIMARY KEY NOT NULL COLLATE NOCASE,
+    project_name TEXT NOT NULL REFERENCES projects(name) ON DELETE CASCADE ON UPDATE CASCADE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_project_aliases_project_name ON project_aliases(project_name);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE project_aliases;
+-- +goose StatementEnd
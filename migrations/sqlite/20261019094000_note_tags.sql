@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE note_tags (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    note_id INTEGER NOT NULL REFERENCES notes(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    note_date DATETIME NOT NULL,
+    tag TEXT NOT NULL COLLATE NOCASE,
+    block_index INTEGER NOT NULL,
+    block_html TEXT NOT NULL
+);
+
+CREATE INDEX idx_note_tags_tag ON note_tags(tag);
+CREATE INDEX idx_note_tags_note_id ON note_tags(note_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE note_tags;
+-- +goose StatementEnd
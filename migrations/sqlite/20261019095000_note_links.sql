@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE note_links (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    source_note_id INTEGER NOT NULL REFERENCES notes(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    source_date DATETIME NOT NULL,
+    target_date DATETIME NOT NULL,
+    block_index INTEGER NOT NULL,
+    snippet TEXT NOT NULL
+);
+
+CREATE INDEX idx_note_links_target_date ON note_links(target_date);
+CREATE INDEX idx_note_links_source_note_id ON note_links(source_note_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE note_links;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE attachments (
+    hash TEXT PRIMARY KEY NOT NULL,
+    mime_type TEXT NOT NULL,
+    size INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Maintained on note save, hashes are not foreign keys so a note may point at a blob
+-- that was never uploaded or has already been collected
+CREATE TABLE note_attachments (
+    note_id INTEGER NOT NULL REFERENCES notes(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    hash TEXT NOT NULL,
+    PRIMARY KEY (note_id, hash)
+);
+
+CREATE INDEX idx_note_attachments_hash ON note_attachments(hash);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE note_attachments;
+DROP TABLE attachments;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE reminders (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    note_id INTEGER NOT NULL REFERENCES notes(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    project_name TEXT REFERENCES projects(name) ON DELETE SET NULL ON UPDATE CASCADE,
+    text TEXT NOT NULL,
+    due_date DATETIME NOT NULL,
+    -- manual reminders are created through the API, detected ones come from task items
+    source TEXT NOT NULL CHECK (source IN ('manual', 'detected')),
+    completed_at DATETIME,
+    fired_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_reminders_due_date ON reminders(due_date);
+CREATE INDEX idx_reminders_note_id ON reminders(note_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE reminders;
+-- +goose StatementEnd
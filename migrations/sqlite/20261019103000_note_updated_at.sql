@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE notes ADD COLUMN updated_at DATETIME;
+
+-- Existing notes were last written no earlier than their own day
+UPDATE notes SET updated_at = note_date;
+
+-- Rows inserted without a timestamp, such as by imports, still get one
+CREATE TRIGGER notes_default_updated_at AFTER INSERT ON notes WHEN NEW.updated_at IS NULL
+BEGIN
+    UPDATE notes SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER notes_default_updated_at;
+ALTER TABLE notes DROP COLUMN updated_at;
+-- +goose StatementEnd
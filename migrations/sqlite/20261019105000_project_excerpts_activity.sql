@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Serves per-project activity and last mentioned sorting from the index alone
+CREATE INDEX idx_project_excerpts_project_name_note_date ON project_excerpts(project_name, note_date);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_project_excerpts_project_name_note_date;
+-- +goose StatementEnd
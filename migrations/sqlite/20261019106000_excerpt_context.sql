@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The blocks around an excerpt in its note, as HTML. Excerpts saved before this
+-- migration get their context the next time their note's excerpts are saved
+ALTER TABLE project_excerpts ADD COLUMN context_heading TEXT;
+ALTER TABLE project_excerpts ADD COLUMN context_parent TEXT;
+ALTER TABLE project_excerpts ADD COLUMN context_before TEXT;
+ALTER TABLE project_excerpts ADD COLUMN context_after TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE project_excerpts DROP COLUMN context_after;
+ALTER TABLE project_excerpts DROP COLUMN context_before;
+ALTER TABLE project_excerpts DROP COLUMN context_parent;
+ALTER TABLE project_excerpts DROP COLUMN context_heading;
+-- +goose StatementEnd
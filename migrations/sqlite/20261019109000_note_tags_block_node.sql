@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The block as the editor's ProseMirror JSON, so tag excerpts render like project excerpts
+ALTER TABLE note_tags ADD COLUMN block_node TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE note_tags DROP COLUMN block_node;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The version of the indexing that last ran over a note. Notes indexed by an older
+-- version, including every note saved before tags were indexed, are reindexed on startup
+ALTER TABLE notes ADD COLUMN index_version INTEGER NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE notes DROP COLUMN index_version;
+-- +goose StatementEnd
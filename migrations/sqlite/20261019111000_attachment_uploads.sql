@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Blobs are deduplicated across workspaces, a workspace can only read those it uploaded.
+-- Its rows expire after the grace period unless a note of that workspace references the blob
+CREATE TABLE attachment_uploads (
+    workspace_id INTEGER NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    hash TEXT NOT NULL REFERENCES attachments(hash) ON DELETE CASCADE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (workspace_id, hash)
+);
+
+CREATE INDEX idx_attachment_uploads_hash ON attachment_uploads(hash);
+
+-- Workspaces keep the attachments their notes reference, the rest were uploaded before
+-- workspaces existed and belong to the default one
+INSERT INTO attachment_uploads (workspace_id, hash, created_at)
+SELECT DISTINCT n.workspace_id, a.hash, a.created_at
+FROM attachments a
+JOIN note_attachments na ON na.hash = a.hash
+JOIN notes n ON n.id = na.note_id;
+
+INSERT INTO attachment_uploads (workspace_id, hash, created_at)
+SELECT 1, hash, created_at FROM attachments WHERE hash NOT IN (SELECT hash FROM note_attachments);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE attachment_uploads;
+-- +goose StatementEnd
@@ -0,0 +1,352 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	// Registered from Go as the tables other tables reference are rebuilt, which needs foreign keys off
+	goose.AddNamedMigrationNoTxContext("20261019108000_workspaces.go",
+		func(ctx context.Context, db *sql.DB) error {
+			return withoutForeignKeys(ctx, db, func(tx *sql.Tx) error {
+				return execAll(ctx, tx, workspacesUp())
+			})
+		},
+		func(ctx context.Context, db *sql.DB) error {
+			return withoutForeignKeys(ctx, db, func(tx *sql.Tx) error {
+				return execAll(ctx, tx, workspacesDown())
+			})
+		},
+	)
+}
+
+// tableRebuild replaces a table with a new definition, copying its rows across
+type tableRebuild struct {
+	table   string
+	create  string   // CREATE TABLE of the new definition, named <table>_rebuilt
+	columns string   // columns copied from the old table, new columns take their defaults
+	objects []string // indexes and triggers, which are dropped along with the old table
+}
+
+func (t tableRebuild) statements() []string {
+	rebuilt := t.table + "_rebuilt"
+
+	statements := []string{
+		t.create,
+		fmt.Sprintf(`INSERT INTO %s (%s) SELECT %s FROM %s`, rebuilt, t.columns, t.columns, t.table),
+		// Keep AUTOINCREMENT counters, so ids of deleted rows are not handed out again
+		fmt.Sprintf(`DELETE FROM sqlite_sequence WHERE name = '%s'`, rebuilt),
+		fmt.Sprintf(`INSERT INTO sqlite_sequence (name, seq) SELECT '%s', seq FROM sqlite_sequence WHERE name = '%s'`, rebuilt, t.table),
+		fmt.Sprintf(`DROP TABLE %s`, t.table),
+		fmt.Sprintf(`ALTER TABLE %s RENAME TO %s`, rebuilt, t.table),
+	}
+
+	return append(statements, t.objects...)
+}
+
+func execAll(ctx context.Context, tx *sql.Tx, statements []string) error {
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("%w: %s", err, statement)
+		}
+	}
+
+	return nil
+}
+
+// Triggers of the rebuilt tables that are the same either side of the migration
+const (
+	noteUpdateTrigger = `CREATE TRIGGER note_stats_invalidate_note_update AFTER UPDATE ON notes
+BEGIN
+    DELETE FROM note_stats WHERE note_id = OLD.id;
+END`
+	noteUpdatedAtTrigger = `CREATE TRIGGER notes_default_updated_at AFTER INSERT ON notes WHEN NEW.updated_at IS NULL
+BEGIN
+    UPDATE notes SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+END`
+	excerptInsertTrigger = `CREATE TRIGGER note_stats_invalidate_excerpt_insert AFTER INSERT ON project_excerpts
+BEGIN
+    DELETE FROM note_stats WHERE note_id = NEW.note_id;
+END`
+	excerptDeleteTrigger = `CREATE TRIGGER note_stats_invalidate_excerpt_delete AFTER DELETE ON project_excerpts
+BEGIN
+    DELETE FROM note_stats WHERE note_id = OLD.note_id;
+END`
+)
+
+// workspacesUp scopes projects, notes and the rows naming a project by workspace, existing rows go to workspace 1
+// Project names are unique per workspace, so references to them become (workspace_id, project_name) pairs
+func workspacesUp() []string {
+	statements := []string{
+		`CREATE TABLE workspaces (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+)`,
+		`INSERT INTO workspaces (id, name) VALUES (1, 'Default')`,
+		`CREATE TABLE users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+    -- hex sha256 of the user's API token
+    token_hash TEXT NOT NULL UNIQUE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+)`,
+		`CREATE TABLE workspace_members (
+    workspace_id INTEGER NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (workspace_id, user_id)
+)`,
+		`CREATE INDEX idx_workspace_members_user_id ON workspace_members(user_id)`,
+		`ALTER TABLE webhooks ADD COLUMN workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE`,
+	}
+
+	rebuilds := []tableRebuild{
+		{
+			table: "projects",
+			// parent_name has no foreign key, a composite one would clear workspace_id along with it on delete
+			create: `CREATE TABLE projects_rebuilt (
+    workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    parent_name TEXT,
+    target_date DATE,
+    PRIMARY KEY (workspace_id, name)
+)`,
+			columns: "name, created_at, parent_name, target_date",
+			objects: []string{
+				`CREATE INDEX idx_projects_parent_name ON projects(workspace_id, parent_name)`,
+				`CREATE TRIGGER projects_clear_references AFTER DELETE ON projects
+BEGIN
+    UPDATE projects SET parent_name = NULL WHERE workspace_id = OLD.workspace_id AND parent_name = OLD.name;
+    UPDATE reminders SET project_name = NULL WHERE project_name = OLD.name
+        AND note_id IN (SELECT id FROM notes WHERE workspace_id = OLD.workspace_id);
+END`,
+			},
+		},
+		{
+			table: "notes",
+			create: `CREATE TABLE notes_rebuilt (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+    html_content TEXT NOT NULL,
+    note_date DATE NOT NULL,
+    updated_at DATETIME,
+    UNIQUE (workspace_id, note_date)
+)`,
+			columns: "id, html_content, note_date, updated_at",
+			objects: []string{noteUpdateTrigger, noteUpdatedAtTrigger},
+		},
+		{
+			table: "project_excerpts",
+			create: `CREATE TABLE project_excerpts_rebuilt (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    workspace_id INTEGER NOT NULL DEFAULT 1,
+    project_name TEXT NOT NULL,
+    note_id INTEGER NOT NULL REFERENCES notes(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    excerpt TEXT NOT NULL,
+    note_date DATE NOT NULL,
+    context_heading TEXT,
+    context_parent TEXT,
+    context_before TEXT,
+    context_after TEXT,
+    FOREIGN KEY (workspace_id, project_name) REFERENCES projects(workspace_id, name) ON DELETE CASCADE ON UPDATE CASCADE
+)`,
+			columns: "id, project_name, note_id, excerpt, note_date, context_heading, context_parent, context_before, context_after",
+			objects: []string{
+				`CREATE INDEX idx_project_excerpts_note_id ON project_excerpts(note_id)`,
+				`CREATE INDEX idx_project_excerpts_project_name_note_date ON project_excerpts(workspace_id, project_name, note_date)`,
+				excerptInsertTrigger,
+				excerptDeleteTrigger,
+			},
+		},
+		{
+			table: "project_aliases",
+			create: `CREATE TABLE project_aliases_rebuilt (
+    workspace_id INTEGER NOT NULL DEFAULT 1,
+    alias TEXT NOT NULL COLLATE NOCASE,
+    project_name TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (workspace_id, alias),
+    FOREIGN KEY (workspace_id, project_name) REFERENCES projects(workspace_id, name) ON DELETE CASCADE ON UPDATE CASCADE
+)`,
+			columns: "alias, project_name, created_at",
+			objects: []string{
+				`CREATE INDEX idx_project_aliases_project_name ON project_aliases(workspace_id, project_name)`,
+			},
+		},
+		{
+			table: "time_entries",
+			create: `CREATE TABLE time_entries_rebuilt (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    workspace_id INTEGER NOT NULL DEFAULT 1,
+    project_name TEXT NOT NULL,
+    note_date DATE NOT NULL,
+    duration_minutes INTEGER NOT NULL CHECK (duration_minutes > 0),
+    started_at DATETIME,
+    ended_at DATETIME,
+    description TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (workspace_id, project_name) REFERENCES projects(workspace_id, name) ON DELETE CASCADE ON UPDATE CASCADE
+)`,
+			columns: "id, project_name, note_date, duration_minutes, started_at, ended_at, description, created_at",
+			objects: []string{
+				`CREATE INDEX idx_time_entries_note_date ON time_entries(workspace_id, note_date)`,
+				`CREATE INDEX idx_time_entries_project_name ON time_entries(workspace_id, project_name)`,
+			},
+		},
+		{
+			// Reminders belong to the workspace of their note, projects_clear_references replaces the project foreign key
+			table: "reminders",
+			create: `CREATE TABLE reminders_rebuilt (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    note_id INTEGER NOT NULL REFERENCES notes(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    project_name TEXT,
+    text TEXT NOT NULL,
+    due_date DATE NOT NULL,
+    -- manual reminders are created through the API, detected ones come from task items
+    source TEXT NOT NULL CHECK (source IN ('manual', 'detected')),
+    completed_at DATETIME,
+    fired_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+)`,
+			columns: "id, note_id, project_name, text, due_date, source, completed_at, fired_at, created_at",
+			objects: []string{
+				`CREATE INDEX idx_reminders_due_date ON reminders(due_date)`,
+				`CREATE INDEX idx_reminders_note_id ON reminders(note_id)`,
+			},
+		},
+	}
+
+	for _, rebuild := range rebuilds {
+		statements = append(statements, rebuild.statements()...)
+	}
+
+	return statements
+}
+
+// workspacesDown merges every workspace back into one, failing when two share a note date, project name or alias
+func workspacesDown() []string {
+	rebuilds := []tableRebuild{
+		{
+			table: "projects",
+			create: `CREATE TABLE projects_rebuilt (
+    name TEXT PRIMARY KEY NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    parent_name TEXT REFERENCES projects(name) ON DELETE SET NULL ON UPDATE CASCADE,
+    target_date DATE
+)`,
+			columns: "name, created_at, parent_name, target_date",
+			objects: []string{`CREATE INDEX idx_projects_parent_name ON projects(parent_name)`},
+		},
+		{
+			table: "notes",
+			create: `CREATE TABLE notes_rebuilt (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    html_content TEXT NOT NULL,
+    note_date DATE NOT NULL UNIQUE,
+    updated_at DATETIME
+)`,
+			columns: "id, html_content, note_date, updated_at",
+			objects: []string{noteUpdateTrigger, noteUpdatedAtTrigger},
+		},
+		{
+			table: "project_excerpts",
+			create: `CREATE TABLE project_excerpts_rebuilt (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    project_name TEXT NOT NULL REFERENCES projects(name) ON DELETE CASCADE ON UPDATE CASCADE,
+    note_id INTEGER NOT NULL REFERENCES notes(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    excerpt TEXT NOT NULL,
+    note_date DATE NOT NULL,
+    context_heading TEXT,
+    context_parent TEXT,
+    context_before TEXT,
+    context_after TEXT
+)`,
+			columns: "id, project_name, note_id, excerpt, note_date, context_heading, context_parent, context_before, context_after",
+			objects: []string{
+				`CREATE INDEX idx_project_excerpts_note_id ON project_excerpts(note_id)`,
+				`CREATE INDEX idx_project_excerpts_project_name_note_date ON project_excerpts(project_name, note_date)`,
+				excerptInsertTrigger,
+				excerptDeleteTrigger,
+			},
+		},
+		{
+			table: "project_aliases",
+			create: `CREATE TABLE project_aliases_rebuilt (
+    alias TEXT PRIMARY KEY NOT NULL COLLATE NOCASE,
+    project_name TEXT NOT NULL REFERENCES projects(name) ON DELETE CASCADE ON UPDATE CASCADE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+)`,
+			columns: "alias, project_name, created_at",
+			objects: []string{`CREATE INDEX idx_project_aliases_project_name ON project_aliases(project_name)`},
+		},
+		{
+			table: "time_entries",
+			create: `CREATE TABLE time_entries_rebuilt (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    project_name TEXT NOT NULL REFERENCES projects(name) ON DELETE CASCADE ON UPDATE CASCADE,
+    note_date DATE NOT NULL,
+    duration_minutes INTEGER NOT NULL CHECK (duration_minutes > 0),
+    started_at DATETIME,
+    ended_at DATETIME,
+    description TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+)`,
+			columns: "id, project_name, note_date, duration_minutes, started_at, ended_at, description, created_at",
+			objects: []string{
+				`CREATE INDEX idx_time_entries_note_date ON time_entries(note_date)`,
+				`CREATE INDEX idx_time_entries_project_name ON time_entries(project_name)`,
+			},
+		},
+		{
+			// SQLite cannot drop a column with a foreign key
+			table: "webhooks",
+			create: `CREATE TABLE webhooks_rebuilt (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    -- comma separated event names, empty matches every event
+    events TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+)`,
+			columns: "id, url, secret, events, created_at",
+		},
+		{
+			table: "reminders",
+			create: `CREATE TABLE reminders_rebuilt (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    note_id INTEGER NOT NULL REFERENCES notes(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    project_name TEXT REFERENCES projects(name) ON DELETE SET NULL ON UPDATE CASCADE,
+    text TEXT NOT NULL,
+    due_date DATE NOT NULL,
+    -- manual reminders are created through the API, detected ones come from task items
+    source TEXT NOT NULL CHECK (source IN ('manual', 'detected')),
+    completed_at DATETIME,
+    fired_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+)`,
+			columns: "id, note_id, project_name, text, due_date, source, completed_at, fired_at, created_at",
+			objects: []string{
+				`CREATE INDEX idx_reminders_due_date ON reminders(due_date)`,
+				`CREATE INDEX idx_reminders_note_id ON reminders(note_id)`,
+			},
+		},
+	}
+
+	var statements []string
+
+	for _, rebuild := range rebuilds {
+		statements = append(statements, rebuild.statements()...)
+	}
+
+	return append(statements,
+		`DROP TABLE workspace_members`,
+		`DROP TABLE users`,
+		`DROP TABLE workspaces`,
+	)
+}
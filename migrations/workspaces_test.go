@@ -0,0 +1,137 @@
+package migrations
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	_ "modernc.org/sqlite"
+)
+
+type WorkspacesSuite struct {
+	suite.Suite
+	db *sql.DB
+}
+
+func (s *WorkspacesSuite) SetupTest() {
+	s.db, _ = sql.Open("sqlite", "file::memory:?_pragma=foreign_keys(1)")
+	s.db.SetMaxOpenConns(1)
+
+	ctx := s.T().Context()
+
+	s.Require().NoError(setup())
+	s.Require().NoError(MigrateTo(ctx, s.db, 20261019107000))
+
+	for _, statement := range []string{
+		`INSERT INTO projects (name) VALUES ('Alpha'), ('Bravo')`,
+		`UPDATE projects SET parent_name = 'Alpha' WHERE name = 'Bravo'`,
+		`INSERT INTO project_aliases (alias, project_name) VALUES ('a', 'Alpha')`,
+		`INSERT INTO notes (id, html_content, note_date) VALUES (1, '<p>a</p>', '2026-10-18'), (5, '', '2026-10-19')`,
+		`DELETE FROM notes WHERE id = 5`,
+		`INSERT INTO project_excerpts (project_name, note_id, excerpt, note_date) VALUES ('Alpha', 1, 'a', '2026-10-18')`,
+		`INSERT INTO time_entries (project_name, note_date, duration_minutes) VALUES ('Alpha', '2026-10-18', 30)`,
+		`INSERT INTO reminders (note_id, project_name, text, due_date, source) VALUES (1, 'Bravo', 'ship', '2026-11-01', 'manual')`,
+		`INSERT INTO webhooks (url, secret) VALUES ('http://localhost', 'secret')`,
+	} {
+		_, err := s.db.ExecContext(ctx, statement)
+		s.Require().NoError(err, statement)
+	}
+
+	// Stop at the workspaces migration so Down rolls it back
+	s.Require().NoError(MigrateTo(ctx, s.db, 20261019108000))
+}
+
+func (s *WorkspacesSuite) TearDownTest() {
+	s.db.Close()
+}
+
+func (s *WorkspacesSuite) exec(statement string) error {
+	_, err := s.db.ExecContext(s.T().Context(), statement)
+	return err
+}
+
+func (s *WorkspacesSuite) count(query string) int {
+	var n int
+	s.Require().NoError(s.db.QueryRowContext(s.T().Context(), query).Scan(&n))
+	return n
+}
+
+func (s *WorkspacesSuite) TestUp_MovesRowsToDefaultWorkspace() {
+	for _, table := range []string{"projects", "notes", "project_excerpts", "project_aliases", "time_entries", "webhooks"} {
+		s.Equal(0, s.count(`SELECT COUNT(*) FROM `+table+` WHERE workspace_id != 1`), table)
+	}
+
+	s.Equal(2, s.count(`SELECT COUNT(*) FROM projects`))
+	s.Equal(1, s.count(`SELECT COUNT(*) FROM project_excerpts`))
+	s.Equal(1, s.count(`SELECT COUNT(*) FROM workspaces WHERE id = 1`))
+
+	// AUTOINCREMENT counters survive the rebuild
+	var id int
+	s.Require().NoError(s.db.QueryRowContext(s.T().Context(), `INSERT INTO notes (html_content, note_date) VALUES ('', '2026-10-20') RETURNING id`).Scan(&id))
+	s.Equal(6, id)
+}
+
+func (s *WorkspacesSuite) TestUp_UniquePerWorkspace() {
+	s.Require().NoError(s.exec(`INSERT INTO workspaces (id, name) VALUES (2, 'Team')`))
+
+	s.NoError(s.exec(`INSERT INTO projects (workspace_id, name) VALUES (2, 'Alpha')`))
+	s.NoError(s.exec(`INSERT INTO project_aliases (workspace_id, alias, project_name) VALUES (2, 'A', 'Alpha')`))
+	s.NoError(s.exec(`INSERT INTO notes (workspace_id, html_content, note_date) VALUES (2, '', '2026-10-18')`))
+
+	s.Error(s.exec(`INSERT INTO projects (workspace_id, name) VALUES (2, 'Alpha')`))
+	s.Error(s.exec(`INSERT INTO notes (workspace_id, html_content, note_date) VALUES (2, '', '2026-10-18')`))
+
+	// Rows cannot name a project of another workspace
+	s.Error(s.exec(`INSERT INTO project_excerpts (workspace_id, project_name, note_id, excerpt, note_date) VALUES (2, 'Bravo', 1, 'b', '2026-10-18')`))
+}
+
+func (s *WorkspacesSuite) TestUp_DeletesStayInWorkspace() {
+	s.Require().NoError(s.exec(`INSERT INTO workspaces (id, name) VALUES (2, 'Team')`))
+	s.Require().NoError(s.exec(`INSERT INTO projects (workspace_id, name, parent_name) VALUES (2, 'Alpha', NULL), (2, 'Bravo', 'Alpha')`))
+
+	s.Require().NoError(s.exec(`DELETE FROM projects WHERE workspace_id = 1 AND name = 'Alpha'`))
+
+	s.Equal(0, s.count(`SELECT COUNT(*) FROM project_excerpts`))
+	s.Equal(0, s.count(`SELECT COUNT(*) FROM project_aliases`))
+	s.Equal(0, s.count(`SELECT COUNT(*) FROM time_entries`))
+	s.Equal(1, s.count(`SELECT COUNT(*) FROM projects WHERE workspace_id = 1 AND parent_name IS NULL`))
+	s.Equal(1, s.count(`SELECT COUNT(*) FROM projects WHERE workspace_id = 2 AND parent_name = 'Alpha'`))
+
+	s.Require().NoError(s.exec(`DELETE FROM projects WHERE workspace_id = 1 AND name = 'Bravo'`))
+	s.Equal(1, s.count(`SELECT COUNT(*) FROM reminders WHERE project_name IS NULL`))
+
+	// Deleting a workspace takes its projects and notes along
+	s.Require().NoError(s.exec(`DELETE FROM workspaces WHERE id = 2`))
+	s.Equal(0, s.count(`SELECT COUNT(*) FROM projects WHERE workspace_id = 2`))
+}
+
+func (s *WorkspacesSuite) TestDown_RestoresSingleWorkspace() {
+	ctx := s.T().Context()
+
+	s.Require().NoError(Down(ctx, s.db))
+
+	s.Equal(0, s.count(`SELECT COUNT(*) FROM sqlite_schema WHERE name IN ('workspaces', 'users', 'workspace_members')`))
+	s.Equal(0, s.count(`SELECT COUNT(*) FROM pragma_table_info('notes') WHERE name = 'workspace_id'`))
+	s.Equal(1, s.count(`SELECT COUNT(*) FROM webhooks`))
+
+	s.Require().NoError(s.exec(`UPDATE projects SET name = 'Renamed' WHERE name = 'Alpha'`))
+	s.Equal(1, s.count(`SELECT COUNT(*) FROM project_excerpts WHERE project_name = 'Renamed'`))
+	s.Equal(1, s.count(`SELECT COUNT(*) FROM projects WHERE parent_name = 'Renamed'`))
+}
+
+func (s *WorkspacesSuite) TestDown_FailsOnSharedNames() {
+	ctx := s.T().Context()
+
+	s.Require().NoError(s.exec(`INSERT INTO workspaces (id, name) VALUES (2, 'Team')`))
+	s.Require().NoError(s.exec(`INSERT INTO projects (workspace_id, name) VALUES (2, 'Alpha')`))
+
+	s.Error(Down(ctx, s.db))
+
+	// The failed migration rolled back and left foreign keys on
+	s.Equal(2, s.count(`SELECT COUNT(*) FROM projects WHERE name = 'Alpha'`))
+	s.Equal(1, s.count(`PRAGMA foreign_keys`))
+}
+
+func TestWorkspacesSuite(t *testing.T) {
+	suite.Run(t, new(WorkspacesSuite))
+}